@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/config"
+	"github.com/john/go-react-test/api/internal/domain/backtest"
+	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+	"github.com/john/go-react-test/api/internal/infrastructure/repository"
+)
+
+func main() {
+	var (
+		fixture   = flag.String("fixture", "", "Load rating events from a JSON fixture instead of the database")
+		snapshot  = flag.String("snapshot", "", "Write the loaded rating events to a JSON fixture and exit")
+		algorithm = flag.String("algorithm", recommendation.AlgorithmLatest, "Recommendation algorithm: latest or consensus")
+		weights   = flag.String("weights", "", "Weights as priceChange,rating,action (default: scoring model)")
+		halfLife  = flag.Float64("half-life", -1, "Half-life in days for recency decay, 0 disables it (default: scoring model)")
+		modelFile = flag.String("scoring-model", "", "Scoring model JSON file (default: scoring_parameters table, or built-in defaults with -fixture)")
+		window    = flag.Duration("consensus-window", 90*24*time.Hour, "Window of calls aggregated by the consensus algorithm")
+		start     = flag.String("start", "", "First simulated date, YYYY-MM-DD (default: first event)")
+		end       = flag.String("end", "", "Last simulated date, YYYY-MM-DD (default: last event)")
+		step      = flag.Duration("step", 24*time.Hour, "Time between simulated dates")
+		horizon   = flag.Duration("horizon", 30*24*time.Hour, "Window after each date used to measure how recommendations evolved")
+		limit     = flag.Int("limit", 10, "Recommendations per simulated date")
+		format    = flag.String("format", "csv", "Report format: csv or json")
+		out       = flag.String("out", "", "Report file (default: stdout)")
+		help      = flag.Bool("help", false, "Show help message")
+	)
+	flag.Parse()
+
+	if *help {
+		showHelp()
+		return
+	}
+
+	ctx := context.Background()
+
+	// Historial de eventos y modelo de scoring: fixture local o base de datos local
+	var events []*stock.RatingEvent
+	var scoringRepo scoring.Repository
+	if *fixture == "" {
+		cfg, err := config.LoadLocal()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if err := database.Connect(cfg.DatabaseDSN()); err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer database.Close()
+
+		events, err = repository.NewCockroachRatingEventRepository().FindSince(ctx, nil, time.Time{})
+		if err != nil {
+			log.Fatalf("Failed to load rating events: %v", err)
+		}
+		scoringRepo = repository.NewCockroachScoringRepository()
+	}
+
+	if *modelFile != "" {
+		scoringRepo = repository.NewFileScoringRepository(*modelFile)
+	}
+	model := scoring.DefaultModel()
+	if scoringRepo != nil {
+		loaded, err := scoringRepo.Load(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load scoring model: %v", err)
+		}
+		model = loaded
+	}
+
+	// Los scores de los ratings del fixture salen del modelo del backtest
+	if *fixture != "" {
+		f, err := os.Open(*fixture)
+		if err != nil {
+			log.Fatalf("Failed to open fixture: %v", err)
+		}
+		events, err = backtest.LoadFixture(f, model)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to load fixture: %v", err)
+		}
+	}
+
+	if *snapshot != "" {
+		if err := writeFile(*snapshot, func(w io.Writer) error { return backtest.WriteFixture(w, events) }); err != nil {
+			log.Fatalf("Failed to write snapshot: %v", err)
+		}
+		log.Printf("Wrote %d rating events to %s", len(events), *snapshot)
+		return
+	}
+
+	// Criterios y rango simulado
+	backtestCfg := backtest.Config{Step: *step, Horizon: *horizon, Limit: *limit}
+	var err error
+	if backtestCfg.Criteria.Weights, err = parseWeights(*weights); err != nil {
+		log.Fatalf("Invalid -weights: %v", err)
+	}
+	if *halfLife >= 0 {
+		d := time.Duration(*halfLife * float64(24*time.Hour))
+		backtestCfg.Criteria.HalfLife = &d
+	}
+	if backtestCfg.Start, err = parseDate(*start); err != nil {
+		log.Fatalf("Invalid -start: %v", err)
+	}
+	if backtestCfg.End, err = parseDate(*end); err != nil {
+		log.Fatalf("Invalid -end: %v", err)
+	}
+
+	// El algoritmo ve el historial y el reloj simulados
+	clock := &backtest.Clock{}
+	store := backtest.NewStore(clock, events)
+	stockService := stock.NewDomainServiceWithClock(model, clock.Now)
+
+	var alg recommendation.Algorithm
+	switch *algorithm {
+	case recommendation.AlgorithmLatest:
+		alg = recommendation.NewRecommendationAlgorithm(stockService)
+	case recommendation.AlgorithmConsensus:
+		alg = recommendation.NewConsensusAlgorithmWithClock(stockService, store, *window, clock.Now)
+	default:
+		log.Fatalf("Unknown algorithm: %s", *algorithm)
+	}
+
+	report, err := backtest.Run(ctx, *algorithm, alg, store, clock, model, backtestCfg)
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	var write func(io.Writer) error
+	switch *format {
+	case "csv":
+		write = func(w io.Writer) error { return backtest.WriteCSV(w, report) }
+	case "json":
+		write = func(w io.Writer) error { return backtest.WriteJSON(w, report) }
+	default:
+		log.Fatalf("Unknown format: %s", *format)
+	}
+
+	if err := writeFile(*out, write); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	s := report.Summary
+	log.Printf("Replayed %d dates: %d recommendations (%d with a full horizon), avg target change %.2f%%, hit rate %.1f%%",
+		s.Steps, s.Picks, s.CompletePicks, s.AvgTargetChange, s.HitRate*100)
+}
+
+// parseWeights lee pesos con el formato priceChange,rating,action
+func parseWeights(value string) (*scoring.Weights, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected priceChange,rating,action")
+	}
+
+	values := make([]float64, 3)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	w := &scoring.Weights{PriceChange: values[0], Rating: values[1], Action: values[2]}
+	return w, w.Validate()
+}
+
+// parseDate lee una fecha YYYY-MM-DD (vacía retorna la fecha cero)
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// writeFile escribe en el archivo indicado o en stdout si path está vacío
+func writeFile(path string, write func(io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func showHelp() {
+	fmt.Println("Recommendation Backtest")
+	fmt.Println()
+	fmt.Println("Replays the rating event history day by day through a recommendation algorithm")
+	fmt.Println("and measures how the recommended tickers' targets and ratings evolved afterwards.")
+	fmt.Println("Reads the local database or a fixture file; it never calls the external API.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  go run ./cmd/backtest [flags]")
+	fmt.Println()
+	fmt.Println("Flags:")
+	flag.PrintDefaults()
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  go run ./cmd/backtest -snapshot events.json")
+	fmt.Println("  go run ./cmd/backtest -fixture events.json -weights 0.2,0.6,0.2 -format json -out report.json")
+	fmt.Println("  go run ./cmd/backtest -algorithm consensus -horizon 720h -limit 5")
+}
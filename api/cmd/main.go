@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,11 +10,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/john/go-react-test/api/internal/application/auth"
 	"github.com/john/go-react-test/api/internal/application/graphql"
 	"github.com/john/go-react-test/api/internal/application/handlers"
+	"github.com/john/go-react-test/api/internal/application/limits"
+	"github.com/john/go-react-test/api/internal/application/middleware"
+	"github.com/john/go-react-test/api/internal/application/persisted"
 	"github.com/john/go-react-test/api/internal/application/services"
 	"github.com/john/go-react-test/api/internal/config"
 	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 	"github.com/john/go-react-test/api/internal/infrastructure/database"
 	"github.com/john/go-react-test/api/internal/infrastructure/external"
@@ -35,41 +41,123 @@ func main() {
 
 	log.Println("Database connected successfully")
 
-	// Verificar si las migraciones ya están aplicadas
+	// Aplicar las migraciones pendientes (registradas en schema_migrations), incluidas las
+	// que agregan columnas o índices a una base ya existente
 	exists, err := database.CheckMigrations()
 	if err != nil {
 		log.Fatalf("Failed to check migrations: %v", err)
 	}
 
 	if !exists {
-		log.Println("Database not initialized, running migrations...")
+		log.Println("Pending migrations found, running migrations...")
 		if err := database.RunMigrations(); err != nil {
 			log.Fatalf("Failed to run migrations: %v", err)
 		}
 		log.Println("Migrations executed successfully")
 	} else {
-		log.Println("Database already initialized")
+		log.Println("Database schema is up to date")
 	}
 
 	// Inicializar dependencias
 	stockRepo := repository.NewCockroachStockRepository()
-	stockDomainSvc := stock.NewDomainService()
-	stockService := services.NewStockService(stockRepo, stockDomainSvc)
+	ratingEventRepo := repository.NewCockroachRatingEventRepository()
+
+	// Modelo de scoring: archivo de configuración, tabla scoring_parameters o valores por defecto
+	var scoringRepo scoring.Repository = repository.NewCockroachScoringRepository()
+	if cfg.Scoring.ModelFile != "" {
+		scoringRepo = repository.NewFileScoringRepository(cfg.Scoring.ModelFile)
+	}
+	scoringModel, err := scoringRepo.Load(context.Background())
+	if err != nil {
+		log.Printf("Failed to load scoring model, using defaults: %v", err)
+		scoringModel = scoring.DefaultModel()
+	}
+
+	stockDomainSvc := stock.NewDomainServiceWithModel(scoringModel)
+	stockService := services.NewStockService(stockRepo, ratingEventRepo, stockDomainSvc)
+
+	// Normalizador de ratings con los alias configurados en la base de datos
+	ratingNormalizer := stock.NewRatingNormalizer(scoringModel, nil)
+	ratingAliasService := services.NewRatingAliasService(repository.NewCockroachRatingAliasRepository(), ratingNormalizer)
+	if err := ratingAliasService.Load(context.Background()); err != nil {
+		log.Printf("Failed to load rating aliases, using built-in aliases only: %v", err)
+	}
+	if n, err := ratingAliasService.BackfillScores(context.Background()); err != nil {
+		log.Printf("Failed to backfill rating scores: %v", err)
+	} else if n > 0 {
+		log.Printf("Backfilled %d rating scores", n)
+	}
 
 	apiClient := external.NewKarenAIClient(cfg.API.BaseURL, cfg.API.APIKey)
-	syncService := services.NewSyncService(apiClient, stockRepo)
+	apiClient.SetRatingNormalizer(ratingNormalizer)
+	syncRunRepo := repository.NewCockroachSyncRunRepository()
+	quarantineRepo := repository.NewCockroachQuarantineRepository()
+	syncService := services.NewSyncService(apiClient, stockRepo, ratingEventRepo, syncRunRepo, quarantineRepo, cfg.Sync.Timeout, cfg.Sync.InstanceID)
+	quarantineService := services.NewQuarantineService(apiClient, syncService, quarantineRepo)
+
+	// Limpiar las ejecuciones que quedaron a medias antes de que el scheduler o una mutación encolen otras
+	if err := syncService.Recover(context.Background()); err != nil {
+		log.Printf("Failed to clean up unfinished sync runs: %v", err)
+	}
+
+	// Worker de sincronización y scheduler en segundo plano (fuera del ciclo de las requests HTTP)
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	syncService.Start(syncCtx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		services.NewSyncScheduler(syncService, cfg.Sync.Interval, cfg.Sync.RunOnStartup).Start(syncCtx)
+	}()
 
 	recommendationAlgorithm := recommendation.NewRecommendationAlgorithm(stockDomainSvc)
 	recommendationService := services.NewRecommendationService(stockService, recommendationAlgorithm)
+	recommendationService.RegisterAlgorithm(
+		recommendation.AlgorithmConsensus,
+		recommendation.NewConsensusAlgorithm(stockDomainSvc, ratingEventRepo, cfg.Recommendation.ConsensusWindow),
+	)
+
+	brokerageService := services.NewBrokerageService(stockService, ratingEventRepo)
 
 	// Inicializar GraphQL schema
-	graphqlSchema, err := graphql.NewSchema(stockService, syncService, recommendationService)
+	graphqlSchema, err := graphql.NewSchema(stockService, syncService, recommendationService, quarantineService, ratingAliasService, brokerageService)
 	if err != nil {
 		log.Fatalf("Failed to create GraphQL schema: %v", err)
 	}
 
+	// Autenticación por API key o JWT; el rol de cada cliente se valida campo a campo en el schema
+	authenticator, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
 	// Crear handler GraphQL
 	graphqlHandler := handlers.NewGraphQLHandler(graphqlSchema.GetSchema())
+	graphqlHandler.SetConnectionInit(authenticator.ConnectionInit)
+	graphqlHandler.SetAllowedOrigins(cfg.CORS.AllowedOrigins)
+	graphqlHandler.SetLimits(
+		limits.NewAnalyzer(graphqlSchema.GetSchema(), limits.Config{
+			MaxDepth:      cfg.Limits.MaxDepth,
+			MaxComplexity: cfg.Limits.MaxComplexity,
+			MaxLimit:      cfg.Limits.MaxLimit,
+		}),
+		limits.NewRateLimiter(cfg.Limits.RatePerSecond, cfg.Limits.RateBurst),
+	)
+
+	// Persisted queries: registro automático (APQ) o allowlist de queries para producción
+	persistedQueries, err := newPersistedQueries(cfg.Persisted)
+	if err != nil {
+		log.Fatalf("Failed to configure persisted queries: %v", err)
+	}
+	graphqlHandler.SetPersistedQueries(persistedQueries)
+
+	// Las queries por GET sobre datos de stocks se cachean hasta la próxima escritura en la base de datos (ETag) y durante max-age
+	graphqlHandler.SetCaching(handlers.CachePolicy{
+		Version:        repository.NewCockroachDataVersionRepository().Current,
+		MaxAge:         cfg.Cache.MaxAge,
+		Fields:         graphql.CacheableFields,
+		VolatileValues: graphql.VolatileArgumentValues,
+	})
 
 	// Configurar servidor HTTP
 	mux := http.NewServeMux()
@@ -80,27 +168,33 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
+	// CORS y headers de seguridad comunes a la API, el Playground y la documentación
+	web := middleware.Chain(
+		middleware.CORS(cfg.CORS),
+		middleware.SecurityHeaders(cfg.Security),
+	)
+
 	// GraphQL endpoint
-	mux.Handle("/query", graphqlHandler)
+	mux.Handle("/query", web(authenticator.Middleware(graphqlHandler)))
 
 	// GraphQL Playground (solo en desarrollo)
-	mux.Handle("/playground", handlers.PlaygroundHandler("GraphQL Playground", "/query"))
+	mux.Handle("/playground", web(handlers.PlaygroundHandler("GraphQL Playground", "/query")))
 
 	// Documentación
-	mux.HandleFunc("/docs", handlers.DocsHandler())
-	mux.HandleFunc("/docs/swagger", handlers.SwaggerUIHandler())
-	mux.HandleFunc("/docs/openapi.yaml", handlers.OpenAPISpecHandler())
+	mux.Handle("/docs", web(handlers.DocsHandler()))
+	mux.Handle("/docs/swagger", web(handlers.SwaggerUIHandler()))
+	mux.Handle("/docs/openapi.yaml", web(handlers.OpenAPISpecHandler()))
 	
 	// Documentación Markdown
-	mux.HandleFunc("/docs/api", handlers.MarkdownDocHandler("API_DOCUMENTATION.md"))
-	mux.HandleFunc("/docs/guide", handlers.MarkdownDocHandler("USER_GUIDE.md"))
-	mux.HandleFunc("/docs/graphql-reference", handlers.MarkdownDocHandler("GRAPHQL_API_REFERENCE.md"))
+	mux.Handle("/docs/api", web(handlers.MarkdownDocHandler("API_DOCUMENTATION.md")))
+	mux.Handle("/docs/guide", web(handlers.MarkdownDocHandler("USER_GUIDE.md")))
+	mux.Handle("/docs/graphql-reference", web(handlers.MarkdownDocHandler("GRAPHQL_API_REFERENCE.md")))
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      mux,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 60 * time.Second, // Aumentado para operaciones largas como syncStocks
+		WriteTimeout: 60 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
@@ -119,6 +213,9 @@ func main() {
 
 	log.Println("Shutting down server...")
 
+	// Detener el scheduler y cancelar la sincronización en curso
+	stopSync()
+
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -127,5 +224,60 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Esperar a que el scheduler y el worker terminen antes de cerrar la base de datos (defer)
+	<-schedulerDone
+	syncService.Stop()
+
 	log.Println("Server exited")
 }
+
+// newAuthenticator crea el autenticador a partir de la configuración de entorno
+func newAuthenticator(cfg config.AuthConfig) (*auth.Authenticator, error) {
+	authCfg := auth.Config{
+		JWT: auth.JWTConfig{
+			HS256Secret: cfg.JWTSecret,
+			JWKSFile:    cfg.JWKSFile,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+		},
+		AnonymousRole: auth.Role(cfg.AnonymousRole),
+	}
+	for _, key := range cfg.APIKeys {
+		authCfg.APIKeys = append(authCfg.APIKeys, auth.APIKey{Name: key.Name, Role: auth.Role(key.Role), Key: key.Key})
+	}
+	return auth.NewAuthenticator(authCfg)
+}
+
+// newPersistedQueries crea las persisted queries a partir de la configuración de entorno;
+// retorna nil si están desactivadas
+func newPersistedQueries(cfg config.PersistedQueriesConfig) (*persisted.Queries, error) {
+	var manifest map[string]string
+	if cfg.ManifestFile != "" {
+		var err error
+		if manifest, err = persisted.LoadManifest(cfg.ManifestFile); err != nil {
+			return nil, err
+		}
+	}
+
+	switch persisted.Mode(cfg.Mode) {
+	case "off":
+		return nil, nil
+	case persisted.ModeAllowlist:
+		if manifest == nil {
+			return nil, fmt.Errorf("PERSISTED_QUERIES_MANIFEST is required in allowlist mode")
+		}
+		log.Printf("Persisted queries: allowlist with %d queries", len(manifest))
+		return persisted.New(persisted.NewStaticStore(manifest), persisted.ModeAllowlist), nil
+	case persisted.ModeAutomatic:
+		// Las queries del manifiesto quedan registradas desde el inicio
+		store := persisted.NewMemoryStore(cfg.CacheSize)
+		for hash, query := range manifest {
+			if err := store.Put(context.Background(), hash, query); err != nil {
+				return nil, err
+			}
+		}
+		return persisted.New(store, persisted.ModeAutomatic), nil
+	default:
+		return nil, fmt.Errorf("invalid PERSISTED_QUERIES %q: must be apq, allowlist or off", cfg.Mode)
+	}
+}
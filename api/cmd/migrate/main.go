@@ -38,16 +38,19 @@ func main() {
 
 	// Ejecutar comando según flag
 	if *check {
-		exists, err := database.CheckMigrations()
+		pending, err := database.PendingMigrations()
 		if err != nil {
 			log.Fatalf("Error checking migrations: %v", err)
 		}
 
-		if exists {
-			fmt.Println("✓ Database is initialized: stocks table exists")
+		if len(pending) == 0 {
+			fmt.Println("✓ Database is up to date: all migrations are applied")
 			os.Exit(0)
 		} else {
-			fmt.Println("✗ Database is not initialized: stocks table does not exist")
+			fmt.Printf("✗ Database has %d pending migrations:\n", len(pending))
+			for _, name := range pending {
+				fmt.Printf("  - %s\n", name)
+			}
 			os.Exit(1)
 		}
 	}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/john/go-react-test/api/internal/infrastructure/websocket"
+)
+
+// APIKeyHeader es el header con la API key del cliente
+const APIKeyHeader = "X-API-Key"
+
+// APIKey es una API key con el nombre del cliente que la usa y su rol
+type APIKey struct {
+	Name string
+	Role Role
+	Key  string
+}
+
+// Config configura la autenticación
+type Config struct {
+	APIKeys []APIKey
+	JWT     JWTConfig
+
+	// AnonymousRole es el rol de las peticiones sin credenciales; vacío las rechaza
+	AnonymousRole Role
+}
+
+// Authenticator identifica al cliente de cada petición a partir de sus credenciales
+type Authenticator struct {
+	apiKeys       map[[sha256.Size]byte]APIKey // Por hash de la key
+	jwt           *JWTVerifier
+	anonymousRole Role
+}
+
+// NewAuthenticator crea un autenticador a partir de la configuración
+func NewAuthenticator(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{
+		apiKeys: make(map[[sha256.Size]byte]APIKey, len(cfg.APIKeys)),
+	}
+
+	if cfg.AnonymousRole != "" {
+		role, err := ParseRole(string(cfg.AnonymousRole))
+		if err != nil {
+			return nil, fmt.Errorf("invalid anonymous role: %w", err)
+		}
+		a.anonymousRole = role
+	}
+
+	for _, key := range cfg.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			return nil, errors.New("API keys require a name and a key")
+		}
+		role, err := ParseRole(string(key.Role))
+		if err != nil {
+			return nil, fmt.Errorf("API key %q: %w", key.Name, err)
+		}
+		key.Role = role
+		hash := sha256.Sum256([]byte(key.Key))
+		if _, exists := a.apiKeys[hash]; exists {
+			return nil, fmt.Errorf("API key %q is duplicated", key.Name)
+		}
+		a.apiKeys[hash] = key
+	}
+
+	verifier, err := NewJWTVerifier(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+	a.jwt = verifier
+
+	return a, nil
+}
+
+// Authenticate identifica al cliente por su API key o su token Bearer.
+// Sin credenciales retorna la identidad anónima, si está permitida;
+// credenciales inválidas siempre son un error.
+func (a *Authenticator) Authenticate(apiKey, authorization string) (*Identity, error) {
+	if apiKey != "" {
+		return a.authenticateAPIKey(apiKey)
+	}
+
+	if authorization != "" {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return nil, fmt.Errorf("%w: unsupported authorization scheme", ErrUnauthenticated)
+		}
+		return a.authenticateToken(strings.TrimSpace(token))
+	}
+
+	if a.anonymousRole == "" {
+		return nil, fmt.Errorf("%w: credentials required", ErrUnauthenticated)
+	}
+	return &Identity{Role: a.anonymousRole, Method: MethodAnonymous}, nil
+}
+
+// authenticateAPIKey busca la API key comparando su hash en tiempo constante
+func (a *Authenticator) authenticateAPIKey(value string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(value))
+	for known, key := range a.apiKeys {
+		if subtle.ConstantTimeCompare(known[:], hash[:]) == 1 {
+			return &Identity{Subject: key.Name, Role: key.Role, Method: MethodAPIKey}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: invalid API key", ErrUnauthenticated)
+}
+
+// authenticateToken valida un JWT
+func (a *Authenticator) authenticateToken(token string) (*Identity, error) {
+	if a.jwt == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrUnauthenticated)
+	}
+	id, err := a.jwt.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	return id, nil
+}
+
+// Middleware autentica las peticiones HTTP y guarda la identidad en su contexto.
+// Las peticiones sin credenciales válidas se rechazan con 401; los preflight CORS pasan sin autenticar
+// y los handshakes WebSocket sin credenciales pueden autenticarse después en connection_init.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, authorization := r.Header.Get(APIKeyHeader), r.Header.Get("Authorization")
+		if r.Method == http.MethodOptions ||
+			(apiKey == "" && authorization == "" && websocket.IsUpgradeRequest(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := a.Authenticate(apiKey, authorization)
+		if err != nil {
+			writeUnauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
+
+// ConnectionInit autentica con las credenciales del payload de connection_init de una
+// conexión WebSocket, ya que los navegadores no permiten enviar headers en el handshake.
+// Acepta las claves "Authorization" y "X-API-Key" (sin distinguir mayúsculas) o "apiKey".
+// Sin credenciales conserva la identidad con la que se abrió la conexión o, si no la hay,
+// usa la anónima.
+func (a *Authenticator) ConnectionInit(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+	var apiKey, authorization string
+	for key, value := range payload {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.EqualFold(key, APIKeyHeader), key == "apiKey":
+			apiKey = s
+		case strings.EqualFold(key, "Authorization"):
+			authorization = s
+		}
+	}
+	if _, ok := FromContext(ctx); ok && apiKey == "" && authorization == "" {
+		return ctx, nil
+	}
+
+	id, err := a.Authenticate(apiKey, authorization)
+	if err != nil {
+		return ctx, err
+	}
+	return WithIdentity(ctx, id), nil
+}
+
+// writeUnauthorized responde 401 con el error en el formato de GraphQL
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{
+			{"message": err.Error()},
+		},
+	})
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthenticator(t *testing.T, anonymous Role) *Authenticator {
+	t.Helper()
+
+	a, err := NewAuthenticator(Config{
+		APIKeys: []APIKey{
+			{Name: "frontend", Role: RoleViewer, Key: "viewer-key"},
+			{Name: "ops", Role: "Operator", Key: "operator-key"},
+		},
+		JWT:           JWTConfig{HS256Secret: testSecret},
+		AnonymousRole: anonymous,
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func TestNewAuthenticator_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"unknown key role", Config{APIKeys: []APIKey{{Name: "ops", Role: "root", Key: "k"}}}, `API key "ops": invalid role`},
+		{"key without name", Config{APIKeys: []APIKey{{Role: RoleViewer, Key: "k"}}}, "require a name and a key"},
+		{"duplicated key", Config{APIKeys: []APIKey{{Name: "a", Role: RoleViewer, Key: "k"}, {Name: "b", Role: RoleAdmin, Key: "k"}}}, `API key "b" is duplicated`},
+		{"unknown anonymous role", Config{AnonymousRole: "guest"}, "invalid anonymous role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthenticator(tt.cfg)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	a := newTestAuthenticator(t, RoleViewer)
+
+	tests := []struct {
+		name          string
+		apiKey        string
+		authorization string
+		want          *Identity
+		wantErr       string
+	}{
+		{
+			name:   "api key",
+			apiKey: "operator-key",
+			want:   &Identity{Subject: "ops", Role: RoleOperator, Method: MethodAPIKey},
+		},
+		{
+			name:          "bearer token",
+			authorization: "Bearer " + signHS256(t, testSecret, validClaims(map[string]interface{}{"role": "admin"})),
+			want:          &Identity{Subject: "user-1", Role: RoleAdmin, Method: MethodJWT},
+		},
+		{
+			name: "anonymous",
+			want: &Identity{Role: RoleViewer, Method: MethodAnonymous},
+		},
+		{
+			name:    "unknown api key",
+			apiKey:  "guess",
+			wantErr: "unauthenticated: invalid API key",
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer " + signHS256(t, "other", validClaims(nil)),
+			wantErr:       "unauthenticated: invalid token signature",
+		},
+		{
+			name:          "unsupported scheme",
+			authorization: "Basic dXNlcjpwYXNz",
+			wantErr:       "unsupported authorization scheme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(tt.apiKey, tt.authorization)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrUnauthenticated)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuthenticator_AnonymousDisabled(t *testing.T) {
+	a := newTestAuthenticator(t, "")
+
+	_, err := a.Authenticate("", "")
+	assert.ErrorContains(t, err, "credentials required")
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	a := newTestAuthenticator(t, "")
+
+	var seen *Identity
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("authenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.Header.Set(APIKeyHeader, "viewer-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, &Identity{Subject: "frontend", Role: RoleViewer, Method: MethodAPIKey}, seen)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		var body struct {
+			Errors []struct{ Message string }
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, "unauthenticated: credentials required", body.Errors[0].Message)
+	})
+
+	t.Run("preflight and websocket handshake pass through", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodOptions, "/query", nil),
+			func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/query", nil)
+				r.Header.Set("Connection", "Upgrade")
+				r.Header.Set("Upgrade", "websocket")
+				return r
+			}(),
+		} {
+			seen = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Nil(t, seen)
+		}
+	})
+}
+
+func TestAuthenticator_ConnectionInit(t *testing.T) {
+	a := newTestAuthenticator(t, "")
+
+	ctx, err := a.ConnectionInit(context.Background(), map[string]interface{}{
+		"Authorization": "Bearer " + signHS256(t, testSecret, validClaims(map[string]interface{}{"role": "operator"})),
+	})
+	require.NoError(t, err)
+	id, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, RoleOperator, id.Role)
+
+	ctx, err = a.ConnectionInit(context.Background(), map[string]interface{}{"apiKey": "viewer-key"})
+	require.NoError(t, err)
+	id, _ = FromContext(ctx)
+	assert.Equal(t, "frontend", id.Subject)
+
+	// Sin credenciales se conserva la identidad del handshake
+	handshake := WithIdentity(context.Background(), &Identity{Subject: "ops", Role: RoleOperator, Method: MethodAPIKey})
+	ctx, err = a.ConnectionInit(handshake, nil)
+	require.NoError(t, err)
+	assert.Equal(t, handshake, ctx)
+
+	// Sin credenciales ni identidad previa se rechaza si no hay acceso anónimo
+	_, err = a.ConnectionInit(context.Background(), map[string]interface{}{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = a.ConnectionInit(context.Background(), map[string]interface{}{"x-api-key": "guess"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestRequire(t *testing.T) {
+	resolve := Require(RoleOperator, func(p graphql.ResolveParams) (interface{}, error) {
+		return "done", nil
+	})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{"without identity", context.Background(), ErrUnauthenticated},
+		{"viewer", WithIdentity(context.Background(), &Identity{Role: RoleViewer}), ErrForbidden},
+		{"operator", WithIdentity(context.Background(), &Identity{Role: RoleOperator}), nil},
+		{"admin", WithIdentity(context.Background(), &Identity{Role: RoleAdmin}), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolve(graphql.ResolveParams{Context: tt.ctx})
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "done", got)
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole(" Admin ")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, role)
+
+	_, err = ParseRole("root")
+	assert.Error(t, err)
+
+	assert.True(t, RoleAdmin.Includes(RoleOperator))
+	assert.True(t, RoleOperator.Includes(RoleOperator))
+	assert.False(t, RoleViewer.Includes(RoleOperator))
+	assert.False(t, Role("").Includes(RoleViewer))
+}
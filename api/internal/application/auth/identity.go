@@ -0,0 +1,103 @@
+// Package auth autentica las peticiones a la API (API keys y JWT) y autoriza
+// el acceso a cada campo del schema GraphQL según el rol del cliente.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+var (
+	// ErrUnauthenticated indica credenciales ausentes o inválidas
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden indica que el rol del cliente no alcanza para la operación
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Role es el nivel de acceso de un cliente; cada rol incluye los permisos de los anteriores
+type Role string
+
+// Roles disponibles, de menor a mayor
+const (
+	RoleViewer   Role = "viewer"   // Consultas y suscripciones
+	RoleOperator Role = "operator" // Además sincronizaciones y cuarentena
+	RoleAdmin    Role = "admin"    // Además configuración (alias de ratings)
+)
+
+// roleLevels ordena los roles para compararlos
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole convierte un string (sin distinguir mayúsculas) en un rol válido
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("invalid role: %q", value)
+	}
+	return role, nil
+}
+
+// Includes retorna true si el rol tiene al menos los permisos de required
+func (r Role) Includes(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+// Métodos de autenticación
+const (
+	MethodAnonymous = "anonymous"
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+)
+
+// Identity es el cliente autenticado de una petición
+type Identity struct {
+	Subject string // Nombre de la API key o claim sub del JWT
+	Role    Role
+	Method  string
+}
+
+// identityKey es la clave del contexto donde se guarda la identidad
+type identityKey struct{}
+
+// WithIdentity retorna un contexto con la identidad del cliente
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext retorna la identidad del cliente, si la petición pasó por la autenticación
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// Authorize verifica que el cliente del contexto tenga el rol requerido
+func Authorize(ctx context.Context, required Role) error {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: authentication required", ErrUnauthenticated)
+	}
+	if !id.Role.Includes(required) {
+		return fmt.Errorf("%w: requires %s role", ErrForbidden, required)
+	}
+	return nil
+}
+
+// Require envuelve un resolver (o la función Subscribe de una suscripción)
+// para que solo lo ejecuten clientes con el rol requerido
+func Require(required Role, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if err := Authorize(p.Context, required); err != nil {
+			return nil, err
+		}
+		return resolve(p)
+	}
+}
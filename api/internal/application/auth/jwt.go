@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtLeeway es la tolerancia a diferencias de reloj al validar exp y nbf
+const jwtLeeway = 30 * time.Second
+
+// JWTConfig configura la validación de tokens JWT
+type JWTConfig struct {
+	HS256Secret string // Secreto compartido para tokens HS256; vacío los rechaza
+	JWKSFile    string // Archivo JWKS con las claves públicas RSA para tokens RS256; vacío los rechaza
+	Issuer      string // Claim iss requerido; vacío no lo valida
+	Audience    string // Claim aud requerido; vacío no lo valida
+}
+
+// JWTVerifier valida tokens JWT firmados con HS256 o RS256
+type JWTVerifier struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey // Por kid
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// jwtHeader es la cabecera de un JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims son los claims que usa la API
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Role      string   `json:"role"`
+	Roles     []string `json:"roles"`
+}
+
+// audience acepta el claim aud como string o como lista
+type audience []string
+
+// UnmarshalJSON implementa json.Unmarshaler
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("invalid aud claim")
+	}
+	*a = list
+	return nil
+}
+
+// jwks es un JSON Web Key Set
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// NewJWTVerifier crea un verificador; retorna nil si no hay secreto ni JWKS configurados
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.HS256Secret == "" && cfg.JWKSFile == "" {
+		return nil, nil
+	}
+
+	v := &JWTVerifier{
+		secret:   []byte(cfg.HS256Secret),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		now:      time.Now,
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		if v.keys, err = ParseJWKS(data); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// ParseJWKS extrae las claves públicas RSA de un JWKS, indexadas por kid.
+// Se ignoran las claves de otros tipos y las que no son de firma.
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: bad modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid JWKS key %q: bad exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// Verify valida la firma y los claims de un token y retorna la identidad del cliente.
+// Los tokens sin claim de rol reciben el rol viewer.
+func (v *JWTVerifier) Verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid token signature encoding")
+	}
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	role, err := claimsRole(claims)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Subject: claims.Subject, Role: role, Method: MethodJWT}, nil
+}
+
+// verifySignature valida la firma con la clave del algoritmo declarado.
+// El algoritmo determina el tipo de clave, así que un token no puede forzar otra combinación.
+func (v *JWTVerifier) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	switch header.Alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "RS256":
+		key, err := v.rsaKey(header.Kid)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported token algorithm: %q", header.Alg)
+	}
+}
+
+// rsaKey retorna la clave del kid; sin kid solo se acepta si el JWKS tiene una única clave
+func (v *JWTVerifier) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if len(v.keys) == 0 {
+		return nil, errors.New("RS256 tokens are not accepted")
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown token key id: %q", kid)
+	}
+	return key, nil
+}
+
+// validateClaims valida vigencia, emisor y audiencia
+func (v *JWTVerifier) validateClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return errors.New("token has no expiration")
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return errors.New("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return errors.New("invalid token issuer")
+	}
+	if v.audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == v.audience {
+				return nil
+			}
+		}
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+// claimsRole retorna el rol del claim role, o el mayor de los reconocidos en roles
+func claimsRole(claims jwtClaims) (Role, error) {
+	if claims.Role != "" {
+		return ParseRole(claims.Role)
+	}
+	var best Role
+	for _, value := range claims.Roles {
+		role, err := ParseRole(value)
+		if err != nil {
+			continue
+		}
+		if best == "" || role.Includes(best) {
+			best = role
+		}
+	}
+	if best == "" {
+		return RoleViewer, nil
+	}
+	return best, nil
+}
+
+// decodeSegment decodifica un segmento base64url de un JWT como JSON
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("bad encoding")
+	}
+	return json.Unmarshal(data, v)
+}
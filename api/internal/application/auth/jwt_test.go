@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// signHS256 genera un token HS256 con los claims dados
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := encodeSegment(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 genera un token RS256 firmado con key e identificado por kid
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := encodeSegment(t, map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// writeJWKS escribe un JWKS con las claves públicas dadas por kid y retorna su ruta
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+
+	var set []map[string]string
+	for kid, key := range keys {
+		set = append(set, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": set})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func validClaims(extra map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: testSecret, Issuer: "stocks", Audience: "api"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Identity
+		wantErr string
+	}{
+		{
+			name:  "valid token with role",
+			token: signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "api", "role": "operator"})),
+			want:  &Identity{Subject: "user-1", Role: RoleOperator, Method: MethodJWT},
+		},
+		{
+			name:  "highest recognised role of the list",
+			token: signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": []string{"web", "api"}, "roles": []string{"viewer", "billing", "admin"}})),
+			want:  &Identity{Subject: "user-1", Role: RoleAdmin, Method: MethodJWT},
+		},
+		{
+			name:  "viewer without role claims",
+			token: signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "api"})),
+			want:  &Identity{Subject: "user-1", Role: RoleViewer, Method: MethodJWT},
+		},
+		{
+			name:    "wrong secret",
+			token:   signHS256(t, "other", validClaims(map[string]interface{}{"iss": "stocks", "aud": "api"})),
+			wantErr: "invalid token signature",
+		},
+		{
+			name:    "expired",
+			token:   signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()})),
+			wantErr: "token expired",
+		},
+		{
+			name:    "without expiration",
+			token:   signHS256(t, testSecret, map[string]interface{}{"sub": "user-1", "iss": "stocks", "aud": "api"}),
+			wantErr: "token has no expiration",
+		},
+		{
+			name:    "not valid yet",
+			token:   signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "api", "nbf": time.Now().Add(time.Hour).Unix()})),
+			wantErr: "token not valid yet",
+		},
+		{
+			name:    "wrong issuer",
+			token:   signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "other", "aud": "api"})),
+			wantErr: "invalid token issuer",
+		},
+		{
+			name:    "wrong audience",
+			token:   signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "web"})),
+			wantErr: "invalid token audience",
+		},
+		{
+			name:    "unknown role",
+			token:   signHS256(t, testSecret, validClaims(map[string]interface{}{"iss": "stocks", "aud": "api", "role": "root"})),
+			wantErr: "invalid role",
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-token",
+			wantErr: "malformed token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJWTVerifier_RS256(t *testing.T) {
+	key1, key2, unknown := generateKey(t), generateKey(t), generateKey(t)
+	verifier, err := NewJWTVerifier(JWTConfig{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"k1": key1, "k2": key2})})
+	require.NoError(t, err)
+
+	got, err := verifier.Verify(signRS256(t, key2, "k2", validClaims(map[string]interface{}{"role": "admin"})))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "user-1", Role: RoleAdmin, Method: MethodJWT}, got)
+
+	// Firmado con otra clave del JWKS
+	_, err = verifier.Verify(signRS256(t, key1, "k2", validClaims(nil)))
+	assert.ErrorContains(t, err, "invalid token signature")
+
+	// Clave que no está en el JWKS
+	_, err = verifier.Verify(signRS256(t, unknown, "k3", validClaims(nil)))
+	assert.ErrorContains(t, err, "unknown token key id")
+
+	// Sin kid solo se acepta si el JWKS tiene una única clave
+	_, err = verifier.Verify(signRS256(t, key1, "", validClaims(nil)))
+	assert.ErrorContains(t, err, "unknown token key id")
+
+	// Sin secreto configurado no se aceptan tokens HS256
+	_, err = verifier.Verify(signHS256(t, testSecret, validClaims(nil)))
+	assert.ErrorContains(t, err, "HS256 tokens are not accepted")
+}
+
+func TestJWTVerifier_RejectsUnsignedTokens(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: testSecret})
+	require.NoError(t, err)
+
+	token := encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, validClaims(map[string]interface{}{"role": "admin"})) + "."
+	_, err = verifier.Verify(token)
+	assert.ErrorContains(t, err, "unsupported token algorithm")
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, verifier)
+
+	_, err = NewJWTVerifier(JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.ErrorContains(t, err, "failed to read JWKS file")
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"ec","crv":"P-256"}]}`))
+	assert.ErrorContains(t, err, "no RSA signing keys")
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"bad","n":"AQAB","e":"!!"}]}`))
+	assert.ErrorContains(t, err, "bad exponent")
+
+	keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"k1","n":"AQAB","e":"AQAB"},{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}]}`))
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, 65537, keys["k1"].E)
+}
@@ -0,0 +1,24 @@
+package graphql
+
+import "github.com/john/go-react-test/api/internal/domain/stock"
+
+// CacheableFields son los campos de Query que se pueden cachear por GET: su respuesta solo
+// cambia al escribir stocks, eventos, alias o parámetros de scoring (ver
+// repository.CockroachDataVersionRepository). Las ejecuciones de
+// sincronización y la cuarentena cambian en cada ejecución, y las recomendaciones decaen con
+// la antigüedad de las llamadas, así que no se cachean.
+var CacheableFields = []string{
+	"stocks",
+	"stock",
+	"stockStats",
+	"search",
+	"history",
+	"brokerages",
+	"scoringModel",
+	"ratingAliases",
+	"normalizeRating",
+}
+
+// VolatileArgumentValues son los valores de argumentos con los que una query cacheable
+// depende del momento de la consulta: el orden por score aplica el decaimiento por antigüedad
+var VolatileArgumentValues = []string{"SCORE", stock.SortScore}
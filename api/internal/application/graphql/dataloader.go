@@ -129,3 +129,63 @@ func (l *StockLoader) Prime(ctx context.Context, ticker string, stock *stock.Sto
 	key := StockLoaderKey(ticker)
 	l.loader.Prime(ctx, key, stock)
 }
+
+// historyKey identifica el historial de un ticker con un limit
+type historyKey struct {
+	ticker string
+	limit  int
+}
+
+// HistoryLoader agrupa en una consulta las cargas del historial de los stocks de una lista
+// (campo Stock.history). No cachea resultados: solo agrupa las cargas de una misma ejecución.
+type HistoryLoader struct {
+	loader *dataloader.Loader[historyKey, []*stock.RatingEvent]
+}
+
+// NewHistoryLoader crea un nuevo DataLoader para el historial de eventos
+func NewHistoryLoader(stockService *services.StockService) *HistoryLoader {
+	return &HistoryLoader{
+		loader: dataloader.NewBatchedLoader(
+			func(ctx context.Context, keys []historyKey) []*dataloader.Result[[]*stock.RatingEvent] {
+				results := make([]*dataloader.Result[[]*stock.RatingEvent], len(keys))
+
+				// Una consulta por cada limit distinto (normalmente uno solo)
+				byLimit := make(map[int][]string)
+				for _, key := range keys {
+					byLimit[key.limit] = append(byLimit[key.limit], key.ticker)
+				}
+				histories := make(map[historyKey][]*stock.RatingEvent, len(keys))
+				errs := make(map[int]error)
+				for limit, tickers := range byLimit {
+					events, err := stockService.GetHistories(ctx, tickers, limit)
+					if err != nil {
+						errs[limit] = err
+						continue
+					}
+					for ticker, history := range events {
+						histories[historyKey{ticker: ticker, limit: limit}] = history
+					}
+				}
+
+				for i, key := range keys {
+					if err := errs[key.limit]; err != nil {
+						results[i] = &dataloader.Result[[]*stock.RatingEvent]{Error: err}
+						continue
+					}
+					results[i] = &dataloader.Result[[]*stock.RatingEvent]{Data: histories[key]}
+				}
+				return results
+			},
+			dataloader.WithCache[historyKey, []*stock.RatingEvent](&dataloader.NoCache[historyKey, []*stock.RatingEvent]{}),
+			dataloader.WithBatchCapacity[historyKey, []*stock.RatingEvent](stock.MaxHistoryLimit),
+			// Las claves de una lista se registran antes de llamar al primer thunk: basta una espera corta
+			dataloader.WithWait[historyKey, []*stock.RatingEvent](time.Millisecond),
+		),
+	}
+}
+
+// Load retorna una función que espera el historial del ticker; las cargas registradas antes
+// de llamarla se resuelven en el mismo batch
+func (l *HistoryLoader) Load(ctx context.Context, ticker string, limit int) func() ([]*stock.RatingEvent, error) {
+	return l.loader.Load(ctx, historyKey{ticker: ticker, limit: limit})
+}
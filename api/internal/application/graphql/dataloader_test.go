@@ -0,0 +1,51 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/john/go-react-test/api/internal/application/services"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHistoryRepository retorna un evento por ticker y registra las consultas en batch
+type fakeHistoryRepository struct {
+	stock.RatingEventRepository
+	calls  [][]string
+	limits []int
+}
+
+func (f *fakeHistoryRepository) FindByTickers(ctx context.Context, tickers []string, limit int) ([]*stock.RatingEvent, error) {
+	f.calls = append(f.calls, tickers)
+	f.limits = append(f.limits, limit)
+	events := make([]*stock.RatingEvent, len(tickers))
+	for i, ticker := range tickers {
+		events[i] = &stock.RatingEvent{Ticker: ticker, Brokerage: "UBS"}
+	}
+	return events, nil
+}
+
+func TestHistoryLoader_Batches(t *testing.T) {
+	repo := &fakeHistoryRepository{}
+	loader := NewHistoryLoader(services.NewStockService(nil, repo, stock.NewDomainService()))
+	ctx := context.Background()
+
+	// Se registran todas las cargas antes de esperar la primera, como con los thunks de graphql-go
+	thunks := map[string]func() ([]*stock.RatingEvent, error){}
+	for _, ticker := range []string{"AAPL", "MSFT", "NFLX"} {
+		thunks[ticker] = loader.Load(ctx, ticker, 0)
+	}
+	for ticker, thunk := range thunks {
+		events, err := thunk()
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, ticker, events[0].Ticker)
+	}
+
+	require.Len(t, repo.calls, 1)
+	assert.ElementsMatch(t, []string{"AAPL", "MSFT", "NFLX"}, repo.calls[0])
+	// limit fuera de rango se acota a 1
+	assert.Equal(t, []int{1}, repo.limits)
+}
@@ -1,12 +1,22 @@
 package graphql
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
 	"github.com/john/go-react-test/api/internal/application/services"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
+	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
 )
 
 // Resolver contiene los resolvers de GraphQL
@@ -14,6 +24,10 @@ type Resolver struct {
 	stockService         *services.StockService
 	syncService         *services.SyncService
 	recommendationService *services.RecommendationService
+	quarantineService    *services.QuarantineService
+	ratingAliasService   *services.RatingAliasService
+	brokerageService     *services.BrokerageService
+	historyLoader        *HistoryLoader
 }
 
 // NewResolver crea un nuevo resolver
@@ -21,11 +35,18 @@ func NewResolver(
 	stockService *services.StockService,
 	syncService *services.SyncService,
 	recommendationService *services.RecommendationService,
+	quarantineService *services.QuarantineService,
+	ratingAliasService *services.RatingAliasService,
+	brokerageService *services.BrokerageService,
 ) *Resolver {
 	return &Resolver{
 		stockService:         stockService,
 		syncService:          syncService,
 		recommendationService: recommendationService,
+		quarantineService:    quarantineService,
+		ratingAliasService:   ratingAliasService,
+		brokerageService:     brokerageService,
+		historyLoader:        NewHistoryLoader(stockService),
 	}
 }
 
@@ -62,41 +83,10 @@ func (r *Resolver) Stocks(p graphql.ResolveParams) (interface{}, error) {
 		}
 	}
 
-	// Convertir filtros con manejo robusto de tipos
-	domainFilter := stock.Filter{}
-	if filter, ok := p.Args["filter"].(map[string]interface{}); ok && filter != nil {
-		// Ticker
-		if tickerVal, ok := filter["ticker"]; ok && tickerVal != nil {
-			if ticker, ok := tickerVal.(string); ok && ticker != "" {
-				domainFilter.Ticker = ticker
-			}
-		}
-		
-		// CompanyName
-		if companyNameVal, ok := filter["companyName"]; ok && companyNameVal != nil {
-			if companyName, ok := companyNameVal.(string); ok && companyName != "" {
-				domainFilter.CompanyName = companyName
-			}
-		}
-		
-		// Action
-		if actionVal, ok := filter["action"]; ok && actionVal != nil {
-			if action, ok := actionVal.(string); ok && action != "" {
-				domainFilter.Action = action
-			}
-		}
-		
-		// Ratings - Solo usar "ratings" (plural)
-		if ratingsVal, ok := filter["ratings"]; ok && ratingsVal != nil {
-			if ratings, ok := ratingsVal.([]interface{}); ok {
-				domainFilter.Ratings = make([]stock.Rating, 0, len(ratings))
-				for _, r := range ratings {
-					if ratingStr, ok := r.(string); ok && ratingStr != "" {
-						domainFilter.Ratings = append(domainFilter.Ratings, stock.Rating(ratingStr))
-					}
-				}
-			}
-		}
+	// Convertir filtros (recursivos con and, or y not)
+	domainFilter, err := parseStockFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
 	}
 
 	// Convertir ordenamiento con valores por defecto
@@ -138,50 +128,104 @@ func (r *Resolver) Stocks(p graphql.ResolveParams) (interface{}, error) {
 		}
 	}
 
-	// Obtener stocks
-	stocks, err := r.stockService.GetStocks(ctx, domainFilter, domainSort)
-	if err != nil {
-		return nil, err
+	domainSort = domainSort.Normalized()
+	if limit < 0 {
+		limit = 0
 	}
 
-	// Contar total
-	totalCount, err := r.stockService.CountStocks(ctx, domainFilter)
+	// Paginación por keyset si hay cursor; offset se mantiene por compatibilidad
+	page := stock.Page{Limit: limit + 1, Offset: offset}
+	if c, ok := p.Args["cursor"].(string); ok && c != "" {
+		cursor, err := stock.DecodeCursor(c)
+		if err != nil {
+			return nil, err
+		}
+		if !cursor.Matches(domainSort) {
+			return nil, fmt.Errorf("%w: cursor does not match sort", stock.ErrInvalidCursor)
+		}
+		page.After = cursor
+	}
+
+	// El score decae con el tiempo: todas las páginas se ordenan respecto al momento de la primera
+	if domainSort.Field == stock.SortScore {
+		domainSort.At = time.Now().UTC()
+		if page.After != nil {
+			domainSort.At = page.After.At
+		}
+	}
+
+	// Obtener una fila más de las pedidas para saber si hay página siguiente
+	stocks, err := r.stockService.GetStocksPage(ctx, domainFilter, domainSort, page)
 	if err != nil {
 		return nil, err
 	}
-
-	// Aplicar paginación
-	start := offset
-	end := start + limit
-	if end > len(stocks) {
-		end = len(stocks)
+	hasNextPage := len(stocks) > limit
+	if hasNextPage {
+		stocks = stocks[:limit]
 	}
 
-	var paginatedStocks []*stock.Stock
-	if start < len(stocks) {
-		paginatedStocks = stocks[start:end]
+	// Contar total solo si se pide
+	totalCount := 0
+	if fieldRequested(p, "totalCount") {
+		totalCount, err = r.stockService.CountStocks(ctx, domainFilter)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Convertir a formato GraphQL
-	graphqlStocks := make([]map[string]interface{}, len(paginatedStocks))
-	for i, s := range paginatedStocks {
+	graphqlStocks := make([]map[string]interface{}, len(stocks))
+	edges := make([]map[string]interface{}, len(stocks))
+	for i, s := range stocks {
 		graphqlStocks[i] = stockToMap(s)
+		edges[i] = map[string]interface{}{
+			"cursor": stock.NewCursor(s, domainSort).Encode(),
+			"node":   graphqlStocks[i],
+		}
 	}
 
-	// Calcular información de paginación
-	hasNextPage := end < totalCount
-	hasPreviousPage := offset > 0
+	var startCursor, endCursor interface{}
+	if len(edges) > 0 {
+		startCursor = edges[0]["cursor"]
+		endCursor = edges[len(edges)-1]["cursor"]
+	}
 
 	return map[string]interface{}{
-		"stocks": graphqlStocks,
+		"stocks":     graphqlStocks,
+		"edges":      edges,
 		"totalCount": totalCount,
 		"pageInfo": map[string]interface{}{
 			"hasNextPage":     hasNextPage,
-			"hasPreviousPage": hasPreviousPage,
+			"hasPreviousPage": page.After != nil || offset > 0,
+			"startCursor":     startCursor,
+			"endCursor":       endCursor,
 		},
 	}, nil
 }
 
+// fieldRequested retorna true si la selección del campo resuelto incluye name
+// (o si no se puede determinar, por ejemplo con fragments)
+func fieldRequested(p graphql.ResolveParams, name string) bool {
+	if len(p.Info.FieldASTs) == 0 {
+		return true
+	}
+	for _, fieldAST := range p.Info.FieldASTs {
+		if fieldAST.SelectionSet == nil {
+			continue
+		}
+		for _, selection := range fieldAST.SelectionSet.Selections {
+			field, ok := selection.(*ast.Field)
+			if !ok {
+				return true
+			}
+			if field.Name != nil && field.Name.Value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Stock resuelve la query stock
 func (r *Resolver) Stock(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
@@ -199,16 +243,167 @@ func (r *Resolver) Stock(p graphql.ResolveParams) (interface{}, error) {
 	return stockToMap(s), nil
 }
 
+// StockStats resuelve la query stockStats
+func (r *Resolver) StockStats(p graphql.ResolveParams) (interface{}, error) {
+	filter, err := parseStockFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := r.stockService.GetStats(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]map[string]interface{}, len(stats.TargetChanges))
+	for i, b := range stats.TargetChanges {
+		bucket := map[string]interface{}{
+			"min":   nil,
+			"max":   nil,
+			"count": b.Count,
+		}
+		if b.Min != nil {
+			bucket["min"] = *b.Min
+		}
+		if b.Max != nil {
+			bucket["max"] = *b.Max
+		}
+		buckets[i] = bucket
+	}
+
+	var ratio interface{}
+	if v, ok := stats.UpgradeDowngradeRatio(); ok {
+		ratio = v
+	}
+
+	return map[string]interface{}{
+		"totalCount":               stats.Total,
+		"byRating":                 groupCountsToMaps(stats.ByRating),
+		"byAction":                 groupCountsToMaps(stats.ByAction),
+		"byBrokerage":              groupCountsToMaps(stats.ByBrokerage),
+		"upgrades":                 stats.Upgrades,
+		"downgrades":               stats.Downgrades,
+		"upgradeDowngradeRatio":    ratio,
+		"targetChangeDistribution": buckets,
+	}, nil
+}
+
+// groupCountsToMaps convierte conteos por valor al tipo GroupCount
+func groupCountsToMaps(counts []stock.GroupCount) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(counts))
+	for i, c := range counts {
+		result[i] = map[string]interface{}{
+			"value": c.Value,
+			"count": c.Count,
+		}
+	}
+	return result
+}
+
+// Search resuelve la query search
+func (r *Resolver) Search(p graphql.ResolveParams) (interface{}, error) {
+	query, _ := p.Args["query"].(string)
+	limit, _ := p.Args["limit"].(int)
+
+	results, err := r.stockService.SearchStocks(p.Context, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(results))
+	for i, res := range results {
+		highlights := make([]map[string]interface{}, len(res.Highlights))
+		for j, h := range res.Highlights {
+			highlights[j] = map[string]interface{}{
+				"start": h.Start,
+				"end":   h.End,
+			}
+		}
+
+		result[i] = map[string]interface{}{
+			"stock":        stockToMap(res.Stock),
+			"score":        res.Score,
+			"matchedField": res.Field,
+			"matchedValue": res.MatchedValue(),
+			"highlights":   highlights,
+		}
+	}
+
+	return result, nil
+}
+
+// History resuelve la query history
+func (r *Resolver) History(p graphql.ResolveParams) (interface{}, error) {
+	ticker, ok := p.Args["ticker"].(string)
+	if !ok {
+		return nil, fmt.Errorf("ticker is required")
+	}
+
+	return r.resolveHistory(p, ticker)
+}
+
+// StockHistory resuelve el campo history del tipo Stock. Retorna un thunk: graphql-go resuelve
+// los thunks después de recorrer la lista, así el historial de todos sus stocks se carga en
+// una sola consulta (HistoryLoader)
+func (r *Resolver) StockHistory(p graphql.ResolveParams) (interface{}, error) {
+	// El campo es una lista no nula: sin ticker el historial está vacío
+	source, ok := p.Source.(map[string]interface{})
+	if !ok {
+		return []map[string]interface{}{}, nil
+	}
+
+	ticker, ok := source["ticker"].(string)
+	if !ok || ticker == "" {
+		return []map[string]interface{}{}, nil
+	}
+
+	thunk := r.historyLoader.Load(p.Context, ticker, historyLimitArg(p))
+	return func() (interface{}, error) {
+		events, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		return ratingEventsToMaps(events), nil
+	}, nil
+}
+
+// resolveHistory obtiene el historial de eventos de un ticker en formato GraphQL
+func (r *Resolver) resolveHistory(p graphql.ResolveParams, ticker string) (interface{}, error) {
+	events, err := r.stockService.GetHistory(p.Context, ticker, historyLimitArg(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return ratingEventsToMaps(events), nil
+}
+
+// historyLimitArg retorna el argumento limit del historial (el servicio lo acota entre 1 y el máximo)
+func historyLimitArg(p graphql.ResolveParams) int {
+	if l, ok := p.Args["limit"].(int); ok {
+		return l
+	}
+	return stock.DefaultHistoryLimit
+}
+
+// ratingEventsToMaps convierte eventos de rating a su formato GraphQL
+func ratingEventsToMaps(events []*stock.RatingEvent) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(events))
+	for i, e := range events {
+		result[i] = ratingEventToMap(e)
+	}
+	return result
+}
+
 // Recommendations resuelve la query recommendations
 func (r *Resolver) Recommendations(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
 
-	limit := 10
-	if l, ok := p.Args["limit"].(int); ok {
-		limit = l
+	algorithm, criteria, limit, err := r.parseRecommendationArgs(p)
+	if err != nil {
+		return nil, err
 	}
 
-	recommendations, err := r.recommendationService.GetRecommendations(ctx, limit)
+	recommendations, err := r.recommendationService.GetRecommendationsWithAlgorithm(ctx, algorithm, criteria, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -216,38 +411,597 @@ func (r *Resolver) Recommendations(p graphql.ResolveParams) (interface{}, error)
 	// Convertir a formato GraphQL
 	result := make([]map[string]interface{}, len(recommendations))
 	for i, rec := range recommendations {
-		result[i] = map[string]interface{}{
-			"stock":       stockToMap(rec.Stock),
-			"score":       rec.Score,
-			"priceChange": rec.PriceChange,
-			"ratingScore": rec.RatingScore,
-			"actionScore": rec.ActionScore,
-		}
+		result[i] = recommendationToMap(rec)
+	}
+
+	return result, nil
+}
+
+// ExplainRecommendation resuelve la query explainRecommendation
+func (r *Resolver) ExplainRecommendation(p graphql.ResolveParams) (interface{}, error) {
+	ctx := p.Context
+
+	ticker, ok := p.Args["ticker"].(string)
+	if !ok {
+		return nil, fmt.Errorf("ticker is required")
+	}
+
+	algorithm, criteria, limit, err := r.parseRecommendationArgs(p)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, err := r.recommendationService.ExplainRecommendation(ctx, algorithm, ticker, criteria, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"ticker":     explanation.Ticker,
+		"included":   explanation.Included,
+		"rank":       nil,
+		"exclusions": explanation.Exclusions,
+	}
+	if explanation.Rank > 0 {
+		result["rank"] = explanation.Rank
+	}
+	if explanation.Recommendation != nil {
+		result["recommendation"] = recommendationToMap(explanation.Recommendation)
 	}
 
 	return result, nil
 }
 
+// parseRecommendationArgs obtiene el algoritmo, los criterios y el límite de las queries de recomendaciones
+func (r *Resolver) parseRecommendationArgs(p graphql.ResolveParams) (string, recommendation.Criteria, int, error) {
+	limit := 10
+	if l, ok := p.Args["limit"].(int); ok {
+		limit = l
+	}
+
+	criteria, err := parseRecommendationInput(p.Args["input"])
+	if err != nil {
+		return "", criteria, 0, err
+	}
+
+	// La fiabilidad de los brokerages se calcula sobre el historial completo solo si se pide
+	if input, ok := p.Args["input"].(map[string]interface{}); ok {
+		if weight, _ := input["weightByReliability"].(bool); weight {
+			criteria.Reliability, err = r.brokerageService.GetReliability(p.Context)
+			if err != nil {
+				return "", criteria, 0, err
+			}
+		}
+	}
+
+	if d, ok := p.Args["diversification"].(map[string]interface{}); ok && d != nil {
+		criteria.Diversification.MaxPerBrokerage, _ = d["maxPerBrokerage"].(int)
+		criteria.Diversification.MaxPerCompany, _ = d["maxPerCompany"].(int)
+		criteria.Diversification.MinBrokerages, _ = d["minBrokerages"].(int)
+		if err := criteria.Diversification.Validate(); err != nil {
+			return "", criteria, 0, err
+		}
+	}
+
+	algorithm := recommendation.AlgorithmLatest
+	if a, ok := p.Args["algorithm"].(string); ok && a != "" {
+		algorithm = a
+	}
+
+	return algorithm, criteria, limit, nil
+}
+
+// recommendationToMap convierte una recomendación a map para GraphQL
+func recommendationToMap(rec *recommendation.Recommendation) map[string]interface{} {
+	explanation := make([]map[string]interface{}, len(rec.Explanation))
+	for i, f := range rec.Explanation {
+		explanation[i] = map[string]interface{}{
+			"factor":       f.Name,
+			"value":        f.Value,
+			"weight":       f.Weight,
+			"contribution": f.Contribution,
+			"reason":       f.Reason,
+		}
+	}
+
+	return map[string]interface{}{
+		"stock":       stockToMap(rec.Stock),
+		"score":       rec.Score,
+		"priceChange": rec.PriceChange,
+		"ratingScore": rec.RatingScore,
+		"actionScore": rec.ActionScore,
+		"reliability": rec.Reliability,
+		"decayFactor": rec.DecayFactor,
+		"consensus":   consensusToMap(rec.Consensus),
+		"explanation": explanation,
+	}
+}
+
+// consensusToMap convierte los componentes del consenso a map (nil si no hay consenso)
+func consensusToMap(c *recommendation.Consensus) map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"calls":            c.Calls,
+		"brokerages":       c.Brokerages,
+		"meanTarget":       c.MeanTarget,
+		"medianTarget":     c.MedianTarget,
+		"targetStdDev":     c.TargetStdDev,
+		"targetDispersion": c.TargetDispersion,
+		"upgrades":         c.Upgrades,
+		"downgrades":       c.Downgrades,
+		"meanRatingScore":  c.MeanRatingScore,
+	}
+}
+
 // SyncStocks resuelve la mutation syncStocks
+// Encola una sincronización y retorna su ID sin esperar a que termine
 func (r *Resolver) SyncStocks(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
 
-	count, err := r.syncService.SyncAllStocks(ctx)
+	mode := syncrun.ModeIncremental
+	if full, ok := p.Args["full"].(bool); ok && full {
+		mode = syncrun.ModeFull
+	}
+
+	run, err := r.syncService.EnqueueSync(ctx, syncrun.TriggerManual, mode)
 	if err != nil {
 		return map[string]interface{}{
-			"success":      false,
-			"message":      err.Error(),
-			"stocksSynced": 0,
+			"success": false,
+			"message": err.Error(),
 		}, nil
 	}
 
 	return map[string]interface{}{
-		"success":      true,
-		"message":      "Stocks synchronized successfully",
-		"stocksSynced": count,
+		"success": true,
+		"message": "Stock synchronization enqueued",
+		"runId":   run.ID.String(),
+		"status":  run.Status.String(),
+		"mode":    run.Mode.String(),
 	}, nil
 }
 
+// SubscribeRatingChanged inicia la suscripción ratingChanged
+func (r *Resolver) SubscribeRatingChanged(p graphql.ResolveParams) (interface{}, error) {
+	filter := stock.Filter{}
+	for _, ticker := range toStringSlice(p.Args["tickers"]) {
+		filter.Or = append(filter.Or, stock.Filter{Ticker: strings.ToUpper(strings.TrimSpace(ticker))})
+	}
+	for _, rating := range toStringSlice(p.Args["ratings"]) {
+		filter.Ratings = append(filter.Ratings, stock.Rating(rating))
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	events := r.syncService.SubscribeRatingChanges(p.Context, filter)
+	return forwardEvents(p.Context, events, func(e services.RatingChangedEvent) interface{} {
+		var previous interface{}
+		if e.Previous != nil {
+			previous = stockToMap(e.Previous)
+		}
+		return map[string]interface{}{
+			"stock":    stockToMap(e.Stock),
+			"previous": previous,
+		}
+	}), nil
+}
+
+// SubscribeSyncCompleted inicia la suscripción syncCompleted
+func (r *Resolver) SubscribeSyncCompleted(p graphql.ResolveParams) (interface{}, error) {
+	runs := r.syncService.SubscribeSyncCompleted(p.Context)
+	return forwardEvents(p.Context, runs, func(run *syncrun.Run) interface{} {
+		return syncRunToMap(run)
+	}), nil
+}
+
+// subscriptionPayload resuelve el campo raíz de una suscripción con el evento ya convertido
+func subscriptionPayload(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source, nil
+}
+
+// forwardEvents convierte los eventos de un servicio al canal que espera graphql-go
+// hasta que el canal de origen se cierre o ctx se cancele
+func forwardEvents[T any](ctx context.Context, events <-chan T, toMap func(T) interface{}) chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for e := range events {
+			select {
+			case out <- toMap(e):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// SyncRuns resuelve la query syncRuns
+func (r *Resolver) SyncRuns(p graphql.ResolveParams) (interface{}, error) {
+	limit := 20
+	if l, ok := p.Args["limit"].(int); ok {
+		limit = l
+	}
+
+	runs, err := r.syncService.GetRuns(p.Context, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(runs))
+	for i, run := range runs {
+		result[i] = syncRunToMap(run)
+	}
+
+	return result, nil
+}
+
+// SyncRun resuelve la query syncRun
+func (r *Resolver) SyncRun(p graphql.ResolveParams) (interface{}, error) {
+	idStr, ok := p.Args["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+
+	run, err := r.syncService.GetRun(p.Context, id)
+	if errors.Is(err, syncrun.ErrRunNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return syncRunToMap(run), nil
+}
+
+// LastSync resuelve la query lastSync
+func (r *Resolver) LastSync(p graphql.ResolveParams) (interface{}, error) {
+	status := syncrun.Status("")
+	if s, ok := p.Args["status"].(string); ok && s != "" {
+		status = syncrun.Status(strings.ToLower(s))
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid status: %s", s)
+		}
+	}
+
+	run, err := r.syncService.GetLastRun(p.Context, status)
+	if errors.Is(err, syncrun.ErrRunNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return syncRunToMap(run), nil
+}
+
+// QuarantinedRecords resuelve la query quarantinedRecords
+func (r *Resolver) QuarantinedRecords(p graphql.ResolveParams) (interface{}, error) {
+	filter := quarantine.Filter{Limit: 50}
+	if l, ok := p.Args["limit"].(int); ok {
+		filter.Limit = l
+	}
+
+	if s, ok := p.Args["status"].(string); ok && s != "" {
+		filter.Status = quarantine.Status(strings.ToLower(s))
+		if !filter.Status.IsValid() {
+			return nil, fmt.Errorf("invalid status: %s", s)
+		}
+	}
+
+	if idStr, ok := p.Args["syncRunId"].(string); ok && idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syncRunId: %w", err)
+		}
+		filter.SyncRunID = &id
+	}
+
+	records, err := r.quarantineService.GetRecords(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(records))
+	for i, rec := range records {
+		result[i] = quarantinedRecordToMap(rec)
+	}
+
+	return result, nil
+}
+
+// ReprocessQuarantined resuelve la mutation reprocessQuarantined
+func (r *Resolver) ReprocessQuarantined(p graphql.ResolveParams) (interface{}, error) {
+	var ids []uuid.UUID
+	if idsVal, ok := p.Args["ids"].([]interface{}); ok {
+		ids = make([]uuid.UUID, 0, len(idsVal))
+		for _, v := range idsVal {
+			idStr, ok := v.(string)
+			if !ok {
+				continue
+			}
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid id %q: %w", idStr, err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	result, err := r.quarantineService.Reprocess(p.Context, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]interface{}, len(result.Records))
+	for i, rec := range result.Records {
+		records[i] = quarantinedRecordToMap(rec)
+	}
+
+	return map[string]interface{}{
+		"processed": result.Processed,
+		"resolved":  result.Resolved,
+		"failed":    result.Failed,
+		"records":   records,
+	}, nil
+}
+
+// ScoringModel resuelve la query scoringModel
+func (r *Resolver) ScoringModel(p graphql.ResolveParams) (interface{}, error) {
+	model := r.stockService.GetScoringModel()
+
+	ratingScores := make([]map[string]interface{}, 0, len(model.RatingScores))
+	for _, name := range model.RatingNames() {
+		ratingScores = append(ratingScores, map[string]interface{}{
+			"name":  name,
+			"score": model.RatingScores[name],
+		})
+	}
+
+	actionScores := make([]map[string]interface{}, 0, len(model.ActionScores))
+	for _, name := range model.ActionNames() {
+		actionScores = append(actionScores, map[string]interface{}{
+			"name":  name,
+			"score": model.ActionScores[name],
+		})
+	}
+
+	return map[string]interface{}{
+		"ratingScores": ratingScores,
+		"actionScores": actionScores,
+		"upgradeBonus": model.UpgradeBonus,
+		"weights": map[string]interface{}{
+			"priceChange": model.Weights.PriceChange,
+			"rating":      model.Weights.Rating,
+			"action":      model.Weights.Action,
+		},
+		"halfLifeDays": model.HalfLifeDays,
+	}, nil
+}
+
+// Brokerages resuelve la query brokerages
+func (r *Resolver) Brokerages(p graphql.ResolveParams) (interface{}, error) {
+	minCalls, _ := p.Args["minCalls"].(int)
+
+	stats, err := r.brokerageService.GetStats(p.Context, minCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(stats))
+	for i, s := range stats {
+		result[i] = map[string]interface{}{
+			"name":              s.Name,
+			"calls":             s.Calls,
+			"tickers":           s.Tickers,
+			"evaluatedCalls":    s.EvaluatedCalls,
+			"hits":              s.Hits,
+			"reversals":         s.Reversals,
+			"hitRate":           s.HitRate,
+			"avgTargetRevision": s.AvgTargetRevision,
+			"reliability":       s.Reliability,
+		}
+	}
+
+	return result, nil
+}
+
+// RatingAliases resuelve la query ratingAliases
+func (r *Resolver) RatingAliases(p graphql.ResolveParams) (interface{}, error) {
+	aliases, err := r.ratingAliasService.GetAliases(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(aliases))
+	for i, a := range aliases {
+		result[i] = map[string]interface{}{
+			"alias":      a.Alias,
+			"rating":     a.Rating.String(),
+			"score":      a.Score,
+			"configured": a.Configured,
+		}
+	}
+
+	return result, nil
+}
+
+// NormalizeRating resuelve la query normalizeRating
+func (r *Resolver) NormalizeRating(p graphql.ResolveParams) (interface{}, error) {
+	raw, _ := p.Args["raw"].(string)
+
+	normalized, err := r.ratingAliasService.Normalize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"raw":    normalized.Raw,
+		"rating": normalized.Rating.String(),
+		"score":  normalized.Score,
+	}, nil
+}
+
+// SaveRatingAlias resuelve la mutation saveRatingAlias
+func (r *Resolver) SaveRatingAlias(p graphql.ResolveParams) (interface{}, error) {
+	alias, _ := p.Args["alias"].(string)
+	rating, _ := p.Args["rating"].(string)
+
+	var score *float64
+	if v, ok := p.Args["score"].(float64); ok {
+		score = &v
+	}
+
+	saved, err := r.ratingAliasService.SaveAlias(p.Context, alias, stock.Rating(rating), score)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"alias":      saved.Alias,
+		"rating":     saved.Rating.String(),
+		"score":      saved.Score,
+		"configured": true,
+	}, nil
+}
+
+// DeleteRatingAlias resuelve la mutation deleteRatingAlias
+func (r *Resolver) DeleteRatingAlias(p graphql.ResolveParams) (interface{}, error) {
+	alias, _ := p.Args["alias"].(string)
+	return r.ratingAliasService.DeleteAlias(p.Context, alias)
+}
+
+// parseRecommendationInput convierte el argumento RecommendationInput en criterios de dominio
+func parseRecommendationInput(arg interface{}) (recommendation.Criteria, error) {
+	criteria := recommendation.Criteria{}
+	input, ok := arg.(map[string]interface{})
+	if !ok || input == nil {
+		return criteria, nil
+	}
+
+	if w, ok := input["weights"].(map[string]interface{}); ok && w != nil {
+		weights := scoring.Weights{}
+		weights.PriceChange, _ = w["priceChange"].(float64)
+		weights.Rating, _ = w["rating"].(float64)
+		weights.Action, _ = w["action"].(float64)
+		criteria.Weights = &weights
+	}
+
+	if v, ok := input["minPriceChange"].(float64); ok {
+		criteria.MinPriceChange = &v
+	}
+
+	for _, v := range toStringSlice(input["ratings"]) {
+		rating := stock.Rating(v)
+		if !rating.IsValid() {
+			return criteria, fmt.Errorf("invalid rating: %s", v)
+		}
+		criteria.Ratings = append(criteria.Ratings, rating)
+	}
+
+	criteria.Brokerages = toStringSlice(input["brokerages"])
+	criteria.ExcludedTickers = toStringSlice(input["excludeTickers"])
+
+	if v, ok := input["halfLifeDays"].(float64); ok {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return criteria, fmt.Errorf("invalid halfLifeDays: %v", v)
+		}
+		halfLife := time.Duration(v * float64(24*time.Hour))
+		criteria.HalfLife = &halfLife
+	}
+
+	return criteria, criteria.Validate()
+}
+
+// parseStockFilter convierte el input StockFilter en un filtro de dominio validado
+func parseStockFilter(arg interface{}) (stock.Filter, error) {
+	filter := parseStockFilterInput(arg)
+	return filter, filter.Validate()
+}
+
+// parseStockFilterInput convierte el input StockFilter y sus subfiltros sin validarlos
+func parseStockFilterInput(arg interface{}) stock.Filter {
+	filter := stock.Filter{}
+	input, ok := arg.(map[string]interface{})
+	if !ok || input == nil {
+		return filter
+	}
+
+	filter.Ticker, _ = input["ticker"].(string)
+	filter.TickerPrefix, _ = input["tickerPrefix"].(string)
+	filter.CompanyName, _ = input["companyName"].(string)
+	filter.Action, _ = input["action"].(string)
+	filter.RatingChange, _ = input["ratingChange"].(string)
+	filter.Brokerages = toStringSlice(input["brokerages"])
+
+	for _, v := range toStringSlice(input["ratings"]) {
+		if v != "" {
+			filter.Ratings = append(filter.Ratings, stock.Rating(v))
+		}
+	}
+
+	filter.EventTimeFrom, _ = input["eventTimeFrom"].(time.Time)
+	filter.EventTimeTo, _ = input["eventTimeTo"].(time.Time)
+	filter.CreatedFrom, _ = input["createdFrom"].(time.Time)
+	filter.CreatedTo, _ = input["createdTo"].(time.Time)
+
+	filter.TargetToMin = floatArg(input["targetToMin"])
+	filter.TargetToMax = floatArg(input["targetToMax"])
+	filter.TargetFromMin = floatArg(input["targetFromMin"])
+	filter.TargetFromMax = floatArg(input["targetFromMax"])
+	filter.MinTargetChange = floatArg(input["minTargetChange"])
+	filter.MaxTargetChange = floatArg(input["maxTargetChange"])
+
+	and, _ := input["and"].([]interface{})
+	for _, sub := range and {
+		filter.And = append(filter.And, parseStockFilterInput(sub))
+	}
+	or, _ := input["or"].([]interface{})
+	for _, sub := range or {
+		filter.Or = append(filter.Or, parseStockFilterInput(sub))
+	}
+	if not, ok := input["not"].(map[string]interface{}); ok && not != nil {
+		f := parseStockFilterInput(not)
+		filter.Not = &f
+	}
+
+	return filter
+}
+
+// floatArg convierte un argumento Float opcional en puntero (nil si se omite)
+func floatArg(arg interface{}) *float64 {
+	switch v := arg.(type) {
+	case float64:
+		return &v
+	case int:
+		f := float64(v)
+		return &f
+	}
+	return nil
+}
+
+// toStringSlice convierte un argumento de lista GraphQL en []string
+func toStringSlice(arg interface{}) []string {
+	values, ok := arg.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
 // stockToMap convierte un stock de dominio a mapa para GraphQL
 func stockToMap(s *stock.Stock) map[string]interface{} {
 	brokerage := s.Brokerage
@@ -263,8 +1017,83 @@ func stockToMap(s *stock.Stock) map[string]interface{} {
 		"ratingTo":    s.RatingTo.String(),
 		"targetFrom":  s.TargetFrom.Value(),
 		"targetTo":    s.TargetTo.Value(),
+		"eventTime":   s.EventTime,
 		"createdAt":   s.CreatedAt,
 		"updatedAt":   s.UpdatedAt,
+
+		"ratingFromRaw":   s.RatingFromRaw,
+		"ratingToRaw":     s.RatingToRaw,
+		"ratingFromScore": s.RatingFromScore,
+		"ratingToScore":   s.RatingToScore,
+	}
+}
+
+// ratingEventToMap convierte un evento de rating de dominio a mapa para GraphQL
+func ratingEventToMap(e *stock.RatingEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          e.ID.String(),
+		"ticker":      e.Ticker,
+		"companyName": e.CompanyName,
+		"brokerage":   e.Brokerage,
+		"action":      e.Action,
+		"ratingFrom":  e.RatingFrom.String(),
+		"ratingTo":    e.RatingTo.String(),
+		"targetFrom":  e.TargetFrom.Value(),
+		"targetTo":    e.TargetTo.Value(),
+		"eventTime":   e.EventTime,
+		"createdAt":   e.CreatedAt,
+
+		"ratingFromRaw":   e.RatingFromRaw,
+		"ratingToRaw":     e.RatingToRaw,
+		"ratingFromScore": e.RatingFromScore,
+		"ratingToScore":   e.RatingToScore,
+	}
+}
+
+// syncRunToMap convierte una ejecución de sincronización a mapa para GraphQL
+func syncRunToMap(run *syncrun.Run) map[string]interface{} {
+	var runErr interface{}
+	if run.Error != "" {
+		runErr = run.Error
+	}
+
+	return map[string]interface{}{
+		"id":           run.ID.String(),
+		"status":       run.Status.String(),
+		"trigger":      run.Trigger.String(),
+		"mode":         run.Mode.String(),
+		"pagesFetched": run.PagesFetched,
+		"rowsUpserted": run.RowsUpserted,
+		"rowsRejected": run.RowsRejected,
+		"error":        runErr,
+		"newestEventTime": run.NewestEventTime,
+		"enqueuedAt":   run.CreatedAt,
+		"startedAt":    run.StartedAt,
+		"finishedAt":   run.FinishedAt,
+	}
+}
+
+// quarantinedRecordToMap convierte un registro en cuarentena a mapa para GraphQL
+func quarantinedRecordToMap(rec *quarantine.Record) map[string]interface{} {
+	var syncRunID, recErr interface{}
+	if rec.SyncRunID != nil {
+		syncRunID = rec.SyncRunID.String()
+	}
+	if rec.Error != "" {
+		recErr = rec.Error
+	}
+
+	return map[string]interface{}{
+		"id":            rec.ID.String(),
+		"syncRunId":     syncRunID,
+		"ticker":        rec.Ticker,
+		"payload":       string(rec.RawPayload),
+		"error":         recErr,
+		"status":        rec.Status.String(),
+		"attempts":      rec.Attempts,
+		"createdAt":     rec.CreatedAt,
+		"lastAttemptAt": rec.LastAttemptAt,
+		"resolvedAt":    rec.ResolvedAt,
 	}
 }
 
@@ -288,6 +1117,14 @@ func mapEnumFieldToDBField(enumValue string) string {
 		return "target_to"
 	case "CREATED_AT":
 		return "created_at"
+	case "EVENT_TIME":
+		return "event_time"
+	case "PRICE_CHANGE":
+		return stock.SortPriceChange
+	case "RATING_DELTA":
+		return stock.SortRatingDelta
+	case "SCORE":
+		return stock.SortScore
 	default:
 		// Si ya viene como nombre de campo válido, retornarlo tal cual
 		// Validar que sea uno de los campos permitidos
@@ -297,6 +1134,10 @@ func mapEnumFieldToDBField(enumValue string) string {
 			"rating_to":    true,
 			"target_to":    true,
 			"created_at":   true,
+			"event_time":   true,
+			stock.SortPriceChange: true,
+			stock.SortRatingDelta: true,
+			stock.SortScore:       true,
 		}
 		lower := strings.ToLower(enumValue)
 		if validFields[lower] {
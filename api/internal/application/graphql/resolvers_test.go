@@ -3,9 +3,15 @@ package graphql
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/graphql-go/graphql"
+	"github.com/john/go-react-test/api/internal/application/auth"
+	"github.com/john/go-react-test/api/internal/application/services"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestResolver_Stocks tests the Stocks resolver with basic argument parsing
@@ -79,18 +85,196 @@ func TestResolver_Stock(t *testing.T) {
 	})
 }
 
-// TestResolver_SyncStocks tests the SyncStocks mutation structure
+// fakeRunRepository acepta las ejecuciones encoladas sin guardarlas
+type fakeRunRepository struct {
+	syncrun.Repository
+}
+
+func (fakeRunRepository) Create(ctx context.Context, run *syncrun.Run) error {
+	return nil
+}
+
+// TestResolver_SyncStocks tests that syncStocks only reports the enqueued run
 func TestResolver_SyncStocks(t *testing.T) {
-	t.Run("sync stocks structure", func(t *testing.T) {
-		// Test that the resolver returns the correct structure
-		result := map[string]interface{}{
-			"success":      true,
-			"message":      "Stocks synchronized successfully",
-			"stocksSynced": 10,
-		}
+	syncService := services.NewSyncService(nil, nil, nil, fakeRunRepository{}, nil, 0, "test")
+	resolver := NewResolver(nil, syncService, nil, nil, nil, nil)
+
+	result, err := resolver.SyncStocks(graphql.ResolveParams{
+		Context: context.Background(),
+		Args:    map[string]interface{}{"full": true},
+	})
+	require.NoError(t, err)
+
+	// Las filas sincronizadas se consultan en syncRun(id: runId) cuando la ejecución termina
+	payload := result.(map[string]interface{})
+	assert.Equal(t, true, payload["success"])
+	assert.NotEmpty(t, payload["runId"])
+	assert.Equal(t, syncrun.StatusPending.String(), payload["status"])
+	assert.Equal(t, syncrun.ModeFull.String(), payload["mode"])
+	assert.NotContains(t, payload, "stocksSynced")
+}
+
+// TestParseRecommendationInput tests the conversion of RecommendationInput into domain criteria
+func TestParseRecommendationInput(t *testing.T) {
+	t.Run("missing input uses defaults", func(t *testing.T) {
+		criteria, err := parseRecommendationInput(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, criteria.Weights)
+		assert.Nil(t, criteria.MinPriceChange)
+		assert.Empty(t, criteria.Ratings)
+	})
+
+	t.Run("full input", func(t *testing.T) {
+		criteria, err := parseRecommendationInput(map[string]interface{}{
+			"weights": map[string]interface{}{
+				"priceChange": 0.2,
+				"rating":      0.7,
+				"action":      0.1,
+			},
+			"minPriceChange": 5.0,
+			"ratings":        []interface{}{"Strong Buy", "Buy"},
+			"brokerages":     []interface{}{"The Goldman Sachs Group"},
+			"excludeTickers": []interface{}{"TSLA"},
+			"halfLifeDays":   30.0,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0.7, criteria.Weights.Rating)
+		assert.Equal(t, 5.0, *criteria.MinPriceChange)
+		assert.Len(t, criteria.Ratings, 2)
+		assert.Equal(t, []string{"The Goldman Sachs Group"}, criteria.Brokerages)
+		assert.Equal(t, []string{"TSLA"}, criteria.ExcludedTickers)
+		assert.Equal(t, 30*24*time.Hour, *criteria.HalfLife)
+	})
+
+	t.Run("negative half-life", func(t *testing.T) {
+		_, err := parseRecommendationInput(map[string]interface{}{
+			"halfLifeDays": -1.0,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid rating", func(t *testing.T) {
+		_, err := parseRecommendationInput(map[string]interface{}{
+			"ratings": []interface{}{"Outstanding"},
+		})
+		assert.Error(t, err)
+	})
 
-		assert.True(t, result["success"].(bool))
-		assert.Equal(t, "Stocks synchronized successfully", result["message"])
-		assert.Equal(t, 10, result["stocksSynced"])
+	t.Run("all-zero weights", func(t *testing.T) {
+		_, err := parseRecommendationInput(map[string]interface{}{
+			"weights": map[string]interface{}{"priceChange": 0.0, "rating": 0.0, "action": 0.0},
+		})
+		assert.Error(t, err)
 	})
 }
+
+func TestParseStockFilter(t *testing.T) {
+	t.Run("nil input", func(t *testing.T) {
+		filter, err := parseStockFilter(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, stock.Filter{}, filter)
+	})
+
+	t.Run("composed filter", func(t *testing.T) {
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter, err := parseStockFilter(map[string]interface{}{
+			"tickerPrefix":    "A",
+			"brokerages":      []interface{}{"UBS"},
+			"targetToMin":     50.0,
+			"minTargetChange": 10.0,
+			"ratingChange":    stock.RatingChangeUpgrade,
+			"eventTimeFrom":   from,
+			"or": []interface{}{
+				map[string]interface{}{"ticker": "MSFT"},
+				map[string]interface{}{"ratings": []interface{}{"Buy"}},
+			},
+			"not": map[string]interface{}{"action": "target lowered by"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "A", filter.TickerPrefix)
+		assert.Equal(t, []string{"UBS"}, filter.Brokerages)
+		assert.Equal(t, 50.0, *filter.TargetToMin)
+		assert.Equal(t, 10.0, *filter.MinTargetChange)
+		assert.Nil(t, filter.TargetToMax)
+		assert.Equal(t, stock.RatingChangeUpgrade, filter.RatingChange)
+		assert.Equal(t, from, filter.EventTimeFrom)
+		require.Len(t, filter.Or, 2)
+		assert.Equal(t, "MSFT", filter.Or[0].Ticker)
+		assert.Equal(t, []stock.Rating{stock.RatingBuy}, filter.Or[1].Ratings)
+		require.NotNil(t, filter.Not)
+		assert.Equal(t, "target lowered by", filter.Not.Action)
+	})
+
+	t.Run("invalid nested rating", func(t *testing.T) {
+		_, err := parseStockFilter(map[string]interface{}{
+			"and": []interface{}{map[string]interface{}{"ratings": []interface{}{"Outstanding"}}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("inverted range", func(t *testing.T) {
+		_, err := parseStockFilter(map[string]interface{}{"targetToMin": 10.0, "targetToMax": 5.0})
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildSchema(t *testing.T) {
+	schema, err := buildSchema(NewResolver(nil, nil, nil, nil, nil, nil))
+	require.NoError(t, err)
+
+	require.NotNil(t, schema.SubscriptionType())
+	assert.NotNil(t, schema.SubscriptionType().Fields()["ratingChanged"])
+	assert.NotNil(t, schema.SubscriptionType().Fields()["syncCompleted"])
+
+	// Los tipos de history coinciden con los declarados en schema.graphql
+	assert.Equal(t, "[RatingEvent!]!", schema.QueryType().Fields()["history"].Type.String())
+	stockType, ok := schema.Type("Stock").(*graphql.Object)
+	require.True(t, ok)
+	assert.Equal(t, "[RatingEvent!]!", stockType.Fields()["history"].Type.String())
+}
+
+func TestBuildSchema_FieldAuthorization(t *testing.T) {
+	schema, err := buildSchema(NewResolver(nil, nil, nil, nil, nil, nil))
+	require.NoError(t, err)
+
+	tests := []struct {
+		query   string
+		role    auth.Role
+		wantErr string
+	}{
+		{`mutation { syncStocks { success } }`, auth.RoleViewer, "forbidden: requires operator role"},
+		{`mutation { reprocessQuarantined { processed } }`, auth.RoleViewer, "forbidden: requires operator role"},
+		{`{ quarantinedRecords { id } }`, auth.RoleViewer, "forbidden: requires operator role"},
+		{`mutation { saveRatingAlias(alias: "x", rating: "Buy") { alias } }`, auth.RoleOperator, "forbidden: requires admin role"},
+		{`mutation { deleteRatingAlias(alias: "x") }`, auth.RoleOperator, "forbidden: requires admin role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: tt.query,
+				Context:       auth.WithIdentity(context.Background(), &auth.Identity{Role: tt.role}),
+			})
+			require.Len(t, result.Errors, 1)
+			assert.Equal(t, tt.wantErr, result.Errors[0].Message)
+		})
+	}
+}
+
+func TestBuildSchema_RequiresAuthentication(t *testing.T) {
+	schema, err := buildSchema(NewResolver(nil, nil, nil, nil, nil, nil))
+	require.NoError(t, err)
+
+	// Todos los campos raíz exigen una identidad: las peticiones que no pasaron por la
+	// autenticación se rechazan antes de llegar a los servicios
+	params := graphql.ResolveParams{Context: context.Background(), Args: map[string]interface{}{}}
+	for name, field := range schema.QueryType().Fields() {
+		_, err := field.Resolve(params)
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated, name)
+	}
+	for name, field := range schema.SubscriptionType().Fields() {
+		_, err := field.Subscribe(params)
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated, name)
+	}
+}
@@ -2,7 +2,10 @@ package graphql
 
 import (
 	"github.com/graphql-go/graphql"
+	"github.com/john/go-react-test/api/internal/application/auth"
 	"github.com/john/go-react-test/api/internal/application/services"
+	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/stock"
 )
 
 // Schema contiene el schema GraphQL completo
@@ -15,8 +18,11 @@ func NewSchema(
 	stockService *services.StockService,
 	syncService *services.SyncService,
 	recommendationService *services.RecommendationService,
+	quarantineService *services.QuarantineService,
+	ratingAliasService *services.RatingAliasService,
+	brokerageService *services.BrokerageService,
 ) (*Schema, error) {
-	resolver := NewResolver(stockService, syncService, recommendationService)
+	resolver := NewResolver(stockService, syncService, recommendationService, quarantineService, ratingAliasService, brokerageService)
 
 	schema, err := buildSchema(resolver)
 	if err != nil {
@@ -34,14 +40,28 @@ func (s *Schema) GetSchema() graphql.Schema {
 // buildSchema construye el schema GraphQL
 func buildSchema(resolver *Resolver) (graphql.Schema, error) {
 	// Definir tipos
-	stockType := defineStockType()
-	recommendationType := defineRecommendationType(stockType)
+	ratingEventType := defineRatingEventType()
+	stockType := defineStockType(resolver, ratingEventType)
+	recommendationType := defineRecommendationType(stockType, defineConsensusType(), defineExplanationFactorType())
+	recommendationExplanationType := defineRecommendationExplanationType(recommendationType)
 	stockConnectionType := defineStockConnectionType(stockType)
+	syncRunType := defineSyncRunType()
 	syncStocksResultType := defineSyncStocksResultType()
+	quarantinedRecordType := defineQuarantinedRecordType()
+	reprocessResultType := defineReprocessResultType(quarantinedRecordType)
+	ratingAliasType := defineRatingAliasType()
+	normalizedRatingType := defineNormalizedRatingType()
+	scoringModelType := defineScoringModelType()
+	brokerageStatsType := defineBrokerageStatsType()
+	searchResultType := defineSearchResultType(stockType)
+	stockStatsType := defineStockStatsType()
 
 	// Definir inputs
 	stockFilterInput := defineStockFilterInput()
 	stockSortInput := defineStockSortInput()
+	recommendationInput := defineRecommendationInput()
+	diversificationInput := defineDiversificationInput()
+	recommendationAlgorithmEnum := defineRecommendationAlgorithmEnum()
 
 	// Definir queries
 	queryType := graphql.NewObject(graphql.ObjectConfig{
@@ -64,8 +84,12 @@ func buildSchema(resolver *Resolver) (graphql.Schema, error) {
 						Type: graphql.Int,
 						DefaultValue: 0,
 					},
+					"cursor": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "endCursor de la página anterior (paginación por keyset); si se indica, offset se ignora",
+					},
 				},
-				Resolve: resolver.Stocks,
+				Resolve: auth.Require(auth.RoleViewer, resolver.Stocks),
 			},
 			"stock": &graphql.Field{
 				Type: stockType,
@@ -74,7 +98,45 @@ func buildSchema(resolver *Resolver) (graphql.Schema, error) {
 						Type: graphql.NewNonNull(graphql.String),
 					},
 				},
-				Resolve: resolver.Stock,
+				Resolve: auth.Require(auth.RoleViewer, resolver.Stock),
+			},
+			"stockStats": &graphql.Field{
+				Type:        graphql.NewNonNull(stockStatsType),
+				Description: "Agregaciones de los stocks que coinciden con el filtro, calculadas en la base de datos",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{
+						Type: stockFilterInput,
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.StockStats),
+			},
+			"search": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(searchResultType))),
+				Description: "Búsqueda por ticker, empresa o brokerage tolerante a errores de escritura, ordenada por relevancia",
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: stock.DefaultSearchLimit,
+						Description:  "Máximo de resultados (hasta 50)",
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.Search),
+			},
+			"history": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ratingEventType))),
+				Args: graphql.FieldConfigArgument{
+					"ticker": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+						DefaultValue: 100,
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.History),
 			},
 			"recommendations": &graphql.Field{
 				Type: graphql.NewList(recommendationType),
@@ -83,32 +145,215 @@ func buildSchema(resolver *Resolver) (graphql.Schema, error) {
 						Type: graphql.Int,
 						DefaultValue: 10,
 					},
+					"input": &graphql.ArgumentConfig{
+						Type:        recommendationInput,
+						Description: "Pesos y filtros para rankings hipotéticos; si se omite se usan los del modelo de scoring",
+					},
+					"algorithm": &graphql.ArgumentConfig{
+						Type:         recommendationAlgorithmEnum,
+						DefaultValue: recommendation.AlgorithmLatest,
+						Description:  "LATEST usa la última llamada por ticker; CONSENSUS agrega las llamadas recientes de todos los brokerages",
+					},
+					"diversification": &graphql.ArgumentConfig{
+						Type:        diversificationInput,
+						Description: "Restricciones de diversificación aplicadas al seleccionar las N primeras",
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.Recommendations),
+			},
+			"explainRecommendation": &graphql.Field{
+				Type:        recommendationExplanationType,
+				Description: "Explica el score de un ticker y, si no aparece en recommendations con los mismos argumentos, por qué",
+				Args: graphql.FieldConfigArgument{
+					"ticker": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: 10,
+					},
+					"input": &graphql.ArgumentConfig{
+						Type: recommendationInput,
+					},
+					"algorithm": &graphql.ArgumentConfig{
+						Type:         recommendationAlgorithmEnum,
+						DefaultValue: recommendation.AlgorithmLatest,
+					},
+					"diversification": &graphql.ArgumentConfig{
+						Type: diversificationInput,
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.ExplainRecommendation),
+			},
+			"syncRuns": &graphql.Field{
+				Type: graphql.NewList(syncRunType),
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+						DefaultValue: 20,
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.SyncRuns),
+			},
+			"syncRun": &graphql.Field{
+				Type: syncRunType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.SyncRun),
+			},
+			"lastSync": &graphql.Field{
+				Type: syncRunType,
+				Args: graphql.FieldConfigArgument{
+					"status": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.LastSync),
+			},
+			"quarantinedRecords": &graphql.Field{
+				Type: graphql.NewList(quarantinedRecordType),
+				Args: graphql.FieldConfigArgument{
+					"status": &graphql.ArgumentConfig{
+						Type: graphql.String,
+						DefaultValue: "pending",
+					},
+					"syncRunId": &graphql.ArgumentConfig{
+						Type: graphql.ID,
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+						DefaultValue: 50,
+					},
+				},
+				Resolve: auth.Require(auth.RoleOperator, resolver.QuarantinedRecords),
+			},
+			"scoringModel": &graphql.Field{
+				Type:    graphql.NewNonNull(scoringModelType),
+				Resolve: auth.Require(auth.RoleViewer, resolver.ScoringModel),
+			},
+			"ratingAliases": &graphql.Field{
+				Type:    graphql.NewList(ratingAliasType),
+				Resolve: auth.Require(auth.RoleViewer, resolver.RatingAliases),
+			},
+			"normalizeRating": &graphql.Field{
+				Type: normalizedRatingType,
+				Args: graphql.FieldConfigArgument{
+					"raw": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: auth.Require(auth.RoleViewer, resolver.NormalizeRating),
+			},
+			"brokerages": &graphql.Field{
+				Type: graphql.NewList(brokerageStatsType),
+				Args: graphql.FieldConfigArgument{
+					"minCalls": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: 0,
+					},
 				},
-				Resolve: resolver.Recommendations,
+				Resolve: auth.Require(auth.RoleViewer, resolver.Brokerages),
 			},
 		},
 	})
 
-	// Definir mutations
+	// Definir mutations: sincronizar y reprocesar la cuarentena requieren rol operator,
+	// modificar los alias de ratings requiere rol admin
 	mutationType := graphql.NewObject(graphql.ObjectConfig{
 		Name: "Mutation",
 		Fields: graphql.Fields{
 			"syncStocks": &graphql.Field{
 				Type: syncStocksResultType,
-				Resolve: resolver.SyncStocks,
+				Args: graphql.FieldConfigArgument{
+					"full": &graphql.ArgumentConfig{
+						Type:         graphql.Boolean,
+						DefaultValue: false,
+						Description:  "Recorrer todas las páginas en lugar de detenerse en los eventos ya ingeridos",
+					},
+				},
+				Resolve: auth.Require(auth.RoleOperator, resolver.SyncStocks),
+			},
+			"reprocessQuarantined": &graphql.Field{
+				Type: reprocessResultType,
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type:        graphql.NewList(graphql.NewNonNull(graphql.ID)),
+						Description: "IDs a reprocesar; si se omite se reprocesan todos los pendientes",
+					},
+				},
+				Resolve: auth.Require(auth.RoleOperator, resolver.ReprocessQuarantined),
+			},
+			"saveRatingAlias": &graphql.Field{
+				Type: ratingAliasType,
+				Args: graphql.FieldConfigArgument{
+					"alias": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"rating": &graphql.ArgumentConfig{
+						Type:        graphql.NewNonNull(graphql.String),
+						Description: "Rating canónico al que se traduce el alias",
+					},
+					"score": &graphql.ArgumentConfig{
+						Type:        graphql.Float,
+						Description: "Si se omite se usa el score por defecto del rating canónico",
+					},
+				},
+				Resolve: auth.Require(auth.RoleAdmin, resolver.SaveRatingAlias),
+			},
+			"deleteRatingAlias": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"alias": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: auth.Require(auth.RoleAdmin, resolver.DeleteRatingAlias),
+			},
+		},
+	})
+
+	// Definir subscriptions (se sirven por WebSocket en el mismo endpoint)
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"ratingChanged": &graphql.Field{
+				Type:        graphql.NewNonNull(defineRatingChangeEventType(stockType)),
+				Description: "Llamadas nuevas o que cambian materialmente el estado de un ticker al sincronizar",
+				Args: graphql.FieldConfigArgument{
+					"tickers": &graphql.ArgumentConfig{
+						Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+						Description: "Solo estos tickers; si se omite, todos",
+					},
+					"ratings": &graphql.ArgumentConfig{
+						Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+						Description: "Solo llamadas con uno de estos ratings destino; si se omite, todos",
+					},
+				},
+				Subscribe: auth.Require(auth.RoleViewer, resolver.SubscribeRatingChanged),
+				Resolve:   subscriptionPayload,
+			},
+			"syncCompleted": &graphql.Field{
+				Type:        graphql.NewNonNull(syncRunType),
+				Description: "Ejecuciones de sincronización a medida que terminan, con éxito o con error",
+				Subscribe:   auth.Require(auth.RoleViewer, resolver.SubscribeSyncCompleted),
+				Resolve:     subscriptionPayload,
 			},
 		},
 	})
 
 	// Crear schema
 	return graphql.NewSchema(graphql.SchemaConfig{
-		Query:    queryType,
-		Mutation: mutationType,
+		Query:        queryType,
+		Mutation:     mutationType,
+		Subscription: subscriptionType,
 	})
 }
 
 // defineStockType define el tipo Stock
-func defineStockType() *graphql.Object {
+func defineStockType(resolver *Resolver, ratingEventType *graphql.Object) *graphql.Object {
 	return graphql.NewObject(graphql.ObjectConfig{
 		Name: "Stock",
 		Fields: graphql.Fields{
@@ -133,154 +378,935 @@ func defineStockType() *graphql.Object {
 			"ratingTo": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.String),
 			},
+			"ratingFromRaw": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Rating original enviado por el broker",
+			},
+			"ratingToRaw": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Rating original enviado por el broker",
+			},
+			"ratingFromScore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"ratingToScore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
 			"targetFrom": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
 			"targetTo": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
+			"eventTime": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+			},
 			"createdAt": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.DateTime),
 			},
 			"updatedAt": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.DateTime),
 			},
+			"history": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ratingEventType))),
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+						DefaultValue: 100,
+					},
+				},
+				Resolve: resolver.StockHistory,
+			},
 		},
 	})
 }
 
-// defineRecommendationType define el tipo Recommendation
-func defineRecommendationType(stockType *graphql.Object) *graphql.Object {
+// defineRatingEventType define el tipo RatingEvent
+func defineRatingEventType() *graphql.Object {
 	return graphql.NewObject(graphql.ObjectConfig{
-		Name: "Recommendation",
+		Name: "RatingEvent",
 		Fields: graphql.Fields{
-			"stock": &graphql.Field{
-				Type: graphql.NewNonNull(stockType),
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
 			},
-			"score": &graphql.Field{
+			"ticker": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"companyName": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"brokerage": &graphql.Field{
+				Type: graphql.String,
+			},
+			"action": &graphql.Field{
+				Type: graphql.String,
+			},
+			"ratingFrom": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"ratingTo": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"ratingFromRaw": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Rating original enviado por el broker",
+			},
+			"ratingToRaw": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Rating original enviado por el broker",
+			},
+			"ratingFromScore": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
-			"priceChange": &graphql.Field{
+			"ratingToScore": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
-			"ratingScore": &graphql.Field{
+			"targetFrom": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
-			"actionScore": &graphql.Field{
+			"targetTo": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
+			"eventTime": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+			},
 		},
 	})
 }
 
-// defineStockConnectionType define el tipo StockConnection
-func defineStockConnectionType(stockType *graphql.Object) *graphql.Object {
+// defineBrokerageStatsType define el tipo BrokerageStats
+func defineBrokerageStatsType() *graphql.Object {
 	return graphql.NewObject(graphql.ObjectConfig{
-		Name: "StockConnection",
+		Name: "BrokerageStats",
 		Fields: graphql.Fields{
-			"stocks": &graphql.Field{
-				Type: graphql.NewList(stockType),
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"totalCount": &graphql.Field{
+			"calls": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Int),
 			},
-			"pageInfo": &graphql.Field{
-				Type: graphql.NewNonNull(definePageInfoType()),
+			"tickers": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"evaluatedCalls": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Llamadas con dirección seguidas de otra llamada del mismo brokerage sobre el ticker",
+			},
+			"hits": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"reversals": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"hitRate": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"avgTargetRevision": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Cambio porcentual medio del precio objetivo en la llamada siguiente",
+			},
+			"reliability": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Tasa de aciertos suavizada hacia 0.5 según el número de llamadas evaluadas",
 			},
 		},
 	})
 }
 
-// definePageInfoType define el tipo PageInfo
-func definePageInfoType() *graphql.Object {
-	return graphql.NewObject(graphql.ObjectConfig{
-		Name: "PageInfo",
+// defineScoringModelType define el tipo ScoringModel
+func defineScoringModelType() *graphql.Object {
+	scoreEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ScoreEntry",
 		Fields: graphql.Fields{
-			"hasNextPage": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Boolean),
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"hasPreviousPage": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Boolean),
+			"score": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
 			},
 		},
 	})
-}
 
-// defineSyncStocksResultType define el tipo SyncStocksResult
-func defineSyncStocksResultType() *graphql.Object {
-	return graphql.NewObject(graphql.ObjectConfig{
-		Name: "SyncStocksResult",
+	scoringWeightsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ScoringWeights",
 		Fields: graphql.Fields{
-			"success": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Boolean),
+			"priceChange": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
 			},
-			"message": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.String),
+			"rating": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
 			},
-			"stocksSynced": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Int),
+			"action": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
 			},
 		},
 	})
-}
 
-// defineStockFilterInput define el input StockFilter
-func defineStockFilterInput() *graphql.InputObject {
-	return graphql.NewInputObject(graphql.InputObjectConfig{
-		Name: "StockFilter",
-		Fields: graphql.InputObjectConfigFieldMap{
-			"ticker": &graphql.InputObjectFieldConfig{
-				Type: graphql.String,
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "ScoringModel",
+		Fields: graphql.Fields{
+			"ratingScores": &graphql.Field{
+				Type:        graphql.NewList(scoreEntryType),
+				Description: "Score por rating canónico, del más alto al más bajo",
 			},
-			"companyName": &graphql.InputObjectFieldConfig{
-				Type: graphql.String,
+			"actionScores": &graphql.Field{
+				Type: graphql.NewList(scoreEntryType),
 			},
-			"ratings": &graphql.InputObjectFieldConfig{
-				Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+			"upgradeBonus": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Se suma al score de rating cuando el rating mejora",
 			},
-			"action": &graphql.InputObjectFieldConfig{
-				Type: graphql.String,
+			"weights": &graphql.Field{
+				Type: graphql.NewNonNull(scoringWeightsType),
+			},
+			"halfLifeDays": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Vida media en días de una llamada; 0 desactiva el decaimiento",
 			},
 		},
 	})
 }
 
-// defineStockSortInput define el input StockSort
-func defineStockSortInput() *graphql.InputObject {
-	stockSortFieldEnum := graphql.NewEnum(graphql.EnumConfig{
-		Name: "StockSortField",
-		Values: graphql.EnumValueConfigMap{
-			"TICKER": &graphql.EnumValueConfig{
-				Value: "ticker",
-			},
-			"COMPANY_NAME": &graphql.EnumValueConfig{
-				Value: "company_name",
-			},
-			"RATING_TO": &graphql.EnumValueConfig{
-				Value: "rating_to",
+// defineRatingAliasType define el tipo RatingAlias
+func defineRatingAliasType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "RatingAlias",
+		Fields: graphql.Fields{
+			"alias": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"TARGET_TO": &graphql.EnumValueConfig{
-				Value: "target_to",
+			"rating": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"CREATED_AT": &graphql.EnumValueConfig{
-				Value: "created_at",
+			"score": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
 			},
-			// También aceptar valores en minúsculas directamente
-			"ticker": &graphql.EnumValueConfig{
-				Value: "ticker",
+			"configured": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "true si el alias está guardado en la base de datos; false si es incorporado",
 			},
-			"company_name": &graphql.EnumValueConfig{
-				Value: "company_name",
+		},
+	})
+}
+
+// defineNormalizedRatingType define el tipo NormalizedRating
+func defineNormalizedRatingType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "NormalizedRating",
+		Fields: graphql.Fields{
+			"raw": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"rating_to": &graphql.EnumValueConfig{
-				Value: "rating_to",
+			"rating": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
 			},
-			"target_to": &graphql.EnumValueConfig{
-				Value: "target_to",
+			"score": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+		},
+	})
+}
+
+// defineRecommendationType define el tipo Recommendation
+func defineRecommendationType(stockType, consensusType, explanationFactorType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Recommendation",
+		Fields: graphql.Fields{
+			"stock": &graphql.Field{
+				Type: graphql.NewNonNull(stockType),
+			},
+			"score": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"priceChange": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"ratingScore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"actionScore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"reliability": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Peso por fiabilidad del brokerage ya aplicado al score (1 = sin ponderar)",
+			},
+			"decayFactor": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Factor por antigüedad de la llamada ya aplicado al score (1 = sin decaimiento)",
+			},
+			"consensus": &graphql.Field{
+				Type:        consensusType,
+				Description: "Componentes del consenso; solo con el algoritmo CONSENSUS",
+			},
+			"explanation": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(explanationFactorType)),
+				Description: "Aporte de cada factor al score; la suma de los aportes es el score",
+			},
+		},
+	})
+}
+
+// defineExplanationFactorType define el tipo ExplanationFactor
+func defineExplanationFactorType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "ExplanationFactor",
+		Fields: graphql.Fields{
+			"factor": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "priceChange, rating, upgradeBonus, action, decay o reliability",
+			},
+			"value": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Valor crudo; en decay y reliability es el multiplicador",
+			},
+			"weight": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Peso del modelo; 1 en los factores multiplicativos",
+			},
+			"contribution": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"reason": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	})
+}
+
+// defineRecommendationExplanationType define el tipo RecommendationExplanation
+func defineRecommendationExplanationType(recommendationType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "RecommendationExplanation",
+		Fields: graphql.Fields{
+			"ticker": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"included": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "true si el ticker aparece en las recomendaciones",
+			},
+			"rank": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Posición entre los stocks que pasan los filtros; null si no los pasa",
+			},
+			"exclusions": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+				Description: "Motivos por los que el ticker no aparece en las recomendaciones",
+			},
+			"recommendation": &graphql.Field{
+				Type:        recommendationType,
+				Description: "Score y explicación por factor; null si no se pudo puntuar",
+			},
+		},
+	})
+}
+
+// defineConsensusType define el tipo Consensus
+func defineConsensusType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Consensus",
+		Fields: graphql.Fields{
+			"calls": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"brokerages": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"meanTarget": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"medianTarget": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"targetStdDev": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"targetDispersion": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Coeficiente de variación de los precios objetivo",
+			},
+			"upgrades": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"downgrades": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"meanRatingScore": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+		},
+	})
+}
+
+// defineRecommendationAlgorithmEnum define el enum RecommendationAlgorithm
+func defineRecommendationAlgorithmEnum() *graphql.Enum {
+	return graphql.NewEnum(graphql.EnumConfig{
+		Name: "RecommendationAlgorithm",
+		Values: graphql.EnumValueConfigMap{
+			"LATEST": &graphql.EnumValueConfig{
+				Value: recommendation.AlgorithmLatest,
+			},
+			"CONSENSUS": &graphql.EnumValueConfig{
+				Value: recommendation.AlgorithmConsensus,
+			},
+		},
+	})
+}
+
+// defineStockConnectionType define el tipo StockConnection
+func defineStockConnectionType(stockType *graphql.Object) *graphql.Object {
+	stockEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StockEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"node": &graphql.Field{
+				Type: graphql.NewNonNull(stockType),
+			},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "StockConnection",
+		Fields: graphql.Fields{
+			"stocks": &graphql.Field{
+				Type: graphql.NewList(stockType),
+			},
+			"edges": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockEdgeType))),
+			},
+			"totalCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(definePageInfoType()),
+			},
+		},
+	})
+}
+
+// defineRatingChangeEventType define el tipo RatingChangeEvent
+func defineRatingChangeEventType(stockType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "RatingChangeEvent",
+		Fields: graphql.Fields{
+			"stock": &graphql.Field{
+				Type:        graphql.NewNonNull(stockType),
+				Description: "Nuevo estado del ticker",
+			},
+			"previous": &graphql.Field{
+				Type:        stockType,
+				Description: "Estado anterior; null si el ticker es nuevo",
+			},
+		},
+	})
+}
+
+// defineStockStatsType define el tipo StockStats
+func defineStockStatsType() *graphql.Object {
+	groupCountType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "GroupCount",
+		Fields: graphql.Fields{
+			"value": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"count": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	})
+
+	bucketType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TargetChangeBucket",
+		Description: "Stocks con cambio porcentual del precio objetivo en [min, max); null no limita",
+		Fields: graphql.Fields{
+			"min": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"max": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"count": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	})
+
+	groupCountList := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(groupCountType)))
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "StockStats",
+		Fields: graphql.Fields{
+			"totalCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"byRating": &graphql.Field{
+				Type:        groupCountList,
+				Description: "Conteo por rating destino, de mayor a menor",
+			},
+			"byAction": &graphql.Field{
+				Type: groupCountList,
+			},
+			"byBrokerage": &graphql.Field{
+				Type: groupCountList,
+			},
+			"upgrades": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"downgrades": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"upgradeDowngradeRatio": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Mejoras por cada empeoramiento de rating; null si no hay empeoramientos",
+			},
+			"targetChangeDistribution": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bucketType))),
+			},
+		},
+	})
+}
+
+// defineSearchResultType define el tipo SearchResult
+func defineSearchResultType(stockType *graphql.Object) *graphql.Object {
+	searchFieldEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "SearchField",
+		Values: graphql.EnumValueConfigMap{
+			"TICKER": &graphql.EnumValueConfig{
+				Value: stock.SearchFieldTicker,
+			},
+			"COMPANY_NAME": &graphql.EnumValueConfig{
+				Value: stock.SearchFieldCompanyName,
+			},
+			"BROKERAGE": &graphql.EnumValueConfig{
+				Value: stock.SearchFieldBrokerage,
+			},
+		},
+	})
+
+	textRangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TextRange",
+		Description: "Tramo [start, end) de un texto, en caracteres",
+		Fields: graphql.Fields{
+			"start": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"end": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "SearchResult",
+		Fields: graphql.Fields{
+			"stock": &graphql.Field{
+				Type: graphql.NewNonNull(stockType),
+			},
+			"score": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "Relevancia entre 0 y 1",
+			},
+			"matchedField": &graphql.Field{
+				Type:        graphql.NewNonNull(searchFieldEnum),
+				Description: "Campo con mejor coincidencia",
+			},
+			"matchedValue": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Valor del campo que coincidió",
+			},
+			"highlights": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(textRangeType))),
+				Description: "Tramos de matchedValue que coinciden con la búsqueda",
+			},
+		},
+	})
+}
+
+// definePageInfoType define el tipo PageInfo
+func definePageInfoType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"hasPreviousPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"startCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+			"endCursor": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Cursor para pedir la página siguiente",
+			},
+		},
+	})
+}
+
+// defineSyncRunType define el tipo SyncRun
+func defineSyncRunType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "SyncRun",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+			},
+			"status": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"trigger": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"mode": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"pagesFetched": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"rowsUpserted": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"rowsRejected": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"error": &graphql.Field{
+				Type: graphql.String,
+			},
+			"newestEventTime": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"enqueuedAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+			},
+			"startedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"finishedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+		},
+	})
+}
+
+// defineQuarantinedRecordType define el tipo QuarantinedRecord
+func defineQuarantinedRecordType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "QuarantinedRecord",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+			},
+			"syncRunId": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"ticker": &graphql.Field{
+				Type: graphql.String,
+			},
+			"payload": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"error": &graphql.Field{
+				Type: graphql.String,
+			},
+			"status": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"attempts": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+			},
+			"lastAttemptAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"resolvedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+		},
+	})
+}
+
+// defineReprocessResultType define el tipo ReprocessResult
+func defineReprocessResultType(quarantinedRecordType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "ReprocessResult",
+		Fields: graphql.Fields{
+			"processed": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"resolved": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"failed": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"records": &graphql.Field{
+				Type: graphql.NewList(quarantinedRecordType),
+			},
+		},
+	})
+}
+
+// defineSyncStocksResultType define el tipo SyncStocksResult
+func defineSyncStocksResultType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "SyncStocksResult",
+		Fields: graphql.Fields{
+			"success": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"message": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"runId": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"status": &graphql.Field{
+				Type: graphql.String,
+			},
+			"mode": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+}
+
+// defineStockFilterInput define el input StockFilter
+func defineStockFilterInput() *graphql.InputObject {
+	ratingChangeEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "RatingChange",
+		Values: graphql.EnumValueConfigMap{
+			"UPGRADE": &graphql.EnumValueConfig{
+				Value: stock.RatingChangeUpgrade,
+			},
+			"DOWNGRADE": &graphql.EnumValueConfig{
+				Value: stock.RatingChangeDowngrade,
+			},
+		},
+	})
+
+	// El input es recursivo (and, or, not), por eso sus campos se definen con un thunk
+	var stockFilterInput *graphql.InputObject
+	stockFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "StockFilter",
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			return graphql.InputObjectConfigFieldMap{
+				"ticker": &graphql.InputObjectFieldConfig{
+					Type: graphql.String,
+				},
+				"tickerPrefix": &graphql.InputObjectFieldConfig{
+					Type:        graphql.String,
+					Description: "Prefijo del ticker, sin distinguir mayúsculas",
+				},
+				"companyName": &graphql.InputObjectFieldConfig{
+					Type: graphql.String,
+				},
+				"ratings": &graphql.InputObjectFieldConfig{
+					Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+				},
+				"brokerages": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+					Description: "Brokerages permitidos, sin distinguir mayúsculas",
+				},
+				"action": &graphql.InputObjectFieldConfig{
+					Type: graphql.String,
+				},
+				"eventTimeFrom": &graphql.InputObjectFieldConfig{
+					Type: graphql.DateTime,
+				},
+				"eventTimeTo": &graphql.InputObjectFieldConfig{
+					Type: graphql.DateTime,
+				},
+				"createdFrom": &graphql.InputObjectFieldConfig{
+					Type: graphql.DateTime,
+				},
+				"createdTo": &graphql.InputObjectFieldConfig{
+					Type: graphql.DateTime,
+				},
+				"targetToMin": &graphql.InputObjectFieldConfig{
+					Type: graphql.Float,
+				},
+				"targetToMax": &graphql.InputObjectFieldConfig{
+					Type: graphql.Float,
+				},
+				"targetFromMin": &graphql.InputObjectFieldConfig{
+					Type: graphql.Float,
+				},
+				"targetFromMax": &graphql.InputObjectFieldConfig{
+					Type: graphql.Float,
+				},
+				"minTargetChange": &graphql.InputObjectFieldConfig{
+					Type:        graphql.Float,
+					Description: "Cambio porcentual mínimo del precio objetivo",
+				},
+				"maxTargetChange": &graphql.InputObjectFieldConfig{
+					Type:        graphql.Float,
+					Description: "Cambio porcentual máximo del precio objetivo",
+				},
+				"ratingChange": &graphql.InputObjectFieldConfig{
+					Type:        ratingChangeEnum,
+					Description: "Solo mejoras o solo empeoramientos del rating",
+				},
+				"and": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(stockFilterInput)),
+					Description: "Todos los filtros deben cumplirse",
+				},
+				"or": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(stockFilterInput)),
+					Description: "Al menos uno de los filtros debe cumplirse",
+				},
+				"not": &graphql.InputObjectFieldConfig{
+					Type:        stockFilterInput,
+					Description: "El filtro no debe cumplirse",
+				},
+			}
+		}),
+	})
+	return stockFilterInput
+}
+
+// defineRecommendationInput define el input RecommendationInput
+func defineRecommendationInput() *graphql.InputObject {
+	weightsInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "RecommendationWeightsInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"priceChange": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"rating": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"action": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+		},
+	})
+
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "RecommendationInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"weights": &graphql.InputObjectFieldConfig{
+				Type:        weightsInput,
+				Description: "Reemplaza los pesos del modelo de scoring",
+			},
+			"minPriceChange": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Float,
+				Description: "Cambio porcentual mínimo del precio objetivo",
+			},
+			"ratings": &graphql.InputObjectFieldConfig{
+				Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+				Description: "Ratings canónicos permitidos; por defecto los positivos",
+			},
+			"brokerages": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+			},
+			"excludeTickers": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+			},
+			"weightByReliability": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Boolean,
+				Description: "Pondera las llamadas por el historial de aciertos de su brokerage",
+			},
+			"halfLifeDays": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Float,
+				Description: "Vida media en días del decaimiento por antigüedad; 0 lo desactiva",
+			},
+		},
+	})
+}
+
+// defineDiversificationInput define el input DiversificationInput
+func defineDiversificationInput() *graphql.InputObject {
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "DiversificationInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"maxPerBrokerage": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Int,
+				Description: "Máximo de recomendaciones de un mismo brokerage; 0 sin límite",
+			},
+			"maxPerCompany": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Int,
+				Description: "Máximo de recomendaciones de una misma empresa (tickers de distintas clases de acciones); 0 sin límite",
+			},
+			"minBrokerages": &graphql.InputObjectFieldConfig{
+				Type:        graphql.Int,
+				Description: "Mínimo de brokerages distintos en la lista cuando hay suficientes candidatos",
+			},
+		},
+	})
+}
+
+// defineStockSortInput define el input StockSort
+func defineStockSortInput() *graphql.InputObject {
+	stockSortFieldEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "StockSortField",
+		Values: graphql.EnumValueConfigMap{
+			"TICKER": &graphql.EnumValueConfig{
+				Value: "ticker",
+			},
+			"COMPANY_NAME": &graphql.EnumValueConfig{
+				Value: "company_name",
+			},
+			"RATING_TO": &graphql.EnumValueConfig{
+				Value: "rating_to",
+			},
+			"TARGET_TO": &graphql.EnumValueConfig{
+				Value: "target_to",
+			},
+			"CREATED_AT": &graphql.EnumValueConfig{
+				Value: "created_at",
+			},
+			"EVENT_TIME": &graphql.EnumValueConfig{
+				Value: "event_time",
+			},
+			"PRICE_CHANGE": &graphql.EnumValueConfig{
+				Value:       stock.SortPriceChange,
+				Description: "Cambio porcentual del precio objetivo",
+			},
+			"RATING_DELTA": &graphql.EnumValueConfig{
+				Value:       stock.SortRatingDelta,
+				Description: "Diferencia entre el score del rating destino y el de origen",
+			},
+			"SCORE": &graphql.EnumValueConfig{
+				Value:       stock.SortScore,
+				Description: "Score de recomendación según el modelo de scoring vigente",
+			},
+			// También aceptar valores en minúsculas directamente
+			"ticker": &graphql.EnumValueConfig{
+				Value: "ticker",
+			},
+			"company_name": &graphql.EnumValueConfig{
+				Value: "company_name",
+			},
+			"rating_to": &graphql.EnumValueConfig{
+				Value: "rating_to",
+			},
+			"target_to": &graphql.EnumValueConfig{
+				Value: "target_to",
 			},
 			"created_at": &graphql.EnumValueConfig{
 				Value: "created_at",
 			},
+			"event_time": &graphql.EnumValueConfig{
+				Value: "event_time",
+			},
+			"price_change": &graphql.EnumValueConfig{
+				Value: stock.SortPriceChange,
+			},
+			"rating_delta": &graphql.EnumValueConfig{
+				Value: stock.SortRatingDelta,
+			},
+			"score": &graphql.EnumValueConfig{
+				Value: stock.SortScore,
+			},
 		},
 	})
 
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/john/go-react-test/api/internal/application/auth"
+)
+
+// CacheVersionFunc retorna la versión de los datos que sirven las queries cacheables; las
+// respuestas cacheadas con otra versión dejan de ser válidas
+type CacheVersionFunc func(ctx context.Context) (string, error)
+
+// CachePolicy define qué queries enviadas por GET se cachean y hasta cuándo
+type CachePolicy struct {
+	// Version identifica los datos servidos; debe cambiar con cada escritura que afecte a Fields
+	Version CacheVersionFunc
+
+	// MaxAge es el max-age de Cache-Control; 0 obliga a revalidar en cada uso
+	MaxAge time.Duration
+
+	// Fields son los campos raíz cacheables; una query con cualquier otro campo no se cachea
+	Fields []string
+
+	// VolatileValues son valores de argumentos (literales o variables) con los que la respuesta
+	// depende del momento de la consulta, como el orden por score con decaimiento
+	VolatileValues []string
+}
+
+// SetCaching habilita el cacheo HTTP de las queries enviadas por GET que cumplen la política:
+// ETag según la versión de los datos y Cache-Control con su max-age
+func (h *GraphQLHandler) SetCaching(policy CachePolicy) {
+	h.cacheVersion = policy.Version
+	h.cacheMaxAge = policy.MaxAge
+	h.cacheFields = toSet(policy.Fields)
+	h.cacheVolatile = toSet(policy.VolatileValues)
+}
+
+// toSet convierte una lista de valores en un conjunto
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// parseGETRequest lee una operación de los parámetros de la URL; variables y extensions
+// vienen codificadas en JSON
+func parseGETRequest(values url.Values) (graphQLRequest, error) {
+	req := graphQLRequest{
+		Query:         values.Get("query"),
+		OperationName: values.Get("operationName"),
+	}
+	if raw := values.Get("variables"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+			return req, err
+		}
+	}
+	if raw := values.Get("extensions"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Extensions); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// etag retorna el ETag de la respuesta a una query por GET, o vacío si no se puede cachear.
+// Depende de la versión de los datos, del rol del cliente (que decide qué campos puede leer)
+// y de la operación.
+func (h *GraphQLHandler) etag(ctx context.Context, req *graphQLRequest) string {
+	if h.cacheVersion == nil || !h.cacheable(req) {
+		return ""
+	}
+	version, err := h.cacheVersion(ctx)
+	if err != nil {
+		log.Printf("Failed to load cache version: %v", err)
+		return ""
+	}
+
+	variables, _ := json.Marshal(req.Variables) // Las claves de los mapas se ordenan
+	hash := sha256.New()
+	for _, part := range []string{
+		version,
+		string(cacheRole(ctx)),
+		req.Query,
+		req.OperationName,
+		string(variables),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// setCacheHeaders agrega los headers de cacheo de una respuesta con el ETag dado.
+// Las respuestas a clientes autenticados solo las puede guardar su navegador.
+func (h *GraphQLHandler) setCacheHeaders(ctx context.Context, w http.ResponseWriter, etag string) {
+	scope := "public"
+	if id, ok := auth.FromContext(ctx); ok && id.Method != auth.MethodAnonymous {
+		scope = "private"
+	}
+	cacheControl := scope + ", max-age=" + strconv.Itoa(int(h.cacheMaxAge.Seconds()))
+	if h.cacheMaxAge <= 0 {
+		cacheControl = scope + ", no-cache"
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Add("Vary", "Authorization, X-API-Key")
+}
+
+// cacheRole retorna el rol de la petición; sin identidad (autenticación desactivada) es vacío
+func cacheRole(ctx context.Context) auth.Role {
+	if id, ok := auth.FromContext(ctx); ok {
+		return id.Role
+	}
+	return ""
+}
+
+// etagMatches retorna true si el header If-None-Match incluye el ETag (comparación débil)
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheable retorna true si la operación solo selecciona campos raíz cacheables y ninguno
+// recibe un argumento volátil
+func (h *GraphQLHandler) cacheable(req *graphQLRequest) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+
+	w := &cacheWalker{
+		fields:    h.cacheFields,
+		volatile:  h.cacheVolatile,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		defaults:  make(map[string]ast.Value),
+		variables: req.Variables,
+		visiting:  make(map[string]bool),
+	}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.OperationDefinition:
+			if req.OperationName == "" || (d.Name != nil && d.Name.Value == req.OperationName) {
+				op = d
+			}
+		case *ast.FragmentDefinition:
+			w.fragments[d.Name.Value] = d
+		}
+	}
+	if op == nil {
+		return false
+	}
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			w.defaults[def.Variable.Name.Value] = def.DefaultValue
+		}
+	}
+	return w.selectionSet(op.SelectionSet)
+}
+
+// cacheWalker recorre los campos raíz de una operación para decidir si se puede cachear
+type cacheWalker struct {
+	fields    map[string]bool
+	volatile  map[string]bool
+	fragments map[string]*ast.FragmentDefinition
+	defaults  map[string]ast.Value // Valores por defecto de las variables de la operación
+	variables map[string]interface{}
+	visiting  map[string]bool // Fragmentos en recorrido, para no seguir ciclos (los rechaza la validación)
+}
+
+// selectionSet retorna true si todas las selecciones son cacheables
+func (w *cacheWalker) selectionSet(set *ast.SelectionSet) bool {
+	if set == nil {
+		return true
+	}
+
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			if !w.fields[s.Name.Value] {
+				return false
+			}
+			for _, arg := range s.Arguments {
+				if w.volatileValue(arg.Value) {
+					return false
+				}
+			}
+		case *ast.InlineFragment:
+			if !w.selectionSet(s.SelectionSet) {
+				return false
+			}
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok {
+				return false
+			}
+			if w.visiting[name] {
+				continue
+			}
+			w.visiting[name] = true
+			cacheable := w.selectionSet(fragment.SelectionSet)
+			delete(w.visiting, name)
+			if !cacheable {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// volatileValue retorna true si el valor de un argumento contiene un valor volátil
+func (w *cacheWalker) volatileValue(value ast.Value) bool {
+	switch v := value.(type) {
+	case *ast.EnumValue:
+		return w.volatile[v.Value]
+	case *ast.StringValue:
+		return w.volatile[v.Value]
+	case *ast.ListValue:
+		for _, item := range v.Values {
+			if w.volatileValue(item) {
+				return true
+			}
+		}
+	case *ast.ObjectValue:
+		for _, field := range v.Fields {
+			if w.volatileValue(field.Value) {
+				return true
+			}
+		}
+	case *ast.Variable:
+		if sent, ok := w.variables[v.Name.Value]; ok {
+			return w.volatileVariable(sent)
+		}
+		if def, ok := w.defaults[v.Name.Value]; ok {
+			return w.volatileValue(def)
+		}
+	}
+	return false
+}
+
+// volatileVariable retorna true si el valor JSON de una variable contiene un valor volátil
+func (w *cacheWalker) volatileVariable(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return w.volatile[v]
+	case []interface{}:
+		for _, item := range v {
+			if w.volatileVariable(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if w.volatileVariable(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,15 +1,48 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/john/go-react-test/api/internal/application/auth"
+	"github.com/john/go-react-test/api/internal/application/limits"
+	"github.com/john/go-react-test/api/internal/application/persisted"
+	"github.com/john/go-react-test/api/internal/infrastructure/websocket"
 )
 
+// ConnectionInitFunc procesa el payload de connection_init de una conexión WebSocket
+// y retorna el contexto de sus operaciones; un error rechaza la conexión
+type ConnectionInitFunc func(ctx context.Context, payload map[string]interface{}) (context.Context, error)
+
 // GraphQLHandler maneja las peticiones GraphQL
 type GraphQLHandler struct {
-	schema gql.Schema
+	schema         gql.Schema
+	connectionInit ConnectionInitFunc
+	analyzer       *limits.Analyzer
+	rateLimiter    *limits.RateLimiter
+	persisted      *persisted.Queries
+	cacheVersion   CacheVersionFunc
+	cacheMaxAge    time.Duration
+	cacheFields    map[string]bool // Campos raíz cacheables
+	cacheVolatile  map[string]bool // Valores de argumentos que impiden cachear
+	allowedOrigins []string        // Orígenes que pueden abrir conexiones WebSocket
+}
+
+// graphQLRequest es una operación GraphQL enviada por HTTP o por WebSocket
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+	Extensions    map[string]interface{} `json:"extensions"`
 }
 
 // NewGraphQLHandler crea un nuevo handler GraphQL
@@ -19,44 +52,96 @@ func NewGraphQLHandler(schema gql.Schema) *GraphQLHandler {
 	}
 }
 
+// SetConnectionInit configura el procesamiento de connection_init (ej: autenticación)
+func (h *GraphQLHandler) SetConnectionInit(fn ConnectionInitFunc) {
+	h.connectionInit = fn
+}
+
+// SetLimits configura los límites de costo de las operaciones y el límite de tasa por cliente;
+// nil desactiva cada uno
+func (h *GraphQLHandler) SetLimits(analyzer *limits.Analyzer, rateLimiter *limits.RateLimiter) {
+	h.analyzer = analyzer
+	h.rateLimiter = rateLimiter
+}
+
+// SetAllowedOrigins configura los orígenes (los de CORS) desde los que un navegador puede
+// abrir una conexión WebSocket; sin configurar solo se acepta el mismo host
+func (h *GraphQLHandler) SetAllowedOrigins(origins []string) {
+	h.allowedOrigins = origins
+}
+
+// SetPersistedQueries configura las persisted queries (APQ o allowlist); nil las desactiva
+func (h *GraphQLHandler) SetPersistedQueries(queries *persisted.Queries) {
+	h.persisted = queries
+}
+
 // ServeHTTP implementa http.Handler
 func (h *GraphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Configurar CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// Manejar preflight requests
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	// Las suscripciones se sirven por WebSocket en el mismo endpoint
+	if websocket.IsUpgradeRequest(r) {
+		h.serveWebSocket(w, r)
 		return
 	}
 
-	// Solo aceptar POST para queries GraphQL
-	if r.Method != "POST" {
+	// CORS y headers de seguridad los aplica la cadena de middlewares (ver cmd/main.go)
+
+	// POST con la operación en el body, o GET con la operación en la URL (solo queries, cacheables)
+	var req graphQLRequest
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	case http.MethodGet:
+		var err error
+		if req, err = parseGETRequest(r.URL.Query()); err != nil {
+			http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Parsear request body
-	var req struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}
+	// Crear un contexto con timeout más largo para operaciones como syncStocks
+	ctx := r.Context()
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// Rechazar la operación antes de ejecutarla si el cliente superó su tasa, no está en la
+	// allowlist de persisted queries o es demasiado costosa
+	if err := h.admit(ctx, r.RemoteAddr, &req); err != nil {
+		writeRejection(w, err)
 		return
 	}
 
-	// Crear un contexto con timeout más largo para operaciones como syncStocks
-	ctx := r.Context()
+	// Por GET no se ejecutan mutations (un link o un prefetch no debe modificar datos) ni suscripciones
+	var etag string
+	if r.Method == http.MethodGet {
+		if op := operationType(req.Query, req.OperationName); op != "" && op != ast.OperationTypeQuery {
+			w.Header().Set("Allow", "POST")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(&gql.Result{Errors: []gqlerrors.FormattedError{
+				gqlerrors.NewFormattedError("GET requests only support query operations; use POST for " + op + "s"),
+			}})
+			return
+		}
+
+		// Si el cliente tiene la respuesta vigente no se ejecuta la query
+		if etag = h.etag(ctx, &req); etag != "" && etagMatches(r.Header.Get("If-None-Match"), etag) {
+			h.setCacheHeaders(ctx, w, etag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
 	
 	// Ejecutar query
 	result := gql.Do(gql.Params{
 		Schema:         h.schema,
 		RequestString:  req.Query,
 		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
 		Context:       ctx,
 	})
 
@@ -75,7 +160,10 @@ func (h *GraphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Configurar headers
+	// Configurar headers; las respuestas con errores no se cachean
+	if etag != "" && len(result.Errors) == 0 {
+		h.setCacheHeaders(ctx, w, etag)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK) // GraphQL siempre retorna 200, errores van en el body
 
@@ -92,6 +180,72 @@ func (h *GraphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// admit aplica el límite de tasa del cliente, resuelve la persisted query de la petición
+// (reemplazando req.Query) y aplica los límites de costo de la operación
+func (h *GraphQLHandler) admit(ctx context.Context, remoteAddr string, req *graphQLRequest) error {
+	if err := h.rateLimiter.Allow(clientKey(ctx, remoteAddr)); err != nil {
+		return err
+	}
+
+	query, err := h.persisted.Resolve(ctx, req.Query, req.Extensions)
+	if err != nil {
+		return err
+	}
+	req.Query = query
+
+	if h.analyzer == nil {
+		return nil
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		// La ejecución reporta el error de sintaxis
+		return nil
+	}
+	_, err = h.analyzer.Check(doc, req.OperationName, req.Variables)
+	return err
+}
+
+// clientKey identifica al cliente para el límite de tasa: la API key o el sujeto del JWT,
+// o la IP para los clientes anónimos
+func clientKey(ctx context.Context, remoteAddr string) string {
+	if id, ok := auth.FromContext(ctx); ok && id.Method != auth.MethodAnonymous {
+		return id.Method + ":" + id.Subject
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
+
+// formattedError es un rechazo con su propio formato de error GraphQL (limits.Error, persisted.Error)
+type formattedError interface {
+	Formatted() gqlerrors.FormattedError
+}
+
+// rejectionResult convierte el rechazo de una operación en una respuesta GraphQL
+func rejectionResult(err error) *gql.Result {
+	var formatted formattedError
+	if errors.As(err, &formatted) {
+		return &gql.Result{Errors: []gqlerrors.FormattedError{formatted.Formatted()}}
+	}
+	return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+}
+
+// writeRejection responde el rechazo de una operación; el límite de tasa responde 429 con Retry-After
+func writeRejection(w http.ResponseWriter, err error) {
+	status := http.StatusOK
+	var limitErr *limits.Error
+	if errors.As(err, &limitErr) && limitErr.Code == limits.CodeRateLimited {
+		w.Header().Set("Retry-After", strconv.Itoa(limitErr.RetryAfterSeconds()))
+		status = http.StatusTooManyRequests
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rejectionResult(err))
+}
+
 // PlaygroundHandler maneja el GraphQL Playground (simple HTML)
 func PlaygroundHandler(title, endpoint string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -125,7 +279,8 @@ func playgroundHTML(title, endpoint string) string {
 		<script>
 			window.addEventListener('load', function (event) {
 				GraphQLPlayground.init(document.getElementById('root'), {
-					endpoint: '` + endpoint + `'
+					endpoint: '` + endpoint + `',
+					subscriptionEndpoint: (location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + '` + endpoint + `'
 				})
 			})
 		</script>
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/john/go-react-test/api/internal/application/auth"
+	"github.com/john/go-react-test/api/internal/application/limits"
+	"github.com/john/go-react-test/api/internal/application/persisted"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postQuery ejecuta una query por HTTP con la identidad dada
+func postQuery(t *testing.T, handler http.Handler, id *auth.Identity, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:4321"
+	if id != nil {
+		req = req.WithContext(auth.WithIdentity(req.Context(), id))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec, response
+}
+
+func TestGraphQLHandler_RateLimit(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetLimits(nil, limits.NewRateLimiter(0.001, 1))
+
+	ops := &auth.Identity{Subject: "ops", Role: auth.RoleOperator, Method: auth.MethodAPIKey}
+	rec, response := postQuery(t, handler, ops, `{"query":"{ hello }"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	rec, response = postQuery(t, handler, ops, `{"query":"{ hello }"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	errs := response["errors"].([]interface{})
+	require.Len(t, errs, 1)
+	ext := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	assert.Equal(t, limits.CodeRateLimited, ext["code"])
+	assert.Greater(t, ext["retryAfter"], float64(0))
+
+	// Otro cliente (los anónimos se identifican por IP) tiene su propio bucket
+	rec, _ = postQuery(t, handler, nil, `{"query":"{ hello }"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGraphQLHandler_QueryLimits(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetLimits(limits.NewAnalyzer(testSchema(t), limits.Config{MaxComplexity: 1}), nil)
+
+	// Se analiza la operación seleccionada por operationName
+	body := `{"query":"query A { hello } query B { hello viewer }","operationName":"%s"}`
+	rec, response := postQuery(t, handler, nil, fmt.Sprintf(body, "A"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	rec, response = postQuery(t, handler, nil, fmt.Sprintf(body, "B"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, response["data"])
+	errs := response["errors"].([]interface{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, map[string]interface{}{"code": limits.CodeQueryTooComplex, "complexity": float64(2), "maxComplexity": float64(1)},
+		errs[0].(map[string]interface{})["extensions"])
+
+	// Los errores de sintaxis los reporta la ejecución
+	_, response = postQuery(t, handler, nil, `{"query":"{ hello "}`)
+	assert.Len(t, response["errors"], 1)
+	assert.Nil(t, response["data"])
+}
+
+// errorCode retorna el extensions.code del único error de una respuesta
+func errorCode(t *testing.T, response map[string]interface{}) interface{} {
+	t.Helper()
+
+	errs, _ := response["errors"].([]interface{})
+	require.Len(t, errs, 1)
+	ext, _ := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	return ext["code"]
+}
+
+func TestGraphQLHandler_AutomaticPersistedQueries(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetPersistedQueries(persisted.New(persisted.NewMemoryStore(10), persisted.ModeAutomatic))
+
+	hash := persisted.Hash("{ hello }")
+	hashOnly := fmt.Sprintf(`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"%s"}}}`, hash)
+
+	// Primero el cliente envía solo el hash; al no conocerlo, reintenta con la query completa
+	rec, response := postQuery(t, handler, nil, hashOnly)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, persisted.CodeNotFound, errorCode(t, response))
+	assert.Equal(t, "PersistedQueryNotFound", response["errors"].([]interface{})[0].(map[string]interface{})["message"])
+
+	_, response = postQuery(t, handler, nil, fmt.Sprintf(
+		`{"query":"{ hello }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"%s"}}}`, hash))
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	// Ya registrada, basta con el hash
+	_, response = postQuery(t, handler, nil, hashOnly)
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	// El hash debe corresponder a la query
+	_, response = postQuery(t, handler, nil, fmt.Sprintf(
+		`{"query":"{ viewer }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"%s"}}}`, hash))
+	assert.Equal(t, persisted.CodeHashMismatch, errorCode(t, response))
+
+	// Las queries sin hash se siguen ejecutando
+	_, response = postQuery(t, handler, nil, `{"query":"{ viewer }"}`)
+	assert.Equal(t, map[string]interface{}{"viewer": nil}, response["data"])
+}
+
+func TestGraphQLHandler_PersistedQueryAllowlist(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetPersistedQueries(persisted.New(
+		persisted.NewStaticStore(map[string]string{persisted.Hash("{ hello }"): "{ hello }"}), persisted.ModeAllowlist))
+
+	// Las queries registradas se ejecutan por hash o con su texto completo
+	_, response := postQuery(t, handler, nil, fmt.Sprintf(
+		`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"%s"}}}`, persisted.Hash("{ hello }")))
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	_, response = postQuery(t, handler, nil, `{"query":"{ hello }"}`)
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, response["data"])
+
+	// El resto se rechaza, incluso si el cliente intenta registrarlas
+	_, response = postQuery(t, handler, nil, `{"query":"{ viewer }"}`)
+	assert.Nil(t, response["data"])
+	assert.Equal(t, persisted.CodeNotAllowed, errorCode(t, response))
+
+	rec, response := postQuery(t, handler, nil, fmt.Sprintf(
+		`{"query":"{ viewer }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"%s"}}}`, persisted.Hash("{ viewer }")))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, persisted.CodeNotAllowed, errorCode(t, response))
+}
+
+// getQuery ejecuta una operación por GET con los parámetros y headers dados
+func getQuery(t *testing.T, handler http.Handler, id *auth.Identity, params url.Values, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/query?"+params.Encode(), nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if id != nil {
+		req = req.WithContext(auth.WithIdentity(req.Context(), id))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGraphQLHandler_GET(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+
+	rec := getQuery(t, handler, nil, url.Values{
+		"query":         {"query A($skip: Boolean!) { hello viewer @skip(if: $skip) } query B { viewer }"},
+		"operationName": {"A"},
+		"variables":     {`{"skip":true}`},
+	}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"hello":"world"}}`, rec.Body.String())
+	// Sin SetCaching no se agregan headers de cacheo
+	assert.Empty(t, rec.Header().Get("ETag"))
+
+	// Mutations y suscripciones solo por POST
+	for _, query := range []string{"mutation { noop }", "subscription { letters }"} {
+		rec = getQuery(t, handler, nil, url.Values{"query": {query}}, nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, query)
+		assert.Equal(t, "POST", rec.Header().Get("Allow"))
+		assert.Contains(t, rec.Body.String(), "GET requests only support query operations")
+	}
+
+	rec = getQuery(t, handler, nil, url.Values{"query": {"{ hello }"}, "variables": {"{"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPut, "/query", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, POST", rec.Header().Get("Allow"))
+}
+
+func TestGraphQLHandler_GETCaching(t *testing.T) {
+	version := "1"
+	var versionErr error
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetCaching(CachePolicy{
+		Version: func(context.Context) (string, error) { return version, versionErr },
+		MaxAge:  time.Minute,
+		Fields:  []string{"hello", "viewer"},
+	})
+	hello := url.Values{"query": {"{ hello }"}}
+
+	rec := getQuery(t, handler, nil, hello, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "public, max-age=60", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "Authorization, X-API-Key", rec.Header().Get("Vary"))
+
+	// Con la respuesta vigente se responde 304 sin body
+	rec = getQuery(t, handler, nil, hello, http.Header{"If-None-Match": {`"other", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	// Otra query u otro rol tienen otro ETag
+	rec = getQuery(t, handler, nil, url.Values{"query": {"{ viewer }"}}, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	ops := &auth.Identity{Subject: "ops", Role: auth.RoleOperator, Method: auth.MethodAPIKey}
+	rec = getQuery(t, handler, ops, hello, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+
+	// Una escritura invalida las respuestas anteriores
+	version = "2"
+	rec = getQuery(t, handler, nil, hello, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	// Los errores y las peticiones POST no se cachean
+	rec = getQuery(t, handler, nil, url.Values{"query": {"{ missing }"}}, nil)
+	assert.Empty(t, rec.Header().Get("ETag"))
+	rec, _ = postQuery(t, handler, nil, `{"query":"{ hello }"}`)
+	assert.Empty(t, rec.Header().Get("ETag"))
+
+	// Si no se puede leer la versión se responde sin cacheo
+	versionErr = errors.New("database unavailable")
+	rec = getQuery(t, handler, nil, hello, http.Header{"If-None-Match": {"*"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
+
+// cacheTestSchema retorna un schema con un saludo cacheable que modifica la mutation
+// setGreeting y un reloj que no se puede cachear. La versión de datos se deriva del
+// saludo guardado, como la del repositorio se deriva de las tablas.
+func cacheTestSchema(t *testing.T) (gql.Schema, CacheVersionFunc) {
+	greeting := "hello"
+	version := func(context.Context) (string, error) { return greeting, nil }
+	style := gql.NewEnum(gql.EnumConfig{
+		Name: "Style",
+		Values: gql.EnumValueConfigMap{
+			"PLAIN": &gql.EnumValueConfig{Value: "plain"},
+			"LIVE":  &gql.EnumValueConfig{Value: "live"},
+		},
+	})
+
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: gql.NewObject(gql.ObjectConfig{
+			Name: "Query",
+			Fields: gql.Fields{
+				"greeting": &gql.Field{
+					Type: gql.String,
+					Args: gql.FieldConfigArgument{"style": &gql.ArgumentConfig{Type: style}},
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						if p.Args["style"] == "live" {
+							return greeting + " at " + time.Now().Format(time.RFC3339Nano), nil
+						}
+						return greeting, nil
+					},
+				},
+				"clock": &gql.Field{
+					Type:    gql.String,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) { return time.Now().Format(time.RFC3339Nano), nil },
+				},
+			},
+		}),
+		Mutation: gql.NewObject(gql.ObjectConfig{
+			Name: "Mutation",
+			Fields: gql.Fields{
+				"setGreeting": &gql.Field{
+					Type: gql.String,
+					Args: gql.FieldConfigArgument{"text": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)}},
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						greeting = p.Args["text"].(string)
+						return greeting, nil
+					},
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+	return schema, version
+}
+
+func TestGraphQLHandler_GETCachePolicy(t *testing.T) {
+	schema, version := cacheTestSchema(t)
+	handler := NewGraphQLHandler(schema)
+	handler.SetCaching(CachePolicy{
+		Version:        version,
+		MaxAge:         time.Minute,
+		Fields:         []string{"greeting"},
+		VolatileValues: []string{"LIVE"},
+	})
+	greeting := url.Values{"query": {"{ greeting }"}}
+
+	rec := getQuery(t, handler, nil, greeting, nil)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	rec = getQuery(t, handler, nil, greeting, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Una mutation que escribe datos invalida el ETag anterior
+	rec, _ = postQuery(t, handler, nil, `{"query":"mutation { setGreeting(text: \"hola\") }"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = getQuery(t, handler, nil, greeting, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"greeting":"hola"}}`, rec.Body.String())
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	// Solo se cachean los campos de la allowlist (también dentro de fragmentos) y sin valores volátiles
+	for _, params := range []url.Values{
+		{"query": {"{ greeting clock }"}},
+		{"query": {"{ ...F } fragment F on Query { clock }"}},
+		{"query": {"{ greeting(style: LIVE) }"}},
+		{"query": {"query Q($s: Style) { greeting(style: $s) }"}, "variables": {`{"s":"LIVE"}`}},
+		{"query": {"query Q($s: Style = LIVE) { greeting(style: $s) }"}},
+	} {
+		rec = getQuery(t, handler, nil, params, nil)
+		assert.Equal(t, http.StatusOK, rec.Code, params.Get("query"))
+		assert.Empty(t, rec.Header().Get("ETag"), params.Get("query"))
+	}
+
+	rec = getQuery(t, handler, nil, url.Values{"query": {"{ __typename greeting(style: PLAIN) }"}}, nil)
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+}
+
+func TestGraphQLHandler_GETPersistedQuery(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetPersistedQueries(persisted.New(
+		persisted.NewStaticStore(map[string]string{persisted.Hash("{ hello }"): "{ hello }"}), persisted.ModeAllowlist))
+	handler.SetCaching(CachePolicy{
+		Version: func(context.Context) (string, error) { return "1", nil },
+		Fields:  []string{"hello"},
+	})
+
+	// Con el hash en la URL la query cabe en un GET cacheable por un CDN
+	rec := getQuery(t, handler, nil, url.Values{
+		"extensions": {fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, persisted.Hash("{ hello }"))},
+	}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"hello":"world"}}`, rec.Body.String())
+	assert.Equal(t, "public, no-cache", rec.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/john/go-react-test/api/internal/infrastructure/websocket"
+)
+
+// Subprotocolos de GraphQL sobre WebSocket soportados, en orden de preferencia
+const (
+	protocolTransportWS = "graphql-transport-ws" // Librería graphql-ws
+	protocolLegacyWS    = "graphql-ws"           // subscriptions-transport-ws (GraphQL Playground)
+)
+
+// Códigos de cierre de graphql-transport-ws
+const (
+	closeBadRequest       = 4400
+	closeUnauthorized     = 4401
+	closeForbidden        = 4403
+	closeInitTimeout      = 4408
+	closeSubscriberExists = 4409
+	closeTooManyInits     = 4429
+)
+
+var (
+	// wsInitTimeout es el plazo para que el cliente envíe connection_init
+	wsInitTimeout = 10 * time.Second
+
+	// wsKeepAliveInterval es cada cuánto se envía un ping (o ka en el protocolo legacy)
+	wsKeepAliveInterval = 20 * time.Second
+)
+
+// wsMessage es un mensaje de los protocolos graphql-transport-ws y graphql-ws
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsSession es una conexión WebSocket con sus operaciones en curso
+type wsSession struct {
+	schema       gql.Schema
+	init         ConnectionInitFunc
+	admit        func(ctx context.Context, req *graphQLRequest) error
+	conn         *websocket.Conn
+	legacy       bool
+	acknowledged atomic.Bool
+
+	mu         sync.Mutex
+	operations map[string]context.CancelFunc
+}
+
+// serveWebSocket atiende una conexión WebSocket de suscripciones GraphQL
+func (h *GraphQLHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r, websocket.Options{
+		Protocols:      []string{protocolTransportWS, protocolLegacyWS},
+		AllowedOrigins: h.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// La conexión sobrevive al ciclo normal de la request; se conservan los valores del contexto
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	s := &wsSession{
+		schema: h.schema,
+		init:   h.connectionInit,
+		admit: func(ctx context.Context, req *graphQLRequest) error {
+			return h.admit(ctx, r.RemoteAddr, req)
+		},
+		conn:       conn,
+		legacy:     conn.Subprotocol() == protocolLegacyWS,
+		operations: make(map[string]context.CancelFunc),
+	}
+	s.run(ctx)
+}
+
+// run lee los mensajes del cliente hasta que cierre la conexión
+func (s *wsSession) run(ctx context.Context) {
+	initTimer := time.AfterFunc(wsInitTimeout, func() {
+		if !s.acknowledged.Load() {
+			s.close(closeInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	for {
+		data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+			s.close(closeBadRequest, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if s.acknowledged.Swap(true) {
+				if !s.legacy {
+					s.close(closeTooManyInits, "Too many initialisation requests")
+					return
+				}
+				continue
+			}
+			if s.init != nil {
+				var payload map[string]interface{}
+				if len(msg.Payload) > 0 {
+					json.Unmarshal(msg.Payload, &payload)
+				}
+				initCtx, err := s.init(ctx, payload)
+				if err != nil {
+					s.reject(err)
+					return
+				}
+				ctx = initCtx
+			}
+			s.send(wsMessage{Type: "connection_ack"})
+			go s.keepAlive(ctx)
+		case "ping":
+			s.send(wsMessage{Type: "pong", Payload: msg.Payload})
+		case "pong":
+		case "subscribe", "start":
+			if !s.acknowledged.Load() {
+				s.close(closeUnauthorized, "Unauthorized")
+				return
+			}
+			if msg.ID == "" {
+				s.close(closeBadRequest, "Operation id is required")
+				return
+			}
+			// Payload de subscribe (start en el protocolo legacy)
+			var req graphQLRequest
+			if err := json.Unmarshal(msg.Payload, &req); err != nil {
+				s.close(closeBadRequest, "Invalid operation payload")
+				return
+			}
+			if !s.start(ctx, msg.ID, req) {
+				s.close(closeSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+				return
+			}
+		case "complete", "stop":
+			s.stop(msg.ID)
+		case "connection_terminate":
+			return
+		default:
+			s.close(closeBadRequest, "Unknown message type: "+msg.Type)
+			return
+		}
+	}
+}
+
+// start ejecuta una operación en segundo plano; retorna false si el id ya está en uso
+func (s *wsSession) start(ctx context.Context, id string, req graphQLRequest) bool {
+	s.mu.Lock()
+	if _, exists := s.operations[id]; exists {
+		s.mu.Unlock()
+		return false
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	s.operations[id] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		// admit resuelve la persisted query antes de armar los parámetros
+		err := s.admit(opCtx, &req)
+		params := gql.Params{
+			Schema:         s.schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        opCtx,
+		}
+
+		completed := true
+		if err != nil {
+			completed = s.sendResult(id, rejectionResult(err))
+		} else if isSubscription(req.Query, req.OperationName) {
+			// El canal se cierra al terminar la suscripción o cancelarse opCtx;
+			// un error termina la operación pero hay que vaciar el canal igualmente
+			for result := range gql.Subscribe(params) {
+				if completed && !s.sendResult(id, result) {
+					completed = false
+					cancel()
+				}
+			}
+		} else {
+			completed = s.sendResult(id, gql.Do(params))
+		}
+
+		// No se envía complete si el cliente ya detuvo la operación o si terminó con error
+		s.mu.Lock()
+		_, active := s.operations[id]
+		delete(s.operations, id)
+		s.mu.Unlock()
+		if active && completed {
+			s.send(wsMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// stop cancela una operación a pedido del cliente
+func (s *wsSession) stop(id string) {
+	s.mu.Lock()
+	cancel, ok := s.operations[id]
+	delete(s.operations, id)
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// sendResult envía un resultado de la operation id; retorna false si fue un error que
+// termina la operación (errores de validación o ejecución sin datos en graphql-transport-ws)
+func (s *wsSession) sendResult(id string, result *gql.Result) bool {
+	if !s.legacy && result.Data == nil && len(result.Errors) > 0 {
+		payload, _ := json.Marshal(result.Errors)
+		s.send(wsMessage{ID: id, Type: "error", Payload: payload})
+		return false
+	}
+
+	msgType := "next"
+	if s.legacy {
+		msgType = "data"
+	}
+	payload, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to encode subscription result: %v", err)
+		return true
+	}
+	s.send(wsMessage{ID: id, Type: msgType, Payload: payload})
+	return true
+}
+
+// keepAlive mantiene viva la conexión detrás de proxies que cierran conexiones inactivas
+func (s *wsSession) keepAlive(ctx context.Context) {
+	msg := wsMessage{Type: "ping"}
+	if s.legacy {
+		msg.Type = "ka"
+	}
+
+	ticker := time.NewTicker(wsKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.send(msg)
+		}
+	}
+}
+
+// send envía un mensaje; los errores de escritura se detectan al leer el siguiente mensaje
+func (s *wsSession) send(msg wsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.conn.WriteMessage(data)
+}
+
+// reject rechaza connection_init; el protocolo legacy lo notifica con connection_error
+func (s *wsSession) reject(err error) {
+	if s.legacy {
+		payload, _ := json.Marshal(map[string]string{"message": err.Error()})
+		s.send(wsMessage{Type: "connection_error", Payload: payload})
+	}
+	s.close(closeForbidden, "Forbidden")
+}
+
+// close cierra la conexión con un código de graphql-transport-ws
+func (s *wsSession) close(code int, reason string) {
+	s.conn.WriteClose(code, reason)
+	s.conn.Close()
+}
+
+// isSubscription retorna true si la operación a ejecutar es una suscripción
+func isSubscription(query, operationName string) bool {
+	return operationType(query, operationName) == ast.OperationTypeSubscription
+}
+
+// operationType retorna el tipo (query, mutation o subscription) de la operación a ejecutar,
+// o vacío si la query no es válida o no contiene la operación
+func operationType(query, operationName string) string {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return ""
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op.Operation
+		}
+	}
+	return ""
+}
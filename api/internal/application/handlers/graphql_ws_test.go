@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/john/go-react-test/api/internal/application/limits"
+	"github.com/john/go-react-test/api/internal/application/persisted"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewerKey es la clave del contexto que lee la query viewer del schema de prueba
+type viewerKey struct{}
+
+// testSchema tiene queries, una mutation y una suscripción que emite "a" y "b" y termina
+func testSchema(t *testing.T) gql.Schema {
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: gql.NewObject(gql.ObjectConfig{
+			Name: "Query",
+			Fields: gql.Fields{
+				"hello": &gql.Field{
+					Type:    gql.String,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) { return "world", nil },
+				},
+				"viewer": &gql.Field{
+					Type:    gql.String,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Context.Value(viewerKey{}), nil },
+				},
+			},
+		}),
+		Mutation: gql.NewObject(gql.ObjectConfig{
+			Name: "Mutation",
+			Fields: gql.Fields{
+				"noop": &gql.Field{
+					Type:    gql.Boolean,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) { return true, nil },
+				},
+			},
+		}),
+		Subscription: gql.NewObject(gql.ObjectConfig{
+			Name: "Subscription",
+			Fields: gql.Fields{
+				"letters": &gql.Field{
+					Type: gql.String,
+					Subscribe: func(p gql.ResolveParams) (interface{}, error) {
+						ch := make(chan interface{})
+						go func() {
+							defer close(ch)
+							for _, l := range []string{"a", "b"} {
+								select {
+								case ch <- l:
+								case <-p.Context.Done():
+									return
+								}
+							}
+						}()
+						return ch, nil
+					},
+					Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source, nil },
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+// wsTestClient es un cliente mínimo de graphql-transport-ws
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialGraphQL(t *testing.T, protocol string) *wsTestClient {
+	t.Helper()
+	return dialHandler(t, NewGraphQLHandler(testSchema(t)), protocol)
+}
+
+func dialHandler(t *testing.T, handler http.Handler, protocol string) *wsTestClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err = conn.Write([]byte("GET /query HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Protocol: " + protocol + "\r\n\r\n"))
+	require.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	return &wsTestClient{conn: conn, br: br}
+}
+
+func (c *wsTestClient) send(t *testing.T, msg string) {
+	t.Helper()
+
+	frame := []byte{0x81, 0x80 | byte(len(msg))}
+	if len(msg) > 125 {
+		frame = []byte{0x81, 0x80 | 126, byte(len(msg) >> 8), byte(len(msg))}
+	}
+	frame = append(frame, 0, 0, 0, 0) // máscara cero: payload sin alterar
+	_, err := c.conn.Write(append(frame, msg...))
+	require.NoError(t, err)
+}
+
+// receive retorna el siguiente mensaje JSON, o {"close": código} si el servidor cierra la conexión
+func (c *wsTestClient) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	var header [2]byte
+	_, err := io.ReadFull(c.br, header[:])
+	require.NoError(t, err)
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, err := io.ReadFull(c.br, ext[:])
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	require.NoError(t, err)
+
+	if header[0]&0x0F == 0x8 {
+		return map[string]interface{}{"close": float64(binary.BigEndian.Uint16(payload))}
+	}
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &msg))
+	return msg
+}
+
+func TestGraphQLHandler_WebSocketSubscription(t *testing.T) {
+	client := dialGraphQL(t, protocolTransportWS)
+
+	client.send(t, `{"type":"connection_init"}`)
+	assert.Equal(t, "connection_ack", client.receive(t)["type"])
+
+	client.send(t, `{"id":"1","type":"subscribe","payload":{"query":"subscription { letters }"}}`)
+	for _, letter := range []string{"a", "b"} {
+		msg := client.receive(t)
+		assert.Equal(t, "next", msg["type"])
+		assert.Equal(t, "1", msg["id"])
+		assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"letters": letter}}, msg["payload"])
+	}
+	assert.Equal(t, map[string]interface{}{"id": "1", "type": "complete"}, client.receive(t))
+
+	// Las queries también se pueden ejecutar por la misma conexión
+	client.send(t, `{"id":"2","type":"subscribe","payload":{"query":"{ hello }"}}`)
+	msg := client.receive(t)
+	assert.Equal(t, "next", msg["type"])
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"hello": "world"}}, msg["payload"])
+	assert.Equal(t, "complete", client.receive(t)["type"])
+
+	// Los errores de validación terminan la operación con un mensaje error
+	client.send(t, `{"id":"3","type":"subscribe","payload":{"query":"subscription { missing }"}}`)
+	msg = client.receive(t)
+	assert.Equal(t, "error", msg["type"])
+	assert.Equal(t, "3", msg["id"])
+
+	client.send(t, `{"type":"ping"}`)
+	assert.Equal(t, "pong", client.receive(t)["type"])
+}
+
+func TestGraphQLHandler_WebSocketLegacyProtocol(t *testing.T) {
+	client := dialGraphQL(t, protocolLegacyWS)
+
+	client.send(t, `{"type":"connection_init","payload":{}}`)
+	assert.Equal(t, "connection_ack", client.receive(t)["type"])
+
+	client.send(t, `{"id":"1","type":"start","payload":{"query":"subscription { letters }"}}`)
+	msg := client.receive(t)
+	assert.Equal(t, "data", msg["type"])
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"letters": "a"}}, msg["payload"])
+}
+
+func TestGraphQLHandler_WebSocketRequiresInit(t *testing.T) {
+	client := dialGraphQL(t, protocolTransportWS)
+
+	client.send(t, `{"id":"1","type":"subscribe","payload":{"query":"subscription { letters }"}}`)
+	assert.Equal(t, map[string]interface{}{"close": float64(closeUnauthorized)}, client.receive(t))
+}
+
+func TestGraphQLHandler_WebSocketConnectionInit(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetConnectionInit(func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+		if payload["token"] != "secret" {
+			return ctx, errors.New("invalid token")
+		}
+		return context.WithValue(ctx, viewerKey{}, "operator"), nil
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		client := dialHandler(t, handler, protocolTransportWS)
+
+		client.send(t, `{"type":"connection_init","payload":{"token":"secret"}}`)
+		assert.Equal(t, "connection_ack", client.receive(t)["type"])
+
+		// Las operaciones usan el contexto retornado por connection_init
+		client.send(t, `{"id":"1","type":"subscribe","payload":{"query":"{ viewer }"}}`)
+		msg := client.receive(t)
+		assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"viewer": "operator"}}, msg["payload"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		client := dialHandler(t, handler, protocolTransportWS)
+
+		client.send(t, `{"type":"connection_init","payload":{"token":"wrong"}}`)
+		assert.Equal(t, map[string]interface{}{"close": float64(closeForbidden)}, client.receive(t))
+	})
+
+	t.Run("rejected legacy", func(t *testing.T) {
+		client := dialHandler(t, handler, protocolLegacyWS)
+
+		client.send(t, `{"type":"connection_init"}`)
+		msg := client.receive(t)
+		assert.Equal(t, "connection_error", msg["type"])
+		assert.Equal(t, map[string]interface{}{"message": "invalid token"}, msg["payload"])
+		assert.Equal(t, map[string]interface{}{"close": float64(closeForbidden)}, client.receive(t))
+	})
+}
+
+func TestGraphQLHandler_WebSocketRateLimit(t *testing.T) {
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetLimits(nil, limits.NewRateLimiter(0.001, 1))
+	client := dialHandler(t, handler, protocolTransportWS)
+
+	client.send(t, `{"type":"connection_init"}`)
+	assert.Equal(t, "connection_ack", client.receive(t)["type"])
+
+	client.send(t, `{"id":"1","type":"subscribe","payload":{"query":"{ hello }"}}`)
+	assert.Equal(t, "next", client.receive(t)["type"])
+	assert.Equal(t, "complete", client.receive(t)["type"])
+
+	// Cada operación consume un token del cliente; sin tokens se rechaza con un error
+	client.send(t, `{"id":"2","type":"subscribe","payload":{"query":"subscription { letters }"}}`)
+	msg := client.receive(t)
+	assert.Equal(t, "error", msg["type"])
+	errs := msg["payload"].([]interface{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, limits.CodeRateLimited, errs[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+}
+
+func TestGraphQLHandler_WebSocketPersistedQueries(t *testing.T) {
+	query := "subscription { letters }"
+	handler := NewGraphQLHandler(testSchema(t))
+	handler.SetPersistedQueries(persisted.New(
+		persisted.NewStaticStore(map[string]string{persisted.Hash(query): query}), persisted.ModeAllowlist))
+	client := dialHandler(t, handler, protocolTransportWS)
+
+	client.send(t, `{"type":"connection_init"}`)
+	assert.Equal(t, "connection_ack", client.receive(t)["type"])
+
+	// La suscripción se envía solo por su hash
+	client.send(t, `{"id":"1","type":"subscribe","payload":{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+persisted.Hash(query)+`"}}}}`)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"letters": "a"}}, client.receive(t)["payload"])
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"letters": "b"}}, client.receive(t)["payload"])
+	assert.Equal(t, "complete", client.receive(t)["type"])
+
+	// Las queries fuera de la allowlist se rechazan también por WebSocket
+	client.send(t, `{"id":"2","type":"subscribe","payload":{"query":"{ hello }"}}`)
+	msg := client.receive(t)
+	assert.Equal(t, "error", msg["type"])
+	errs := msg["payload"].([]interface{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, persisted.CodeNotAllowed, errs[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+}
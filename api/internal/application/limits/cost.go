@@ -0,0 +1,283 @@
+package limits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// limitArgument es el argumento que indica cuántos elementos retorna un campo
+const limitArgument = "limit"
+
+// maxCost acota el costo calculado para que no desborde con argumentos limit enormes
+const maxCost = 1 << 40
+
+// Config configura los límites de las operaciones; 0 desactiva cada uno
+type Config struct {
+	MaxDepth      int // Niveles de campos anidados
+	MaxComplexity int // Costo estimado (ver Analyzer)
+	MaxLimit      int // Valor máximo de los argumentos limit
+}
+
+// Cost es el costo estimado de una operación
+type Cost struct {
+	Depth      int
+	Complexity int
+}
+
+// Analyzer calcula el costo de las operaciones a partir de su AST, antes de ejecutarlas.
+// Cada campo cuesta 1 más el costo de sus subcampos multiplicado por su argumento limit
+// (explícito, por variable o por defecto en el schema), ya que se resuelven una vez por elemento.
+// Los campos de introspección no cuentan: su tamaño lo acota el schema.
+type Analyzer struct {
+	schema graphql.Schema
+	cfg    Config
+}
+
+// NewAnalyzer crea un analizador para el schema
+func NewAnalyzer(schema graphql.Schema, cfg Config) *Analyzer {
+	return &Analyzer{schema: schema, cfg: cfg}
+}
+
+// Check calcula el costo de la operación operationName (o la única del documento) y retorna
+// un *Error si supera algún límite. Si no puede elegir la operación no la limita: la
+// ejecución reportará el error.
+func (a *Analyzer) Check(doc *ast.Document, operationName string, variables map[string]interface{}) (Cost, error) {
+	op, fragments := splitDocument(doc, operationName)
+	if op == nil {
+		return Cost{}, nil
+	}
+
+	root := a.rootType(op.Operation)
+	if root == nil {
+		return Cost{}, nil
+	}
+
+	w := &costWalker{
+		schema:    a.schema,
+		fragments: fragments,
+		variables: withVariableDefaults(op, variables),
+		maxLimit:  a.cfg.MaxLimit,
+		visiting:  make(map[string]bool),
+	}
+	complexity, depth := w.selectionSet(root, op.SelectionSet, 0)
+	cost := Cost{Depth: depth, Complexity: complexity}
+
+	if w.err != nil {
+		return cost, w.err
+	}
+	if a.cfg.MaxDepth > 0 && cost.Depth > a.cfg.MaxDepth {
+		return cost, &Error{
+			Code:    CodeQueryTooDeep,
+			Message: fmt.Sprintf("query depth %d exceeds the maximum of %d", cost.Depth, a.cfg.MaxDepth),
+			Details: map[string]interface{}{"depth": cost.Depth, "maxDepth": a.cfg.MaxDepth},
+		}
+	}
+	if a.cfg.MaxComplexity > 0 && cost.Complexity > a.cfg.MaxComplexity {
+		return cost, &Error{
+			Code:    CodeQueryTooComplex,
+			Message: fmt.Sprintf("query complexity %d exceeds the maximum of %d", cost.Complexity, a.cfg.MaxComplexity),
+			Details: map[string]interface{}{"complexity": cost.Complexity, "maxComplexity": a.cfg.MaxComplexity},
+		}
+	}
+	return cost, nil
+}
+
+// rootType retorna el tipo raíz de una operación
+func (a *Analyzer) rootType(operation string) graphql.Type {
+	var root *graphql.Object
+	switch operation {
+	case ast.OperationTypeQuery:
+		root = a.schema.QueryType()
+	case ast.OperationTypeMutation:
+		root = a.schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = a.schema.SubscriptionType()
+	}
+	if root == nil {
+		return nil
+	}
+	return root
+}
+
+// splitDocument retorna la operación a ejecutar y los fragmentos del documento por nombre
+func splitDocument(doc *ast.Document, operationName string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition) {
+	var op *ast.OperationDefinition
+	operations := 0
+	fragments := make(map[string]*ast.FragmentDefinition)
+
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.OperationDefinition:
+			operations++
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				op = d
+			}
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+		}
+	}
+	if operationName == "" && operations != 1 {
+		return nil, fragments
+	}
+	return op, fragments
+}
+
+// withVariableDefaults completa las variables no enviadas con su valor por defecto en la operación
+func withVariableDefaults(op *ast.OperationDefinition, variables map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		values[k] = v
+	}
+	for _, def := range op.VariableDefinitions {
+		name := def.Variable.Name.Value
+		if _, ok := values[name]; ok || def.DefaultValue == nil {
+			continue
+		}
+		values[name] = def.DefaultValue.GetValue()
+	}
+	return values
+}
+
+// costWalker recorre las selecciones de una operación acumulando su costo
+type costWalker struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	maxLimit  int
+	visiting  map[string]bool // Fragmentos en recorrido, para no seguir ciclos (los rechaza la validación)
+	err       error           // Primer argumento limit negativo o excedido
+}
+
+// fieldContainer es un tipo con campos (objetos e interfaces)
+type fieldContainer interface {
+	Fields() graphql.FieldDefinitionMap
+}
+
+// selectionSet retorna el costo de las selecciones de parent y la máxima profundidad alcanzada
+func (w *costWalker) selectionSet(parent graphql.Type, set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return 0, depth
+	}
+
+	complexity, maxDepth := 0, depth
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			c, d := w.field(parent, s, depth+1)
+			complexity = min(complexity+c, maxCost)
+			maxDepth = max(maxDepth, d)
+		case *ast.InlineFragment:
+			t := parent
+			if s.TypeCondition != nil {
+				t = w.schema.Type(s.TypeCondition.Name.Value)
+			}
+			c, d := w.selectionSet(t, s.SelectionSet, depth)
+			complexity = min(complexity+c, maxCost)
+			maxDepth = max(maxDepth, d)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || w.visiting[name] {
+				continue
+			}
+			w.visiting[name] = true
+			c, d := w.selectionSet(w.schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, depth)
+			delete(w.visiting, name)
+			complexity = min(complexity+c, maxCost)
+			maxDepth = max(maxDepth, d)
+		}
+	}
+	return complexity, maxDepth
+}
+
+// field retorna el costo de un campo y la máxima profundidad alcanzada en sus subcampos
+func (w *costWalker) field(parent graphql.Type, field *ast.Field, depth int) (int, int) {
+	var def *graphql.FieldDefinition
+	if container, ok := parent.(fieldContainer); ok {
+		def = container.Fields()[field.Name.Value]
+	}
+
+	multiplier := 1
+	var child graphql.Type
+	if def != nil {
+		if limit, ok := w.limit(def, field); ok {
+			// Los resolvers tratan un limit negativo de formas distintas (algunos lo ignoran)
+			if limit < 0 && w.err == nil {
+				w.err = &Error{
+					Code:    CodeNegativeLimit,
+					Message: fmt.Sprintf("%s: limit %d must not be negative", field.Name.Value, limit),
+					Details: map[string]interface{}{"field": field.Name.Value, "limit": limit},
+				}
+			}
+			if w.maxLimit > 0 && limit > w.maxLimit && w.err == nil {
+				w.err = &Error{
+					Code:    CodeLimitTooLarge,
+					Message: fmt.Sprintf("%s: limit %d exceeds the maximum of %d", field.Name.Value, limit, w.maxLimit),
+					Details: map[string]interface{}{"field": field.Name.Value, "limit": limit, "maxLimit": w.maxLimit},
+				}
+			}
+			multiplier = max(limit, 1)
+		}
+		child, _ = graphql.GetNamed(def.Type).(graphql.Type)
+	}
+
+	c, d := w.selectionSet(child, field.SelectionSet, depth)
+	if c > 0 && multiplier > maxCost/c {
+		return maxCost, d
+	}
+	return 1 + multiplier*c, d
+}
+
+// limit retorna el valor del argumento limit del campo: el enviado o el de por defecto
+func (w *costWalker) limit(def *graphql.FieldDefinition, field *ast.Field) (int, bool) {
+	var argDef *graphql.Argument
+	for _, arg := range def.Args {
+		if arg.Name() == limitArgument {
+			argDef = arg
+			break
+		}
+	}
+	if argDef == nil {
+		return 0, false
+	}
+
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != limitArgument {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, err := strconv.Atoi(v.Value)
+			return n, err == nil
+		case *ast.Variable:
+			if n, ok := toInt(w.variables[v.Name.Value]); ok {
+				return n, true
+			}
+		}
+		break
+	}
+	return toInt(argDef.DefaultValue)
+}
+
+// toInt convierte el valor de una variable (JSON decodifica los números como float64)
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,226 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// costSchema imita la forma del schema de la API: una conexión con limit por defecto 50
+// cuyos elementos tienen un historial con limit por defecto 100
+func costSchema(t *testing.T) graphql.Schema {
+	t.Helper()
+
+	event := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Event",
+		Fields: graphql.Fields{
+			"rating": &graphql.Field{Type: graphql.String},
+			"date":   &graphql.Field{Type: graphql.String},
+		},
+	})
+	var stock *graphql.Object
+	stock = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Stock",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"ticker": &graphql.Field{Type: graphql.String},
+				"price":  &graphql.Field{Type: graphql.Float},
+				"history": &graphql.Field{
+					Type: graphql.NewList(event),
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 100},
+					},
+				},
+				"peers": &graphql.Field{Type: graphql.NewList(stock)},
+			}
+		}),
+	})
+	connection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StockConnection",
+		Fields: graphql.Fields{
+			"stocks":     &graphql.Field{Type: graphql.NewList(stock)},
+			"totalCount": &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"stocks": &graphql.Field{
+					Type: connection,
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 50},
+					},
+				},
+				"stock": &graphql.Field{
+					Type: stock,
+					Args: graphql.FieldConfigArgument{
+						"ticker": &graphql.ArgumentConfig{Type: graphql.String},
+					},
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func TestAnalyzer_Cost(t *testing.T) {
+	analyzer := NewAnalyzer(costSchema(t), Config{})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		operation string
+		want      Cost
+	}{
+		{
+			name:  "single field",
+			query: `{ stock(ticker: "AAPL") { ticker price } }`,
+			want:  Cost{Depth: 2, Complexity: 3},
+		},
+		{
+			name:  "default limit multiplies the children",
+			query: `{ stocks { stocks { ticker } totalCount } }`,
+			want:  Cost{Depth: 3, Complexity: 1 + 50*(2+1)},
+		},
+		{
+			name:  "explicit limit",
+			query: `{ stocks(limit: 10) { stocks { ticker } } }`,
+			want:  Cost{Depth: 3, Complexity: 1 + 10*2},
+		},
+		{
+			name:      "limit from variable",
+			query:     `query Q($n: Int) { stocks(limit: $n) { stocks { ticker } } }`,
+			variables: map[string]interface{}{"n": float64(5)},
+			want:      Cost{Depth: 3, Complexity: 1 + 5*2},
+		},
+		{
+			name:  "limit from variable default",
+			query: `query Q($n: Int = 4) { stocks(limit: $n) { stocks { ticker } } }`,
+			want:  Cost{Depth: 3, Complexity: 1 + 4*2},
+		},
+		{
+			name:  "nested limits multiply",
+			query: `{ stocks(limit: 10) { stocks { history(limit: 3) { rating } } } }`,
+			want:  Cost{Depth: 4, Complexity: 1 + 10*(1+(1+3*1))},
+		},
+		{
+			name:  "fragments and introspection",
+			query: `{ __typename stock(ticker: "AAPL") { ...F ... on Stock { price } } } fragment F on Stock { ticker __typename }`,
+			want:  Cost{Depth: 2, Complexity: 3},
+		},
+		{
+			name:      "selected operation",
+			query:     `query A { stock { ticker } } query B { stocks(limit: 2) { totalCount } }`,
+			operation: "B",
+			want:      Cost{Depth: 2, Complexity: 1 + 2*1},
+		},
+		{
+			name:  "ambiguous operation is left to execution",
+			query: `query A { stock { ticker } } query B { stock { ticker } }`,
+			want:  Cost{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			require.NoError(t, err)
+
+			cost, err := analyzer.Check(doc, tt.operation, tt.variables)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cost)
+		})
+	}
+}
+
+func TestAnalyzer_Limits(t *testing.T) {
+	analyzer := NewAnalyzer(costSchema(t), Config{MaxDepth: 4, MaxComplexity: 1000, MaxLimit: 100})
+
+	tests := []struct {
+		name        string
+		query       string
+		wantCode    string
+		wantDetails map[string]interface{}
+	}{
+		{
+			name:        "limit too large",
+			query:       `{ stocks(limit: 100000) { totalCount } }`,
+			wantCode:    CodeLimitTooLarge,
+			wantDetails: map[string]interface{}{"field": "stocks", "limit": 100000, "maxLimit": 100},
+		},
+		{
+			name:        "negative limit",
+			query:       `{ stocks(limit: -1000000) { totalCount } }`,
+			wantCode:    CodeNegativeLimit,
+			wantDetails: map[string]interface{}{"field": "stocks", "limit": -1000000},
+		},
+		{
+			name:        "too deep",
+			query:       `{ stock { peers { peers { peers { peers { ticker } } } } } }`,
+			wantCode:    CodeQueryTooDeep,
+			wantDetails: map[string]interface{}{"depth": 6, "maxDepth": 4},
+		},
+		{
+			name:        "too complex",
+			query:       `{ stocks { stocks { history { rating } } } }`,
+			wantCode:    CodeQueryTooComplex,
+			wantDetails: map[string]interface{}{"complexity": 1 + 50*(1+(1+100*1)), "maxComplexity": 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			require.NoError(t, err)
+
+			_, err = analyzer.Check(doc, "", nil)
+			var limitErr *Error
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tt.wantCode, limitErr.Code)
+			assert.Equal(t, tt.wantDetails, limitErr.Details)
+		})
+	}
+
+	// Un limit negativo por variable también se rechaza
+	doc, err := parser.Parse(parser.ParseParams{Source: `query Q($n: Int) { stocks(limit: $n) { totalCount } }`})
+	require.NoError(t, err)
+	_, err = analyzer.Check(doc, "", map[string]interface{}{"n": float64(-5)})
+	var limitErr *Error
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, CodeNegativeLimit, limitErr.Code)
+	assert.Equal(t, map[string]interface{}{"field": "stocks", "limit": -5}, limitErr.Details)
+
+	doc, err = parser.Parse(parser.ParseParams{Source: `{ stocks(limit: 100) { stocks { ticker price } } }`})
+	require.NoError(t, err)
+	_, err = analyzer.Check(doc, "", nil)
+	assert.NoError(t, err)
+}
+
+func TestAnalyzer_CostIsBounded(t *testing.T) {
+	analyzer := NewAnalyzer(costSchema(t), Config{MaxComplexity: 1000})
+
+	doc, err := parser.Parse(parser.ParseParams{Source: `{ stocks(limit: 2000000000) { stocks { history(limit: 2000000000) { rating date } } } }`})
+	require.NoError(t, err)
+
+	cost, err := analyzer.Check(doc, "", nil)
+	assert.Equal(t, maxCost, cost.Complexity)
+	var limitErr *Error
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, CodeQueryTooComplex, limitErr.Code)
+}
+
+func TestError_Formatted(t *testing.T) {
+	err := &Error{Code: CodeRateLimited, Message: "rate limit exceeded", RetryAfter: 1200 * time.Millisecond}
+
+	formatted := err.Formatted()
+	assert.Equal(t, "rate limit exceeded", formatted.Message)
+	assert.Equal(t, map[string]interface{}{"code": CodeRateLimited, "retryAfter": 2}, formatted.Extensions)
+}
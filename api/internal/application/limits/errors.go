@@ -0,0 +1,59 @@
+// Package limits protege el endpoint GraphQL de consultas abusivas: limita la profundidad,
+// el costo estimado y los argumentos limit de cada operación, y la tasa de peticiones por cliente.
+package limits
+
+import (
+	"math"
+	"time"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// Códigos de error (extensions.code)
+const (
+	CodeQueryTooDeep    = "QUERY_TOO_DEEP"
+	CodeQueryTooComplex = "QUERY_TOO_COMPLEX"
+	CodeLimitTooLarge   = "LIMIT_TOO_LARGE"
+	CodeNegativeLimit   = "NEGATIVE_LIMIT"
+	CodeRateLimited     = "RATE_LIMITED"
+)
+
+// Error es una operación rechazada antes de ejecutarse
+type Error struct {
+	Code       string
+	Message    string
+	Details    map[string]interface{} // Se agregan a extensions
+	RetryAfter time.Duration          // Solo en CodeRateLimited
+}
+
+// Error implementa la interfaz error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// RetryAfterSeconds retorna RetryAfter redondeado hacia arriba, como lo espera el header Retry-After
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Extensions implementa gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.Code}
+	for k, v := range e.Details {
+		ext[k] = v
+	}
+	if e.RetryAfter > 0 {
+		ext["retryAfter"] = e.RetryAfterSeconds()
+	}
+	return ext
+}
+
+// Formatted retorna el error en el formato de los errores de una respuesta GraphQL
+func (e *Error) Formatted() gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    e.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: e.Extensions(),
+	}
+}
@@ -0,0 +1,88 @@
+package limits
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleClientTTL es el tiempo sin peticiones tras el cual se descarta el bucket de un cliente
+const idleClientTTL = 10 * time.Minute
+
+// RateLimiter limita las peticiones de cada cliente con un token bucket propio
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	now   func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+// rateClient es el bucket de un cliente
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter crea un limitador de perSecond peticiones por segundo con ráfagas de burst
+// peticiones por cliente; retorna nil (sin límite) si perSecond no es positivo
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		limit:   rate.Limit(perSecond),
+		burst:   max(burst, 1),
+		now:     time.Now,
+		clients: make(map[string]*rateClient),
+	}
+}
+
+// Allow consume un token del cliente; si no hay, retorna un *Error con el tiempo a esperar
+func (l *RateLimiter) Allow(key string) error {
+	if l == nil {
+		return nil
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	l.sweep(now)
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	l.mu.Unlock()
+
+	reservation := client.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	// No se espera: se devuelve el token para no penalizar el reintento
+	reservation.CancelAt(now)
+
+	return &Error{
+		Code:       CodeRateLimited,
+		Message:    fmt.Sprintf("rate limit exceeded, retry in %s", delay.Round(time.Millisecond)),
+		RetryAfter: delay,
+	}
+}
+
+// sweep descarta los buckets de los clientes inactivos; se ejecuta como máximo una vez por minuto
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, client := range l.clients {
+		if now.Sub(client.lastSeen) > idleClientTTL {
+			delete(l.clients, key)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_PerClientBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+
+	// La ráfaga se consume y el siguiente token llega en 1/2 segundo
+	assert.NoError(t, limiter.Allow("api_key:ops"))
+	assert.NoError(t, limiter.Allow("api_key:ops"))
+
+	err := limiter.Allow("api_key:ops")
+	var limitErr *Error
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, CodeRateLimited, limitErr.Code)
+	assert.Equal(t, 500*time.Millisecond, limitErr.RetryAfter)
+	assert.Equal(t, 1, limitErr.RetryAfterSeconds())
+
+	// Cada cliente tiene su propio bucket
+	assert.NoError(t, limiter.Allow("api_key:frontend"))
+
+	// Un rechazo no consume tokens: al pasar el tiempo indicado se vuelve a permitir
+	now = now.Add(limitErr.RetryAfter)
+	assert.NoError(t, limiter.Allow("api_key:ops"))
+	assert.Error(t, limiter.Allow("api_key:ops"))
+}
+
+func TestRateLimiter_SweepsIdleClients(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(1, 1)
+	limiter.now = func() time.Time { return now }
+
+	require.NoError(t, limiter.Allow("ip:10.0.0.1"))
+	require.NoError(t, limiter.Allow("ip:10.0.0.2"))
+
+	now = now.Add(idleClientTTL + time.Minute)
+	require.NoError(t, limiter.Allow("ip:10.0.0.2"))
+	assert.Len(t, limiter.clients, 1)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(0, 10)
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.Allow("anyone"))
+}
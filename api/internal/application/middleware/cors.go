@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/john/go-react-test/api/internal/config"
+	"github.com/john/go-react-test/api/internal/infrastructure/websocket"
+)
+
+// CORS aplica la política de orígenes cruzados configurada.
+// Responde los preflight sin llegar al handler (403 si el origen, el método o algún
+// header no están permitidos) y rechaza los handshakes WebSocket de orígenes no
+// permitidos, ya que los navegadores no aplican CORS a WebSocket.
+// Con el comodín nunca se permiten credenciales: config.Load rechaza esa combinación, y
+// aunque llegue aquí no se refleja el origen (cualquier sitio podría leer respuestas autenticadas).
+func CORS(cfg config.CORSConfig) Middleware {
+	allowAll := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		origins[strings.ToLower(strings.TrimRight(origin, "/"))] = true
+	}
+
+	methods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		methods[strings.ToUpper(method)] = true
+	}
+	headers := make(map[string]bool, len(cfg.AllowedHeaders))
+	for _, header := range cfg.AllowedHeaders {
+		headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		return allowAll || origins[strings.ToLower(origin)]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// La respuesta depende del origen aunque no se permita: los caches deben distinguirlos
+			if !allowAll {
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if !allowed(origin) {
+				if isPreflight(r) || websocket.IsUpgradeRequest(r) {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !isPreflight(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] ||
+				!headersAllowed(headers, r.Header.Get("Access-Control-Request-Headers")) {
+				http.Error(w, "CORS request not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// isPreflight retorna true si r es una petición preflight de CORS
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// headersAllowed retorna true si todos los headers pedidos en un preflight están permitidos
+func headersAllowed(allowed map[string]bool, requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		if header = strings.TrimSpace(header); header != "" && !allowed[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
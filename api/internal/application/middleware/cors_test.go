@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func corsConfig(origins ...string) config.CORSConfig {
+	return config.CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	r := httptest.NewRequest(http.MethodOptions, "/query", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return r
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	handler := CORS(corsConfig("https://app.example.com"))(okHandler)
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantOrigin string
+	}{
+		{"allowed", preflight("https://app.example.com", "POST", "content-type, x-api-key"), http.StatusNoContent, "https://app.example.com"},
+		{"origin case insensitive", preflight("https://APP.example.com", "POST", ""), http.StatusNoContent, "https://APP.example.com"},
+		{"origin not allowed", preflight("https://evil.example.com", "POST", ""), http.StatusForbidden, ""},
+		{"method not allowed", preflight("https://app.example.com", "DELETE", ""), http.StatusForbidden, "https://app.example.com"},
+		{"header not allowed", preflight("https://app.example.com", "POST", "X-Custom"), http.StatusForbidden, "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+			if tt.wantStatus == http.StatusNoContent {
+				assert.Equal(t, "GET, POST, OPTIONS", rec.Header().Get("Access-Control-Allow-Methods"))
+				assert.Equal(t, "Content-Type, Authorization, X-API-Key", rec.Header().Get("Access-Control-Allow-Headers"))
+				assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestCORS_SimpleRequests(t *testing.T) {
+	handler := CORS(corsConfig("https://app.example.com"))(okHandler)
+
+	// Origen permitido: el handler responde con los headers CORS
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Origen no permitido: el handler responde sin headers CORS y el navegador bloquea la respuesta
+	r = httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// Sin Origin (misma página, curl): sin cambios
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Values("Vary"))
+}
+
+func TestCORS_WebSocketOrigin(t *testing.T) {
+	handler := CORS(corsConfig("https://app.example.com"))(okHandler)
+
+	upgrade := func(origin string) int {
+		r := httptest.NewRequest(http.MethodGet, "/query", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, upgrade("https://app.example.com"))
+	assert.Equal(t, http.StatusForbidden, upgrade("https://evil.example.com"))
+}
+
+func TestCORS_Wildcard(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.Header.Set("Origin", "https://any.example.com")
+
+	rec := httptest.NewRecorder()
+	CORS(corsConfig("*"))(okHandler).ServeHTTP(rec, r)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Values("Vary"))
+
+	// El comodín nunca refleja el origen ni permite credenciales
+	cfg := corsConfig("*")
+	cfg.AllowCredentials = true
+	rec = httptest.NewRecorder()
+	CORS(cfg)(okHandler).ServeHTTP(rec, r)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_Credentials(t *testing.T) {
+	cfg := corsConfig("https://app.example.com")
+	cfg.AllowCredentials = true
+
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	CORS(cfg)(okHandler).ServeHTTP(rec, r)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+}
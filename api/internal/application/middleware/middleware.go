@@ -0,0 +1,17 @@
+// Package middleware contiene los middlewares HTTP comunes a todas las rutas de la API
+package middleware
+
+import "net/http"
+
+// Middleware envuelve un handler HTTP
+type Middleware func(http.Handler) http.Handler
+
+// Chain compone middlewares; el primero es el más externo (el primero en ver la petición)
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
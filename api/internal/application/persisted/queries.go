@@ -0,0 +1,140 @@
+// Package persisted implementa las persisted queries de GraphQL con el protocolo de Apollo:
+// el cliente envía el hash sha256 de la query en extensions.persistedQuery en lugar del texto,
+// y solo lo reenvía completo la primera vez (APQ). En modo allowlist solo se ejecutan las
+// queries registradas de antemano en un manifiesto.
+package persisted
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// Mode es el modo de las persisted queries
+type Mode string
+
+const (
+	// ModeAutomatic ejecuta cualquier query y registra las que llegan con su hash (APQ)
+	ModeAutomatic Mode = "apq"
+
+	// ModeAllowlist solo ejecuta las queries del store, enviadas por hash o con su texto completo
+	ModeAllowlist Mode = "allowlist"
+)
+
+// Códigos de error (extensions.code), compatibles con los clientes de Apollo
+const (
+	CodeNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	CodeNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+	CodeHashMismatch = "PERSISTED_QUERY_HASH_MISMATCH"
+	CodeNotAllowed   = "PERSISTED_QUERY_NOT_ALLOWED"
+)
+
+// Error es una petición de persisted query rechazada
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error implementa la interfaz error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Formatted retorna el error en el formato de los errores de una respuesta GraphQL
+func (e *Error) Formatted() gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    e.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: map[string]interface{}{"code": e.Code},
+	}
+}
+
+// Queries resuelve el texto de las queries de las peticiones
+type Queries struct {
+	store Store
+	mode  Mode
+}
+
+// New crea el resolvedor de persisted queries sobre un store
+func New(store Store, mode Mode) *Queries {
+	return &Queries{store: store, mode: mode}
+}
+
+// Hash retorna el hash con el que se identifica una query
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Resolve retorna la query a ejecutar según las extensions de la petición.
+// Sin persisted queries configuradas (q nil) retorna la query enviada.
+func (q *Queries) Resolve(ctx context.Context, query string, extensions map[string]interface{}) (string, error) {
+	if q == nil {
+		return query, nil
+	}
+
+	ext, ok := extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		if q.mode == ModeAllowlist {
+			return q.allowed(ctx, Hash(query), query)
+		}
+		return query, nil
+	}
+
+	if version, _ := ext["version"].(float64); version != 1 {
+		return "", &Error{Code: CodeNotSupported, Message: "PersistedQueryNotSupported"}
+	}
+	hash, _ := ext["sha256Hash"].(string)
+	hash = strings.ToLower(hash)
+	if hash == "" {
+		return "", &Error{Code: CodeNotFound, Message: "PersistedQueryNotFound"}
+	}
+
+	if query != "" {
+		if Hash(query) != hash {
+			return "", &Error{Code: CodeHashMismatch, Message: "provided sha does not match query"}
+		}
+		if q.mode == ModeAllowlist {
+			return q.allowed(ctx, hash, query)
+		}
+		if err := q.store.Put(ctx, hash, query); err != nil {
+			return "", fmt.Errorf("failed to store persisted query: %w", err)
+		}
+		return query, nil
+	}
+
+	stored, found, err := q.store.Get(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to load persisted query: %w", err)
+	}
+	if !found {
+		if q.mode == ModeAllowlist {
+			return "", notAllowed()
+		}
+		// El cliente reintenta con el texto completo para registrarla
+		return "", &Error{Code: CodeNotFound, Message: "PersistedQueryNotFound"}
+	}
+	return stored, nil
+}
+
+// allowed retorna la query si su hash está registrado en el store
+func (q *Queries) allowed(ctx context.Context, hash, query string) (string, error) {
+	_, found, err := q.store.Get(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to load persisted query: %w", err)
+	}
+	if !found {
+		return "", notAllowed()
+	}
+	return query, nil
+}
+
+// notAllowed es el error de las queries fuera de la allowlist
+func notAllowed() *Error {
+	return &Error{Code: CodeNotAllowed, Message: "query is not in the persisted query allowlist"}
+}
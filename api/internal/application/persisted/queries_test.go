@@ -0,0 +1,121 @@
+package persisted
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore es un Store cuyo backend no responde
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("connection refused")
+}
+
+func (failingStore) Put(context.Context, string, string) error {
+	return errors.New("connection refused")
+}
+
+// persistedQuery arma las extensions de una petición con el hash dado
+func persistedQuery(version float64, hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": version, "sha256Hash": hash},
+	}
+}
+
+func TestHash(t *testing.T) {
+	// sha256 en hex de la query tal cual se envía, como lo calculan los clientes de Apollo
+	assert.Equal(t, "001c3174e099bd72b729d0c0a529ba9f5a740c446e2a6e1d71b283cb84ec3065", Hash("{ hello }"))
+	assert.NotEqual(t, Hash("{ hello }"), Hash("{ hello}"))
+}
+
+func TestQueries_Automatic(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	queries := New(store, ModeAutomatic)
+	query := "{ stocks { totalCount } }"
+	hash := Hash(query)
+
+	tests := []struct {
+		name       string
+		query      string
+		extensions map[string]interface{}
+		want       string
+		wantCode   string
+	}{
+		{name: "plain query", query: query, want: query},
+		{name: "unknown hash", extensions: persistedQuery(1, hash), wantCode: CodeNotFound},
+		{name: "registration", query: query, extensions: persistedQuery(1, hash), want: query},
+		{name: "registered hash", extensions: persistedQuery(1, hash), want: query},
+		{name: "uppercase hash", extensions: persistedQuery(1, strings.ToUpper(hash)), want: query},
+		{name: "hash mismatch", query: "{ other }", extensions: persistedQuery(1, hash), wantCode: CodeHashMismatch},
+		{name: "unsupported version", extensions: persistedQuery(2, hash), wantCode: CodeNotSupported},
+		{name: "missing hash", extensions: persistedQuery(1, ""), wantCode: CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := queries.Resolve(ctx, tt.query, tt.extensions)
+			if tt.wantCode != "" {
+				var pqErr *Error
+				require.ErrorAs(t, err, &pqErr)
+				assert.Equal(t, tt.wantCode, pqErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestQueries_Allowlist(t *testing.T) {
+	ctx := context.Background()
+	query := "{ stocks { totalCount } }"
+	queries := New(NewStaticStore(map[string]string{Hash(query): query}), ModeAllowlist)
+
+	got, err := queries.Resolve(ctx, "", persistedQuery(1, Hash(query)))
+	require.NoError(t, err)
+	assert.Equal(t, query, got)
+
+	got, err = queries.Resolve(ctx, query, nil)
+	require.NoError(t, err)
+	assert.Equal(t, query, got)
+
+	for _, tt := range []struct {
+		query      string
+		extensions map[string]interface{}
+	}{
+		{query: "{ other }"},
+		{extensions: persistedQuery(1, Hash("{ other }"))},
+		{query: "{ other }", extensions: persistedQuery(1, Hash("{ other }"))},
+	} {
+		_, err := queries.Resolve(ctx, tt.query, tt.extensions)
+		var pqErr *Error
+		require.ErrorAs(t, err, &pqErr)
+		assert.Equal(t, CodeNotAllowed, pqErr.Code)
+	}
+}
+
+func TestQueries_StoreErrors(t *testing.T) {
+	queries := New(failingStore{}, ModeAutomatic)
+
+	_, err := queries.Resolve(context.Background(), "", persistedQuery(1, Hash("{ hello }")))
+	assert.ErrorContains(t, err, "failed to load persisted query")
+
+	_, err = queries.Resolve(context.Background(), "{ hello }", persistedQuery(1, Hash("{ hello }")))
+	assert.ErrorContains(t, err, "failed to store persisted query")
+}
+
+func TestQueries_Disabled(t *testing.T) {
+	var queries *Queries
+
+	got, err := queries.Resolve(context.Background(), "{ hello }", persistedQuery(1, "abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "{ hello }", got)
+}
@@ -0,0 +1,143 @@
+package persisted
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrReadOnly indica que el store no admite registrar queries
+var ErrReadOnly = errors.New("persisted query store is read-only")
+
+// Store guarda el texto de las queries por su hash sha256 (hex en minúsculas)
+type Store interface {
+	// Get retorna la query del hash; false si no está registrada
+	Get(ctx context.Context, hash string) (string, bool, error)
+
+	// Put registra una query
+	Put(ctx context.Context, hash, query string) error
+}
+
+// MemoryStore es un Store en memoria que descarta las queries menos usadas al llenarse
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Más reciente al frente
+}
+
+// memoryEntry es una query de MemoryStore
+type memoryEntry struct {
+	hash  string
+	query string
+}
+
+// NewMemoryStore crea un store con capacidad para capacity queries (0 sin límite)
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implementa Store
+func (s *MemoryStore) Get(_ context.Context, hash string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[hash]
+	if !ok {
+		return "", false, nil
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).query, true, nil
+}
+
+// Put implementa Store
+func (s *MemoryStore) Put(_ context.Context, hash, query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[hash]; ok {
+		elem.Value.(*memoryEntry).query = query
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	s.entries[hash] = s.order.PushFront(&memoryEntry{hash: hash, query: query})
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).hash)
+	}
+	return nil
+}
+
+// Len retorna el número de queries registradas
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// StaticStore es un Store de solo lectura con las queries de un manifiesto
+type StaticStore struct {
+	queries map[string]string
+}
+
+// NewStaticStore crea un store con las queries dadas por hash
+func NewStaticStore(queries map[string]string) *StaticStore {
+	return &StaticStore{queries: queries}
+}
+
+// Get implementa Store
+func (s *StaticStore) Get(_ context.Context, hash string) (string, bool, error) {
+	query, ok := s.queries[hash]
+	return query, ok, nil
+}
+
+// Put implementa Store; los manifiestos no admiten registrar queries
+func (s *StaticStore) Put(context.Context, string, string) error {
+	return ErrReadOnly
+}
+
+// manifest es el formato de manifiesto de Apollo (apollo-persisted-query-manifest)
+type manifest struct {
+	Format     string `json:"format"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// LoadManifest lee un manifiesto de queries y retorna las queries por hash.
+// Acepta el formato de Apollo ({"format": ..., "operations": [{"id", "body"}]}) o un
+// objeto {"<sha256>": "<query>"}. Falla si algún hash no corresponde a su query.
+func LoadManifest(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted query manifest: %w", err)
+	}
+
+	queries := make(map[string]string)
+	var apollo manifest
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Format != "" {
+		for _, op := range apollo.Operations {
+			queries[op.ID] = op.Body
+		}
+	} else if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("invalid persisted query manifest: %w", err)
+	}
+
+	for hash, query := range queries {
+		if Hash(query) != hash {
+			return nil, fmt.Errorf("invalid persisted query manifest: hash %s does not match its query", hash)
+		}
+	}
+	return queries, nil
+}
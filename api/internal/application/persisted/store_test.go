@@ -0,0 +1,90 @@
+package persisted
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	require.NoError(t, store.Put(ctx, "a", "{ a }"))
+	require.NoError(t, store.Put(ctx, "b", "{ b }"))
+
+	// Leer "a" la vuelve la más reciente: al llenarse se descarta "b"
+	_, found, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	require.NoError(t, store.Put(ctx, "c", "{ c }"))
+
+	_, found, _ = store.Get(ctx, "b")
+	assert.False(t, found)
+	query, found, _ := store.Get(ctx, "a")
+	assert.True(t, found)
+	assert.Equal(t, "{ a }", query)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestStaticStore_ReadOnly(t *testing.T) {
+	store := NewStaticStore(map[string]string{"a": "{ a }"})
+
+	query, found, err := store.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "{ a }", query)
+	assert.ErrorIs(t, store.Put(context.Background(), "b", "{ b }"), ErrReadOnly)
+}
+
+func TestLoadManifest(t *testing.T) {
+	query := "{ hello }"
+	hash := Hash(query)
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "apollo format",
+			content: `{"format":"apollo-persisted-query-manifest","version":1,"operations":[{"id":"` + hash + `","name":"Hello","type":"query","body":"{ hello }"}]}`,
+			want:    map[string]string{hash: query},
+		},
+		{
+			name:    "hash to query map",
+			content: `{"` + hash + `":"{ hello }"}`,
+			want:    map[string]string{hash: query},
+		},
+		{
+			name:    "hash mismatch",
+			content: `{"` + hash + `":"{ hello world }"}`,
+			wantErr: "does not match",
+		},
+		{
+			name:    "invalid json",
+			content: `[`,
+			wantErr: "invalid persisted query manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			got, err := LoadManifest(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// BrokerageService es el servicio de aplicación para el historial de aciertos de los brokerages
+type BrokerageService struct {
+	stockService *StockService
+	eventRepo    stock.RatingEventRepository
+
+	// Estadísticas calculadas y el último cambio del historial con que se calcularon
+	mu           sync.Mutex
+	stats        []*brokerage.Stats
+	statsVersion time.Time
+}
+
+// NewBrokerageService crea un nuevo servicio de brokerages
+func NewBrokerageService(stockService *StockService, eventRepo stock.RatingEventRepository) *BrokerageService {
+	return &BrokerageService{
+		stockService: stockService,
+		eventRepo:    eventRepo,
+	}
+}
+
+// GetStats calcula el historial de cada brokerage con al menos minCalls llamadas
+// a partir de todos los eventos de rating registrados
+func (s *BrokerageService) GetStats(ctx context.Context, minCalls int) ([]*brokerage.Stats, error) {
+	stats, err := s.allStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*brokerage.Stats, 0, len(stats))
+	for _, st := range stats {
+		if st.Calls >= minCalls {
+			filtered = append(filtered, st)
+		}
+	}
+	return filtered, nil
+}
+
+// allStats retorna las estadísticas de todos los brokerages. Recorrer el historial completo es
+// caro, así que solo se recalculan cuando se registró algún evento desde el último cálculo.
+func (s *BrokerageService) allStats(ctx context.Context) ([]*brokerage.Stats, error) {
+	version, err := s.eventRepo.LastChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats != nil && version.Equal(s.statsVersion) {
+		return s.stats, nil
+	}
+
+	events, err := s.eventRepo.FindSince(ctx, nil, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rating events: %w", err)
+	}
+
+	s.stats = brokerage.ComputeStats(events, s.stockService.GetScoringModel())
+	s.statsVersion = version
+	return s.stats, nil
+}
+
+// GetReliability retorna el peso de fiabilidad de cada brokerage para ponderar recomendaciones
+func (s *BrokerageService) GetReliability(ctx context.Context) (brokerage.Reliability, error) {
+	stats, err := s.GetStats(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return brokerage.NewReliability(stats), nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerageService_GetStats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	events := &fakeEventRepository{}
+	_, err := events.BatchInsert(ctx, []*stock.RatingEvent{
+		stock.NewRatingEventFromStock(testStock("AAPL", stock.RatingBuy, 110, now.Add(-48*time.Hour))),
+		stock.NewRatingEventFromStock(testStock("AAPL", stock.RatingBuy, 120, now.Add(-24*time.Hour))),
+		stock.NewRatingEventFromStock(testStock("MSFT", stock.RatingBuy, 120, now)),
+	})
+	require.NoError(t, err)
+
+	stockService := NewStockService(nil, events, stock.NewDomainService())
+	svc := NewBrokerageService(stockService, events)
+
+	stats, err := svc.GetStats(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "UBS", stats[0].Name)
+	assert.Equal(t, 3, stats[0].Calls)
+	assert.Equal(t, 1, events.finds)
+
+	// Sin eventos nuevos se reutiliza el cálculo anterior
+	stats, err = svc.GetStats(ctx, 5)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+	_, err = svc.GetReliability(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, events.finds)
+
+	// Un evento nuevo invalida el cálculo
+	_, err = events.BatchInsert(ctx, []*stock.RatingEvent{
+		stock.NewRatingEventFromStock(testStock("MSFT", stock.RatingStrongBuy, 150, now.Add(time.Hour))),
+	})
+	require.NoError(t, err)
+
+	stats, err = svc.GetStats(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats[0].Calls)
+	assert.Equal(t, 2, events.finds)
+}
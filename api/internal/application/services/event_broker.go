@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// subscriptionBuffer es el número de eventos que se encolan por suscriptor;
+// si un suscriptor lento lo llena, los eventos siguientes se descartan para él
+const subscriptionBuffer = 64
+
+// RatingChangedEvent es una llamada nueva o que cambió materialmente el último estado de un ticker
+type RatingChangedEvent struct {
+	Stock    *stock.Stock
+	Previous *stock.Stock // Estado anterior; nil si el ticker es nuevo
+}
+
+// eventBroker reparte los eventos publicados entre los suscriptores cuyo filtro los acepta
+type eventBroker[T any] struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]*subscriber[T]
+}
+
+// subscriber es un suscriptor de un eventBroker
+type subscriber[T any] struct {
+	ch    chan T
+	match func(T) bool
+}
+
+// newEventBroker crea un broker sin suscriptores
+func newEventBroker[T any]() *eventBroker[T] {
+	return &eventBroker[T]{subs: make(map[int]*subscriber[T])}
+}
+
+// subscribe registra un suscriptor (match nil acepta todos los eventos).
+// El canal se cierra y el suscriptor se elimina cuando ctx se cancela.
+func (b *eventBroker[T]) subscribe(ctx context.Context, match func(T) bool) <-chan T {
+	sub := &subscriber[T]{ch: make(chan T, subscriptionBuffer), match: match}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, id)
+		close(sub.ch)
+		b.mu.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// publish entrega el evento a los suscriptores sin bloquearse
+func (b *eventBroker[T]) publish(event T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if sub.match != nil && !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// hasSubscribers retorna true si hay algún suscriptor registrado
+func (b *eventBroker[T]) hasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
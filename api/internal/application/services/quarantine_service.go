@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/infrastructure/external"
+)
+
+// ReprocessResult contiene el resultado de reprocesar registros en cuarentena
+type ReprocessResult struct {
+	Processed int
+	Resolved  int
+	Failed    int
+	Records   []*quarantine.Record
+}
+
+// QuarantineService gestiona los registros rechazados durante la sincronización
+type QuarantineService struct {
+	apiClient   *external.KarenAIClient
+	syncService *SyncService // Ingiere los registros resueltos y notifica los cambios de rating
+	quarRepo    quarantine.Repository
+}
+
+// NewQuarantineService crea un nuevo servicio de cuarentena
+func NewQuarantineService(
+	apiClient *external.KarenAIClient,
+	syncService *SyncService,
+	quarRepo quarantine.Repository,
+) *QuarantineService {
+	return &QuarantineService{
+		apiClient:   apiClient,
+		syncService: syncService,
+		quarRepo:    quarRepo,
+	}
+}
+
+// GetRecords obtiene registros en cuarentena con filtros
+func (s *QuarantineService) GetRecords(ctx context.Context, filter quarantine.Filter) ([]*quarantine.Record, error) {
+	return s.quarRepo.Find(ctx, filter)
+}
+
+// Reprocess vuelve a pasar por el parser actual los registros indicados
+// (todos los pendientes si ids está vacío) e ingiere los que ahora son válidos por el mismo
+// camino que la sincronización, notificando los cambios de rating a los suscriptores
+func (s *QuarantineService) Reprocess(ctx context.Context, ids []uuid.UUID) (*ReprocessResult, error) {
+	var records []*quarantine.Record
+	var err error
+	if len(ids) == 0 {
+		records, err = s.quarRepo.Find(ctx, quarantine.Filter{Status: quarantine.StatusPending})
+	} else {
+		records, err = s.quarRepo.FindByIDs(ctx, ids)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReprocessResult{Records: make([]*quarantine.Record, 0, len(records))}
+	resolved := make([]*quarantine.Record, 0, len(records))
+	stocks := make([]*stock.Stock, 0, len(records))
+
+	for _, rec := range records {
+		if rec.Status == quarantine.StatusResolved {
+			continue
+		}
+		result.Processed++
+
+		st, convErr := s.apiClient.ConvertRawStock(rec.RawPayload)
+		if convErr != nil {
+			rec.RecordFailure(convErr)
+			result.Failed++
+			if err := s.quarRepo.Update(ctx, rec); err != nil {
+				return nil, err
+			}
+			result.Records = append(result.Records, rec)
+			continue
+		}
+
+		stocks = append(stocks, st)
+		resolved = append(resolved, rec)
+	}
+
+	// Ingerir primero y marcar como resueltos solo si se guardaron
+	if len(stocks) > 0 {
+		if _, err := s.syncService.IngestStocks(ctx, stocks); err != nil {
+			return nil, fmt.Errorf("failed to ingest reprocessed records: %w", err)
+		}
+	}
+
+	for _, rec := range resolved {
+		rec.Resolve()
+		result.Resolved++
+		if err := s.quarRepo.Update(ctx, rec); err != nil {
+			return nil, err
+		}
+		result.Records = append(result.Records, rec)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/infrastructure/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStockRepository guarda en memoria los stocks de BatchUpsert, los retorna en FindByTickers
+// y registra los tickers consultados
+type fakeStockRepository struct {
+	stock.Repository
+	upserted  []*stock.Stock
+	err       error
+	requested [][]string
+}
+
+func (f *fakeStockRepository) BatchUpsert(ctx context.Context, stocks []*stock.Stock) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.upserted = append(f.upserted, stocks...)
+	return nil
+}
+
+func (f *fakeStockRepository) FindByTickers(ctx context.Context, tickers []string) ([]*stock.Stock, error) {
+	f.requested = append(f.requested, tickers)
+	var result []*stock.Stock
+	for _, s := range f.upserted {
+		for _, ticker := range tickers {
+			if s.Ticker == ticker {
+				result = append(result, s)
+			}
+		}
+	}
+	return result, nil
+}
+
+// fakeEventRepository guarda en memoria los eventos de BatchInsert y cuenta las lecturas
+// del historial completo
+type fakeEventRepository struct {
+	stock.RatingEventRepository
+	inserted   []*stock.RatingEvent
+	lastChange time.Time
+	finds      int
+}
+
+func (f *fakeEventRepository) BatchInsert(ctx context.Context, events []*stock.RatingEvent) (int, error) {
+	f.inserted = append(f.inserted, events...)
+	f.lastChange = time.Now()
+	return len(events), nil
+}
+
+func (f *fakeEventRepository) FindSince(ctx context.Context, tickers []string, since time.Time) ([]*stock.RatingEvent, error) {
+	f.finds++
+	return f.inserted, nil
+}
+
+func (f *fakeEventRepository) LastChange(ctx context.Context) (time.Time, error) {
+	return f.lastChange, nil
+}
+
+// fakeQuarantineRepository retorna los registros en memoria y guarda los nuevos y los actualizados
+type fakeQuarantineRepository struct {
+	quarantine.Repository
+	records []*quarantine.Record
+	updated []*quarantine.Record
+}
+
+func (f *fakeQuarantineRepository) SaveBatch(ctx context.Context, records []*quarantine.Record) error {
+	f.records = append(f.records, records...)
+	return nil
+}
+
+func (f *fakeQuarantineRepository) Find(ctx context.Context, filter quarantine.Filter) ([]*quarantine.Record, error) {
+	var result []*quarantine.Record
+	for _, rec := range f.records {
+		if filter.Status == "" || rec.Status == filter.Status {
+			result = append(result, rec)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeQuarantineRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*quarantine.Record, error) {
+	var result []*quarantine.Record
+	for _, rec := range f.records {
+		for _, id := range ids {
+			if rec.ID == id {
+				result = append(result, rec)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeQuarantineRepository) Update(ctx context.Context, rec *quarantine.Record) error {
+	f.updated = append(f.updated, rec)
+	return nil
+}
+
+func quarantinedRecord(ticker, targetTo string) *quarantine.Record {
+	payload := `{"ticker":"` + ticker + `","company":"` + ticker + ` Inc.","brokerage":"UBS","action":"target raised by",` +
+		`"rating_from":"Buy","rating_to":"Strong Buy","target_from":"$100.00","target_to":"` + targetTo + `","time":"2025-01-20T10:00:00Z"}`
+	return quarantine.NewRecord(uuid.New(), ticker, []byte(payload), errors.New("invalid target_to"))
+}
+
+func newTestQuarantineService(repo *fakeStockRepository, quarRepo *fakeQuarantineRepository) *QuarantineService {
+	client := external.NewKarenAIClient("http://localhost", "key")
+	syncService := NewSyncService(client, repo, &fakeEventRepository{}, nil, quarRepo, 0, "test")
+	return NewQuarantineService(client, syncService, quarRepo)
+}
+
+func TestQuarantineService_Reprocess(t *testing.T) {
+	t.Run("partial success", func(t *testing.T) {
+		good := quarantinedRecord("GOOD", "$120.00")
+		bad := quarantinedRecord("BAD", "$1,200.00")
+		repo := &fakeStockRepository{}
+		quarRepo := &fakeQuarantineRepository{records: []*quarantine.Record{good, bad}}
+
+		result, err := newTestQuarantineService(repo, quarRepo).Reprocess(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Processed)
+		assert.Equal(t, 1, result.Resolved)
+		assert.Equal(t, 1, result.Failed)
+
+		require.Len(t, repo.upserted, 1)
+		assert.Equal(t, "GOOD", repo.upserted[0].Ticker)
+		assert.Equal(t, quarantine.StatusResolved, good.Status)
+		assert.NotNil(t, good.ResolvedAt)
+		assert.Len(t, quarRepo.updated, 2)
+	})
+
+	t.Run("convert failure keeps the record pending", func(t *testing.T) {
+		bad := quarantinedRecord("BAD", "$1,200.00")
+		repo := &fakeStockRepository{}
+		quarRepo := &fakeQuarantineRepository{records: []*quarantine.Record{bad}}
+
+		result, err := newTestQuarantineService(repo, quarRepo).Reprocess(context.Background(), []uuid.UUID{bad.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+		assert.Empty(t, repo.upserted)
+		assert.Equal(t, quarantine.StatusPending, bad.Status)
+		assert.Equal(t, 2, bad.Attempts)
+		assert.Contains(t, bad.Error, "target_to")
+		assert.NotNil(t, bad.LastAttemptAt)
+	})
+
+	t.Run("resolved records are skipped", func(t *testing.T) {
+		done := quarantinedRecord("DONE", "$120.00")
+		done.Resolve()
+		repo := &fakeStockRepository{}
+		quarRepo := &fakeQuarantineRepository{records: []*quarantine.Record{done}}
+
+		result, err := newTestQuarantineService(repo, quarRepo).Reprocess(context.Background(), []uuid.UUID{done.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Processed)
+		assert.Empty(t, repo.upserted)
+		assert.Empty(t, quarRepo.updated)
+	})
+
+	t.Run("ingest failure does not resolve records", func(t *testing.T) {
+		good := quarantinedRecord("GOOD", "$120.00")
+		repo := &fakeStockRepository{err: errors.New("connection refused")}
+		quarRepo := &fakeQuarantineRepository{records: []*quarantine.Record{good}}
+
+		_, err := newTestQuarantineService(repo, quarRepo).Reprocess(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Equal(t, quarantine.StatusPending, good.Status)
+		assert.Empty(t, quarRepo.updated)
+	})
+	t.Run("publishes rating changes", func(t *testing.T) {
+		good := quarantinedRecord("GOOD", "$120.00")
+		repo := &fakeStockRepository{}
+		quarRepo := &fakeQuarantineRepository{records: []*quarantine.Record{good}}
+		svc := newTestQuarantineService(repo, quarRepo)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes := svc.syncService.SubscribeRatingChanges(ctx, stock.Filter{})
+
+		_, err := svc.Reprocess(context.Background(), nil)
+		require.NoError(t, err)
+
+		select {
+		case e := <-changes:
+			assert.Equal(t, "GOOD", e.Stock.Ticker)
+			assert.Nil(t, e.Previous)
+		case <-time.After(time.Second):
+			t.Fatal("expected a ratingChanged event")
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// RatingAliasEntry es un alias efectivo del normalizador
+type RatingAliasEntry struct {
+	stock.RatingAlias
+	Configured bool // true si proviene de la tabla rating_aliases (sobrescribe al incorporado)
+}
+
+// RatingAliasService gestiona los alias de ratings y mantiene el normalizador sincronizado con la DB
+type RatingAliasService struct {
+	repo       stock.RatingAliasRepository
+	normalizer *stock.RatingNormalizer
+}
+
+// NewRatingAliasService crea un nuevo servicio de alias de ratings
+func NewRatingAliasService(repo stock.RatingAliasRepository, normalizer *stock.RatingNormalizer) *RatingAliasService {
+	return &RatingAliasService{
+		repo:       repo,
+		normalizer: normalizer,
+	}
+}
+
+// Load carga los alias configurados en la DB dentro del normalizador
+func (s *RatingAliasService) Load(ctx context.Context) error {
+	aliases, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load rating aliases: %w", err)
+	}
+	s.normalizer.SetAliases(aliases)
+	return nil
+}
+
+// BackfillScores asigna score a los stocks y eventos guardados sin él (filas anteriores a la
+// normalización de ratings) con los alias y el modelo de scoring vigentes. Los ratings que
+// ningún alias reconoce quedan sin score hasta que se configure uno.
+func (s *RatingAliasService) BackfillScores(ctx context.Context) (int, error) {
+	ratings, err := s.repo.FindUnscoredRatings(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, raw := range ratings {
+		normalized, err := s.normalizer.Normalize(raw)
+		if err != nil {
+			continue
+		}
+		n, err := s.repo.SetMissingScores(ctx, raw, normalized.Score)
+		if err != nil {
+			return updated, err
+		}
+		updated += n
+	}
+
+	return updated, nil
+}
+
+// GetAliases retorna los alias efectivos (incorporados y configurados) ordenados por alias
+func (s *RatingAliasService) GetAliases(ctx context.Context) ([]RatingAliasEntry, error) {
+	configured, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rating aliases: %w", err)
+	}
+
+	entries := make(map[string]RatingAliasEntry)
+	for _, a := range s.normalizer.BuiltinAliases() {
+		entries[stock.NormalizeRatingKey(a.Alias)] = RatingAliasEntry{RatingAlias: a}
+	}
+	for _, a := range configured {
+		entries[stock.NormalizeRatingKey(a.Alias)] = RatingAliasEntry{RatingAlias: a, Configured: true}
+	}
+
+	result := make([]RatingAliasEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return stock.NormalizeRatingKey(result[i].Alias) < stock.NormalizeRatingKey(result[j].Alias)
+	})
+
+	return result, nil
+}
+
+// Normalize traduce un rating crudo con los alias vigentes
+func (s *RatingAliasService) Normalize(raw string) (stock.NormalizedRating, error) {
+	return s.normalizer.Normalize(raw)
+}
+
+// SaveAlias guarda un alias y recarga el normalizador.
+// Si score es nil se usa el score por defecto del rating canónico.
+func (s *RatingAliasService) SaveAlias(ctx context.Context, alias string, rating stock.Rating, score *float64) (*stock.RatingAlias, error) {
+	a := stock.RatingAlias{Alias: stock.NormalizeRatingKey(alias), Rating: rating}
+	if score != nil {
+		a.Score = *score
+	} else {
+		a.Score = s.normalizer.RatingScore(rating)
+	}
+
+	if err := stock.ValidateRatingAlias(a); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, a); err != nil {
+		return nil, err
+	}
+
+	if err := s.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// DeleteAlias elimina un alias configurado y recarga el normalizador.
+// Los alias incorporados no se pueden eliminar, solo sobrescribir.
+func (s *RatingAliasService) DeleteAlias(ctx context.Context, alias string) (bool, error) {
+	deleted, err := s.repo.Delete(ctx, alias)
+	if err != nil {
+		return false, err
+	}
+
+	if deleted {
+		if err := s.Load(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAliasRepository retorna los alias y ratings sin score en memoria y guarda los scores asignados
+type fakeAliasRepository struct {
+	stock.RatingAliasRepository
+	aliases  []stock.RatingAlias
+	unscored []string
+	scores   map[string]float64
+}
+
+func (f *fakeAliasRepository) FindAll(ctx context.Context) ([]stock.RatingAlias, error) {
+	return f.aliases, nil
+}
+
+func (f *fakeAliasRepository) FindUnscoredRatings(ctx context.Context) ([]string, error) {
+	return f.unscored, nil
+}
+
+func (f *fakeAliasRepository) SetMissingScores(ctx context.Context, raw string, score float64) (int, error) {
+	f.scores[raw] = score
+	return 1, nil
+}
+
+func TestRatingAliasService_BackfillScores(t *testing.T) {
+	repo := &fakeAliasRepository{
+		aliases:  []stock.RatingAlias{{Alias: "conviction list", Rating: stock.RatingStrongBuy, Score: 5.5}},
+		unscored: []string{"Buy", "Overweight", "Conviction List", "Moonshot"},
+		scores:   make(map[string]float64),
+	}
+	svc := NewRatingAliasService(repo, stock.NewRatingNormalizer(scoring.DefaultModel(), nil))
+	require.NoError(t, svc.Load(context.Background()))
+
+	updated, err := svc.BackfillScores(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated)
+
+	// Los scores salen de los alias vigentes, incluidos los configurados
+	assert.Equal(t, 3.0, repo.scores["Buy"])
+	assert.Equal(t, 3.0, repo.scores["Overweight"])
+	assert.Equal(t, 5.5, repo.scores["Conviction List"])
+
+	// Un rating sin alias queda sin score
+	_, ok := repo.scores["Moonshot"]
+	assert.False(t, ok)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/john/go-react-test/api/internal/domain/recommendation"
 	"github.com/john/go-react-test/api/internal/domain/stock"
@@ -11,6 +12,7 @@ import (
 type RecommendationService struct {
 	stockService *StockService
 	algorithm    recommendation.Algorithm
+	algorithms   map[string]recommendation.Algorithm // Algoritmos alternativos por nombre
 }
 
 // NewRecommendationService crea un nuevo servicio de recomendaciones
@@ -21,18 +23,58 @@ func NewRecommendationService(
 	return &RecommendationService{
 		stockService: stockService,
 		algorithm:    algorithm,
+		algorithms: map[string]recommendation.Algorithm{
+			recommendation.AlgorithmLatest: algorithm,
+		},
 	}
 }
 
+// RegisterAlgorithm registra un algoritmo alternativo con el nombre indicado
+func (s *RecommendationService) RegisterAlgorithm(name string, algorithm recommendation.Algorithm) {
+	s.algorithms[name] = algorithm
+}
+
 // GetRecommendations obtiene las mejores recomendaciones de inversión
 func (s *RecommendationService) GetRecommendations(ctx context.Context, limit int) ([]*recommendation.Recommendation, error) {
-	// Obtener todos los stocks con rating positivo
-	filter := stock.Filter{
-		Ratings: []stock.Rating{
-			stock.RatingStrongBuy,
-			stock.RatingBuy,
-			stock.RatingSpeculativeBuy,
-		},
+	return s.GetRecommendationsWithCriteria(ctx, recommendation.Criteria{}, limit)
+}
+
+// GetRecommendationsWithCriteria obtiene recomendaciones con pesos y filtros ajustados
+func (s *RecommendationService) GetRecommendationsWithCriteria(
+	ctx context.Context,
+	criteria recommendation.Criteria,
+	limit int,
+) ([]*recommendation.Recommendation, error) {
+	return s.GetRecommendationsWithAlgorithm(ctx, recommendation.AlgorithmLatest, criteria, limit)
+}
+
+// GetRecommendationsWithAlgorithm obtiene recomendaciones calculadas con el algoritmo indicado
+func (s *RecommendationService) GetRecommendationsWithAlgorithm(
+	ctx context.Context,
+	name string,
+	criteria recommendation.Criteria,
+	limit int,
+) ([]*recommendation.Recommendation, error) {
+	algorithm, err := s.getAlgorithm(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := criteria.Validate(); err != nil {
+		return nil, err
+	}
+
+	// El consenso evalúa todas las llamadas recientes, no solo el rating de la última fila
+	filter := stock.Filter{}
+	if name == recommendation.AlgorithmLatest {
+		// Obtener todos los stocks con los ratings permitidos (los positivos por defecto)
+		filter.Ratings = criteria.Ratings
+		if len(filter.Ratings) == 0 {
+			filter.Ratings = []stock.Rating{
+				stock.RatingStrongBuy,
+				stock.RatingBuy,
+				stock.RatingSpeculativeBuy,
+			}
+		}
 	}
 
 	stocks, err := s.stockService.GetStocks(ctx, filter, stock.Sort{})
@@ -41,5 +83,37 @@ func (s *RecommendationService) GetRecommendations(ctx context.Context, limit in
 	}
 
 	// Calcular recomendaciones usando el algoritmo
-	return s.algorithm.CalculateRecommendations(ctx, stocks, limit)
+	return algorithm.CalculateWithCriteria(ctx, stocks, criteria, limit)
+}
+
+// ExplainRecommendation explica el score de un ticker con el algoritmo indicado y,
+// si no está entre las limit primeras recomendaciones, por qué
+func (s *RecommendationService) ExplainRecommendation(
+	ctx context.Context,
+	name string,
+	ticker string,
+	criteria recommendation.Criteria,
+	limit int,
+) (*recommendation.Explanation, error) {
+	algorithm, err := s.getAlgorithm(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se evalúan todos los stocks para poder explicar también los que no pasan los filtros
+	stocks, err := s.stockService.GetStocks(ctx, stock.Filter{}, stock.Sort{})
+	if err != nil {
+		return nil, err
+	}
+
+	return algorithm.Explain(ctx, stocks, ticker, criteria, limit)
+}
+
+// getAlgorithm obtiene un algoritmo registrado por nombre
+func (s *RecommendationService) getAlgorithm(name string) (recommendation.Algorithm, error) {
+	algorithm, ok := s.algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown recommendation algorithm: %s", name)
+	}
+	return algorithm, nil
 }
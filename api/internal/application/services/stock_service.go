@@ -3,26 +3,38 @@ package services
 import (
 	"context"
 
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 )
 
 // StockService es el servicio de aplicación para stocks
 type StockService struct {
 	repo       stock.Repository
+	eventRepo  stock.RatingEventRepository
 	domainSvc  stock.Service
 }
 
 // NewStockService crea un nuevo servicio de stocks
-func NewStockService(repo stock.Repository, domainSvc stock.Service) *StockService {
+func NewStockService(repo stock.Repository, eventRepo stock.RatingEventRepository, domainSvc stock.Service) *StockService {
 	return &StockService{
 		repo:      repo,
+		eventRepo: eventRepo,
 		domainSvc: domainSvc,
 	}
 }
 
 // GetStocks obtiene stocks con filtros y ordenamiento
 func (s *StockService) GetStocks(ctx context.Context, filter stock.Filter, sort stock.Sort) ([]*stock.Stock, error) {
-	return s.repo.FindAll(ctx, filter, sort)
+	return s.GetStocksPage(ctx, filter, sort, stock.Page{})
+}
+
+// GetStocksPage obtiene una página de stocks con filtros y ordenamiento
+// (el orden por score usa el modelo de scoring del servicio si no se indica otro)
+func (s *StockService) GetStocksPage(ctx context.Context, filter stock.Filter, sort stock.Sort, page stock.Page) ([]*stock.Stock, error) {
+	if sort.Field == stock.SortScore && sort.Model == nil {
+		sort.Model = s.domainSvc.ScoringModel()
+	}
+	return s.repo.FindAll(ctx, filter, sort, page)
 }
 
 // GetStock obtiene un stock por ticker
@@ -30,11 +42,70 @@ func (s *StockService) GetStock(ctx context.Context, ticker string) (*stock.Stoc
 	return s.repo.FindByTicker(ctx, ticker)
 }
 
+// GetHistory obtiene el historial de eventos de rating de un ticker
+// (limit se acota entre 1 y el máximo)
+func (s *StockService) GetHistory(ctx context.Context, ticker string, limit int) ([]*stock.RatingEvent, error) {
+	return s.eventRepo.FindByTicker(ctx, ticker, historyLimit(limit))
+}
+
+// GetHistories obtiene el historial de eventos de rating de varios tickers en una sola consulta
+// (para DataLoader); limit se acota entre 1 y el máximo
+func (s *StockService) GetHistories(ctx context.Context, tickers []string, limit int) (map[string][]*stock.RatingEvent, error) {
+	events, err := s.eventRepo.FindByTickers(ctx, tickers, historyLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make(map[string][]*stock.RatingEvent, len(tickers))
+	for _, e := range events {
+		histories[e.Ticker] = append(histories[e.Ticker], e)
+	}
+	return histories, nil
+}
+
+// historyLimit acota el limit del historial entre 1 y el máximo
+func historyLimit(limit int) int {
+	if limit < 1 {
+		return 1
+	}
+	if limit > stock.MaxHistoryLimit {
+		return stock.MaxHistoryLimit
+	}
+	return limit
+}
+
+// GetScoringModel obtiene el modelo de scoring usado para puntuar recomendaciones
+func (s *StockService) GetScoringModel() *scoring.Model {
+	return s.domainSvc.ScoringModel()
+}
+
 // CountStocks cuenta el número de stocks que coinciden con el filtro
 func (s *StockService) CountStocks(ctx context.Context, filter stock.Filter) (int, error) {
 	return s.repo.Count(ctx, filter)
 }
 
+// GetStats calcula las agregaciones de los stocks que coinciden con el filtro
+func (s *StockService) GetStats(ctx context.Context, filter stock.Filter) (*stock.Stats, error) {
+	return s.repo.Stats(ctx, filter)
+}
+
+// SearchStocks busca stocks por ticker, empresa o brokerage ordenados por relevancia
+// (limit fuera de rango usa el valor por defecto o el máximo)
+func (s *StockService) SearchStocks(ctx context.Context, query string, limit int) ([]*stock.SearchResult, error) {
+	query, err := stock.NormalizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = stock.DefaultSearchLimit
+	}
+	if limit > stock.MaxSearchLimit {
+		limit = stock.MaxSearchLimit
+	}
+	return s.repo.Search(ctx, query, limit)
+}
+
 // GetStocksByTickers obtiene múltiples stocks por sus tickers (para DataLoader)
 func (s *StockService) GetStocksByTickers(ctx context.Context, tickers []string) ([]*stock.Stock, error) {
 	if len(tickers) == 0 {
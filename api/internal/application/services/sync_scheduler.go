@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+)
+
+// SyncScheduler encola sincronizaciones periódicas en el SyncService
+type SyncScheduler struct {
+	syncService  *SyncService
+	interval     time.Duration
+	runOnStartup bool
+}
+
+// NewSyncScheduler crea un nuevo scheduler de sincronización
+func NewSyncScheduler(syncService *SyncService, interval time.Duration, runOnStartup bool) *SyncScheduler {
+	return &SyncScheduler{
+		syncService:  syncService,
+		interval:     interval,
+		runOnStartup: runOnStartup,
+	}
+}
+
+// Start encola una sincronización en cada intervalo hasta que el contexto se cancele
+func (s *SyncScheduler) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	log.Printf("Sync scheduler started (interval: %s)", s.interval)
+
+	if s.runOnStartup {
+		s.enqueue(ctx)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Sync scheduler stopped")
+			return
+		case <-ticker.C:
+			s.enqueue(ctx)
+		}
+	}
+}
+
+// enqueue encola una sincronización programada (incremental)
+func (s *SyncScheduler) enqueue(ctx context.Context) {
+	run, err := s.syncService.EnqueueSync(ctx, syncrun.TriggerScheduled, syncrun.ModeIncremental)
+	if err != nil {
+		log.Printf("Failed to enqueue scheduled sync: %v", err)
+		return
+	}
+	log.Printf("Scheduled sync enqueued (run %s)", run.ID)
+}
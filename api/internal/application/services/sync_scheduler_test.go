@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextQueued espera la siguiente ejecución encolada en svc, como lo haría el worker
+func nextQueued(t *testing.T, svc *SyncService) *syncrun.Run {
+	t.Helper()
+	select {
+	case run := <-svc.queue:
+		svc.mu.Lock()
+		svc.pending = nil
+		svc.mu.Unlock()
+		return run
+	case <-time.After(time.Second):
+		t.Fatal("no sync run was enqueued")
+		return nil
+	}
+}
+
+func TestSyncScheduler_Start(t *testing.T) {
+	t.Run("enqueues an incremental sync on each tick", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		svc := NewSyncService(nil, nil, nil, runRepo, nil, 0, "api-1")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go NewSyncScheduler(svc, 10*time.Millisecond, false).Start(ctx)
+
+		for i := 0; i < 2; i++ {
+			run := nextQueued(t, svc)
+			assert.Equal(t, syncrun.TriggerScheduled, run.Trigger)
+			assert.Equal(t, syncrun.ModeIncremental, run.Mode)
+		}
+	})
+
+	t.Run("runs on startup without waiting for the interval", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		svc := NewSyncService(nil, nil, nil, runRepo, nil, 0, "api-1")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go NewSyncScheduler(svc, time.Hour, true).Start(ctx)
+
+		nextQueued(t, svc)
+		require.Len(t, runRepo.createdRuns(), 1)
+	})
+
+	t.Run("disabled without interval", func(t *testing.T) {
+		svc := NewSyncService(nil, nil, nil, &fakeRunRepository{}, nil, 0, "api-1")
+
+		done := make(chan struct{})
+		go func() {
+			NewSyncScheduler(svc, 0, true).Start(context.Background())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler without interval should return immediately")
+		}
+		assert.Empty(t, svc.queue)
+	})
+}
@@ -2,36 +2,308 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
 	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
 	"github.com/john/go-react-test/api/internal/infrastructure/external"
 )
 
+// SyncResult contiene las estadísticas de una sincronización
+type SyncResult struct {
+	Mode            syncrun.Mode // Modo efectivo (incremental cae a full sin marca de agua previa)
+	PagesFetched    int
+	RowsUpserted    int
+	RowsRejected    int
+	EventsInserted  int
+	NewestEventTime time.Time // Marca de agua para la siguiente sincronización incremental
+}
+
+// ErrSyncQueueFull indica que no hay lugar para encolar otra sincronización
+var ErrSyncQueueFull = errors.New("sync queue is full")
+
 // SyncService maneja la sincronización de stocks desde la API externa
 type SyncService struct {
-	apiClient *external.KarenAIClient
-	repo      stock.Repository
+	apiClient  *external.KarenAIClient
+	repo       stock.Repository
+	eventRepo  stock.RatingEventRepository
+	runRepo    syncrun.Repository
+	quarRepo   quarantine.Repository
+	runTimeout time.Duration
+	instance   string // Identifica las ejecuciones de este proceso en el ledger
+
+	mu         sync.Mutex
+	queue      chan *syncrun.Run
+	pending    *syncrun.Run       // Ejecución encolada que aún no ha comenzado
+	stopWorker context.CancelFunc // Cancela el worker lanzado por Start
+	worker     sync.WaitGroup
+
+	// Eventos para las suscripciones GraphQL
+	ratingChanges *eventBroker[RatingChangedEvent]
+	syncCompleted *eventBroker[*syncrun.Run]
 }
 
 // NewSyncService crea un nuevo servicio de sincronización
-func NewSyncService(apiClient *external.KarenAIClient, repo stock.Repository) *SyncService {
+func NewSyncService(
+	apiClient *external.KarenAIClient,
+	repo stock.Repository,
+	eventRepo stock.RatingEventRepository,
+	runRepo syncrun.Repository,
+	quarRepo quarantine.Repository,
+	runTimeout time.Duration,
+	instance string,
+) *SyncService {
 	return &SyncService{
-		apiClient: apiClient,
-		repo:      repo,
+		apiClient:  apiClient,
+		repo:       repo,
+		eventRepo:  eventRepo,
+		runRepo:    runRepo,
+		quarRepo:   quarRepo,
+		runTimeout: runTimeout,
+		instance:   instance,
+		queue:      make(chan *syncrun.Run, 1),
+
+		ratingChanges: newEventBroker[RatingChangedEvent](),
+		syncCompleted: newEventBroker[*syncrun.Run](),
 	}
 }
 
-// SyncAllStocks sincroniza todos los stocks desde la API externa
-func (s *SyncService) SyncAllStocks(ctx context.Context) (int, error) {
-	// Obtener todos los stocks de la API externa
-	stocks, err := s.apiClient.FetchAllStocks(ctx)
+// SubscribeRatingChanges retorna las llamadas nuevas o que cambian materialmente el estado
+// de un ticker en las próximas sincronizaciones, limitadas a las que cumplen el filtro.
+// El canal se cierra cuando ctx se cancela.
+func (s *SyncService) SubscribeRatingChanges(ctx context.Context, filter stock.Filter) <-chan RatingChangedEvent {
+	return s.ratingChanges.subscribe(ctx, func(e RatingChangedEvent) bool {
+		return filter.Matches(e.Stock)
+	})
+}
+
+// SubscribeSyncCompleted retorna las ejecuciones de sincronización a medida que terminan
+// (con éxito o con error). El canal se cierra cuando ctx se cancela.
+func (s *SyncService) SubscribeSyncCompleted(ctx context.Context) <-chan *syncrun.Run {
+	return s.syncCompleted.subscribe(ctx, nil)
+}
+
+// Recover marca como fallidas las ejecuciones que este proceso dejó a medias antes de
+// reiniciarse, y las de cualquier réplica que llevan más del doble de runTimeout sin terminar
+// (una encolada espera como mucho a la ejecución en curso). Debe llamarse antes de Start y
+// de encolar sincronizaciones: las ejecuciones encoladas después también están sin terminar.
+func (s *SyncService) Recover(ctx context.Context) error {
+	var staleBefore time.Time
+	if s.runTimeout > 0 {
+		staleBefore = time.Now().Add(-2 * s.runTimeout)
+	}
+
+	n, err := s.runRepo.FailUnfinished(ctx, s.instance, staleBefore, "interrupted by server restart")
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch stocks from API: %w", err)
+		return err
+	}
+	if n > 0 {
+		log.Printf("Marked %d unfinished sync runs as failed", n)
 	}
+	return nil
+}
+
+// Start lanza en segundo plano el worker que procesa las ejecuciones encoladas hasta que
+// el contexto se cancele o se llame a Stop. Debe llamarse una sola vez.
+func (s *SyncService) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.stopWorker = cancel
+	s.mu.Unlock()
+
+	s.worker.Add(1)
+	go func() {
+		defer s.worker.Done()
+		s.work(ctx)
+	}()
+}
+
+// Stop cancela la sincronización en curso y espera a que el worker termine de registrarla
+// en el ledger; después ya se puede cerrar la base de datos
+func (s *SyncService) Stop() {
+	s.mu.Lock()
+	cancel := s.stopWorker
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.worker.Wait()
+}
+
+// work ejecuta las sincronizaciones encoladas, una a la vez, hasta que ctx se cancele
+func (s *SyncService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case run := <-s.queue:
+			s.mu.Lock()
+			s.pending = nil
+			s.mu.Unlock()
+
+			s.execute(ctx, run)
+		}
+	}
+}
 
+// EnqueueSync encola una sincronización y retorna la ejecución registrada.
+// Si ya hay una ejecución pendiente, se retorna esa en lugar de encolar otra
+// (si se pide una full y la pendiente es incremental, la pendiente pasa a full).
+// Retorna ErrSyncQueueFull si la cola no admite otra ejecución.
+func (s *SyncService) EnqueueSync(ctx context.Context, trigger syncrun.Trigger, mode syncrun.Mode) (*syncrun.Run, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("invalid sync mode: %s", mode)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending != nil {
+		if mode == syncrun.ModeFull && s.pending.Mode != syncrun.ModeFull {
+			s.pending.Mode = syncrun.ModeFull
+			if err := s.runRepo.Update(ctx, s.pending); err != nil {
+				return nil, fmt.Errorf("failed to upgrade pending sync run: %w", err)
+			}
+		}
+		return s.pending, nil
+	}
+
+	// Solo se encola bajo s.mu, así que si hay lugar ahora el envío no bloquea
+	if len(s.queue) == cap(s.queue) {
+		return nil, ErrSyncQueueFull
+	}
+
+	run := syncrun.NewRun(s.instance, trigger, mode)
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to enqueue sync run: %w", err)
+	}
+
+	s.pending = run
+	s.queue <- run
+
+	return run, nil
+}
+
+// execute ejecuta una sincronización encolada y registra el resultado en el ledger
+func (s *SyncService) execute(ctx context.Context, run *syncrun.Run) {
+	run.Start()
+	if err := s.runRepo.Update(ctx, run); err != nil {
+		log.Printf("Failed to mark sync run %s as running: %v", run.ID, err)
+	}
+
+	runCtx := ctx
+	if s.runTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.runTimeout)
+		defer cancel()
+	}
+
+	result, err := s.SyncAllStocks(runCtx, run.ID, run.Mode)
+	if err != nil {
+		run.Fail(err)
+		log.Printf("Sync run %s failed: %v", run.ID, err)
+	} else {
+		run.Mode = result.Mode
+		run.Succeed(result.PagesFetched, result.RowsUpserted, result.RowsRejected, result.NewestEventTime)
+		log.Printf("Sync run %s finished (%s): %d pages, %d rows upserted, %d rejected",
+			run.ID, run.Mode, result.PagesFetched, result.RowsUpserted, result.RowsRejected)
+	}
+
+	// Usar un contexto independiente para poder registrar el resultado aunque ctx se haya cancelado
+	updateCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.runRepo.Update(updateCtx, run); err != nil {
+		log.Printf("Failed to record sync run %s: %v", run.ID, err)
+	}
+
+	s.syncCompleted.publish(run)
+}
+
+// SyncAllStocks sincroniza los stocks desde la API externa de forma síncrona.
+// En modo incremental solo se ingieren los eventos desde la marca de agua de la última
+// ejecución exitosa (los repetidos se descartan al insertarlos); sin marca de agua se hace
+// una sincronización full.
+// Los registros rechazados se guardan en cuarentena asociados a runID (puede ser uuid.Nil).
+func (s *SyncService) SyncAllStocks(ctx context.Context, runID uuid.UUID, mode syncrun.Mode) (*SyncResult, error) {
+	// Las caches del cliente sirven datos de hasta 10 minutos; una sincronización debe ver la API actual
+	opts := external.FetchOptions{NoCache: true}
+	if mode == syncrun.ModeIncremental {
+		since, err := s.lastWatermark(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if since.IsZero() {
+			mode = syncrun.ModeFull
+		}
+		opts.Since = since
+	}
+
+	// Obtener los stocks de la API externa
+	fetched, err := s.apiClient.FetchAllStocks(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stocks from API: %w", err)
+	}
+
+	result := &SyncResult{
+		Mode:            mode,
+		PagesFetched:    fetched.PagesFetched,
+		RowsRejected:    len(fetched.Rejections),
+		NewestEventTime: fetched.NewestEventTime,
+	}
+	// La marca de agua nunca retrocede
+	if opts.Since.After(result.NewestEventTime) {
+		result.NewestEventTime = opts.Since
+	}
+
+	// Guardar los registros rechazados en cuarentena para poder revisarlos y reprocesarlos
+	if len(fetched.Rejections) > 0 {
+		records := make([]*quarantine.Record, len(fetched.Rejections))
+		for i, rej := range fetched.Rejections {
+			records[i] = quarantine.NewRecord(runID, rej.DTO.Ticker, rej.RawPayload, rej.Err)
+		}
+		if err := s.quarRepo.SaveBatch(ctx, records); err != nil {
+			return nil, fmt.Errorf("failed to quarantine rejected records: %w", err)
+		}
+	}
+
+	stocks := fetched.Stocks
 	if len(stocks) == 0 {
-		return 0, fmt.Errorf("no stocks found in API response")
+		// Sin stocks válidos la ejecución termina igual si hubo rechazos: quedaron en cuarentena
+		if mode == syncrun.ModeIncremental || len(fetched.Rejections) > 0 {
+			return result, nil
+		}
+		return nil, fmt.Errorf("no stocks found in API response")
+	}
+
+	inserted, err := s.IngestStocks(ctx, stocks)
+	if err != nil {
+		return nil, err
+	}
+	result.RowsUpserted = len(stocks)
+	result.EventsInserted = inserted
+
+	return result, nil
+}
+
+// IngestStocks guarda los stocks (último estado por ticker), registra cada llamada en el
+// historial de eventos y notifica los cambios de rating a los suscriptores; retorna cuántos
+// eventos eran nuevos. Es el único camino de escritura de stocks (sincronización y cuarentena).
+func (s *SyncService) IngestStocks(ctx context.Context, stocks []*stock.Stock) (int, error) {
+	// El estado anterior solo hace falta para notificar a los suscriptores
+	var previous map[string]*stock.Stock
+	if s.ratingChanges.hasSubscribers() {
+		var err error
+		if previous, err = s.currentStates(ctx, stocks); err != nil {
+			log.Printf("Failed to load current stocks, rating change notifications skipped: %v", err)
+		}
 	}
 
 	// Guardar en base de datos usando batch upsert
@@ -39,5 +311,101 @@ func (s *SyncService) SyncAllStocks(ctx context.Context) (int, error) {
 		return 0, fmt.Errorf("failed to save stocks to database: %w", err)
 	}
 
-	return len(stocks), nil
+	// Registrar cada llamada en el historial (los duplicados se ignoran)
+	events := make([]*stock.RatingEvent, len(stocks))
+	for i, st := range stocks {
+		events[i] = stock.NewRatingEventFromStock(st)
+	}
+	inserted, err := s.eventRepo.BatchInsert(ctx, events)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save rating events to database: %w", err)
+	}
+
+	if previous != nil {
+		s.publishRatingChanges(previous, stocks)
+	}
+	return inserted, nil
+}
+
+// currentStates retorna el último estado almacenado de cada ticker de batch
+func (s *SyncService) currentStates(ctx context.Context, batch []*stock.Stock) (map[string]*stock.Stock, error) {
+	seen := make(map[string]bool, len(batch))
+	tickers := make([]string, 0, len(batch))
+	for _, st := range batch {
+		if !seen[st.Ticker] {
+			seen[st.Ticker] = true
+			tickers = append(tickers, st.Ticker)
+		}
+	}
+
+	stocks, err := s.repo.FindByTickers(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]*stock.Stock, len(stocks))
+	for _, st := range stocks {
+		states[st.Ticker] = st
+	}
+	return states, nil
+}
+
+// publishRatingChanges notifica, por ticker, la llamada más reciente de stocks si el ticker
+// es nuevo o si cambia materialmente el estado anterior (las llamadas más antiguas no lo sobrescriben)
+func (s *SyncService) publishRatingChanges(previous map[string]*stock.Stock, stocks []*stock.Stock) {
+	latest := make(map[string]*stock.Stock)
+	for _, st := range stocks {
+		if cur, ok := latest[st.Ticker]; !ok || st.EventTime.After(cur.EventTime) {
+			latest[st.Ticker] = st
+		}
+	}
+
+	tickers := make([]string, 0, len(latest))
+	for ticker := range latest {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	for _, ticker := range tickers {
+		next := *latest[ticker]
+		prev := previous[ticker]
+		if prev != nil {
+			if next.EventTime.Before(prev.EventTime) || !prev.HasMaterialChange(&next) {
+				continue
+			}
+			// La fila almacenada conserva su ID y fecha de creación
+			next.ID, next.CreatedAt = prev.ID, prev.CreatedAt
+		}
+		s.ratingChanges.publish(RatingChangedEvent{Stock: &next, Previous: prev})
+	}
+}
+
+// lastWatermark retorna la marca de agua de la última sincronización exitosa
+func (s *SyncService) lastWatermark(ctx context.Context) (time.Time, error) {
+	last, err := s.runRepo.FindLatest(ctx, syncrun.StatusSucceeded)
+	if errors.Is(err, syncrun.ErrRunNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load last sync watermark: %w", err)
+	}
+	if last.NewestEventTime == nil {
+		return time.Time{}, nil
+	}
+	return *last.NewestEventTime, nil
+}
+
+// GetRun obtiene una ejecución por ID
+func (s *SyncService) GetRun(ctx context.Context, id uuid.UUID) (*syncrun.Run, error) {
+	return s.runRepo.FindByID(ctx, id)
+}
+
+// GetRuns obtiene las últimas ejecuciones de sincronización
+func (s *SyncService) GetRuns(ctx context.Context, limit int) ([]*syncrun.Run, error) {
+	return s.runRepo.FindRecent(ctx, limit)
+}
+
+// GetLastRun obtiene la ejecución más reciente con el estado indicado (cualquiera si es vacío)
+func (s *SyncService) GetLastRun(ctx context.Context, status syncrun.Status) (*syncrun.Run, error) {
+	return s.runRepo.FindLatest(ctx, status)
 }
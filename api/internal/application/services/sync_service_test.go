@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+	"github.com/john/go-react-test/api/internal/infrastructure/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunRepository guarda en memoria las ejecuciones creadas y actualizadas
+type fakeRunRepository struct {
+	syncrun.Repository
+	mu      sync.Mutex
+	created []*syncrun.Run
+	updated []*syncrun.Run
+
+	failedInstance string
+	staleBefore    time.Time
+}
+
+func (f *fakeRunRepository) Create(ctx context.Context, run *syncrun.Run) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, run)
+	return nil
+}
+
+func (f *fakeRunRepository) Update(ctx context.Context, run *syncrun.Run) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = append(f.updated, run)
+	return nil
+}
+
+func (f *fakeRunRepository) FailUnfinished(ctx context.Context, instance string, staleBefore time.Time, reason string) (int, error) {
+	f.failedInstance = instance
+	f.staleBefore = staleBefore
+	return 1, nil
+}
+
+// createdRuns retorna una copia de las ejecuciones creadas
+func (f *fakeRunRepository) createdRuns() []*syncrun.Run {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*syncrun.Run(nil), f.created...)
+}
+
+func testStock(ticker string, rating stock.Rating, target float64, eventTime time.Time) *stock.Stock {
+	from, _ := stock.NewPrice(100)
+	to, _ := stock.NewPrice(target)
+	s, _ := stock.NewStock(ticker, ticker+" Inc.", "UBS", "target raised by", stock.RatingNeutral, rating, from, to)
+	s.EventTime = eventTime
+	return s
+}
+
+// receiveAll retorna los eventos ya publicados en ch
+func receiveAll[T any](ch <-chan T) []T {
+	var events []T
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSyncService_PublishRatingChanges(t *testing.T) {
+	svc := NewSyncService(nil, nil, nil, nil, nil, 0, "test")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all := svc.SubscribeRatingChanges(ctx, stock.Filter{})
+	buys := svc.SubscribeRatingChanges(ctx, stock.Filter{Ratings: []stock.Rating{stock.RatingBuy}})
+
+	now := time.Now()
+	previous := map[string]*stock.Stock{
+		"AAPL": testStock("AAPL", stock.RatingBuy, 120, now.Add(-time.Hour)),
+		"MSFT": testStock("MSFT", stock.RatingBuy, 120, now.Add(-time.Hour)),
+		"NVDA": testStock("NVDA", stock.RatingBuy, 120, now),
+	}
+	svc.publishRatingChanges(previous, []*stock.Stock{
+		testStock("AAPL", stock.RatingStrongBuy, 150, now.Add(-2*time.Hour)), // Más antigua que la almacenada
+		testStock("AAPL", stock.RatingBuy, 130, now),                         // Cambio de precio objetivo
+		testStock("MSFT", stock.RatingBuy, 120, now),                         // Sin cambios materiales
+		testStock("NVDA", stock.RatingSell, 90, now.Add(-time.Minute)),       // Más antigua que la almacenada
+		testStock("TSLA", stock.RatingSell, 90, now),                         // Ticker nuevo
+	})
+
+	events := receiveAll(all)
+	require.Len(t, events, 2)
+	assert.Equal(t, "AAPL", events[0].Stock.Ticker)
+	assert.Equal(t, 130.0, events[0].Stock.TargetTo.Value())
+	assert.Equal(t, previous["AAPL"].ID, events[0].Stock.ID)
+	assert.Same(t, previous["AAPL"], events[0].Previous)
+	assert.Equal(t, "TSLA", events[1].Stock.Ticker)
+	assert.Nil(t, events[1].Previous)
+
+	filtered := receiveAll(buys)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "AAPL", filtered[0].Stock.Ticker)
+}
+
+func TestSyncService_IngestStocksLoadsBatchTickers(t *testing.T) {
+	now := time.Now()
+	stored := testStock("AAPL", stock.RatingBuy, 120, now.Add(-time.Hour))
+	repo := &fakeStockRepository{upserted: []*stock.Stock{stored, testStock("MSFT", stock.RatingBuy, 120, now)}}
+	svc := NewSyncService(nil, repo, &fakeEventRepository{}, nil, nil, 0, "test")
+
+	// Sin suscriptores no se consulta el estado anterior
+	stored = testStock("AAPL", stock.RatingBuy, 125, now.Add(-time.Minute))
+	_, err := svc.IngestStocks(context.Background(), []*stock.Stock{stored})
+	require.NoError(t, err)
+	assert.Empty(t, repo.requested)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := svc.SubscribeRatingChanges(ctx, stock.Filter{})
+
+	_, err = svc.IngestStocks(context.Background(), []*stock.Stock{
+		testStock("AAPL", stock.RatingStrongBuy, 150, now),
+		testStock("AAPL", stock.RatingBuy, 130, now.Add(-2*time.Minute)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"AAPL"}}, repo.requested)
+
+	events := receiveAll(changes)
+	require.Len(t, events, 1)
+	assert.Same(t, stored, events[0].Previous)
+}
+
+func TestSyncService_SubscriptionsEndWithContext(t *testing.T) {
+	svc := NewSyncService(nil, nil, nil, nil, nil, 0, "test")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := svc.SubscribeSyncCompleted(ctx)
+	assert.True(t, svc.syncCompleted.hasSubscribers())
+
+	run := syncrun.NewRun("test", syncrun.TriggerManual, syncrun.ModeFull)
+	svc.syncCompleted.publish(run)
+	assert.Same(t, run, <-runs)
+
+	cancel()
+	_, open := <-runs
+	assert.False(t, open)
+	assert.False(t, svc.syncCompleted.hasSubscribers())
+}
+
+func TestSyncService_Stop(t *testing.T) {
+	// La API externa no responde hasta que se cancela la petición
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	runRepo := &fakeRunRepository{}
+	client := external.NewKarenAIClientWithOptions(server.URL, "key", 1000, 1, nil)
+	svc := NewSyncService(client, &fakeStockRepository{}, &fakeEventRepository{}, runRepo, &fakeQuarantineRepository{}, 0, "api-1")
+	svc.Start(context.Background())
+
+	run, err := svc.EnqueueSync(context.Background(), syncrun.TriggerManual, syncrun.ModeFull)
+	require.NoError(t, err)
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync did not start")
+	}
+
+	// Al retornar, la ejecución cancelada ya quedó registrada
+	svc.Stop()
+	runRepo.mu.Lock()
+	defer runRepo.mu.Unlock()
+	require.NotEmpty(t, runRepo.updated)
+	last := runRepo.updated[len(runRepo.updated)-1]
+	assert.Equal(t, run.ID, last.ID)
+	assert.Equal(t, syncrun.StatusFailed, last.Status)
+}
+
+func TestSyncService_EnqueueSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("coalesces into the pending run", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		svc := NewSyncService(nil, nil, nil, runRepo, nil, 0, "api-1")
+
+		first, err := svc.EnqueueSync(ctx, syncrun.TriggerScheduled, syncrun.ModeIncremental)
+		require.NoError(t, err)
+		assert.Equal(t, "api-1", first.Instance)
+
+		second, err := svc.EnqueueSync(ctx, syncrun.TriggerScheduled, syncrun.ModeIncremental)
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+		assert.Empty(t, runRepo.updated)
+
+		// Pedir una full convierte la pendiente en full en lugar de encolar otra
+		third, err := svc.EnqueueSync(ctx, syncrun.TriggerManual, syncrun.ModeFull)
+		require.NoError(t, err)
+		assert.Same(t, first, third)
+		assert.Equal(t, syncrun.ModeFull, first.Mode)
+		require.Len(t, runRepo.updated, 1)
+
+		assert.Len(t, runRepo.createdRuns(), 1)
+		assert.Same(t, first, <-svc.queue)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		runRepo := &fakeRunRepository{}
+		svc := NewSyncService(nil, nil, nil, runRepo, nil, 0, "api-1")
+		svc.queue <- syncrun.NewRun("api-1", syncrun.TriggerManual, syncrun.ModeFull)
+
+		run, err := svc.EnqueueSync(ctx, syncrun.TriggerManual, syncrun.ModeFull)
+		assert.True(t, errors.Is(err, ErrSyncQueueFull))
+		assert.Nil(t, run)
+		assert.Empty(t, runRepo.createdRuns())
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		svc := NewSyncService(nil, nil, nil, &fakeRunRepository{}, nil, 0, "api-1")
+		_, err := svc.EnqueueSync(ctx, syncrun.TriggerManual, "partial")
+		assert.Error(t, err)
+	})
+}
+
+func TestSyncService_Recover(t *testing.T) {
+	runRepo := &fakeRunRepository{}
+	svc := NewSyncService(nil, nil, nil, runRepo, nil, 15*time.Minute, "api-1")
+
+	require.NoError(t, svc.Recover(context.Background()))
+	assert.Equal(t, "api-1", runRepo.failedInstance)
+	assert.WithinDuration(t, time.Now().Add(-30*time.Minute), runRepo.staleBefore, time.Minute)
+
+	// Sin timeout no se puede saber si la ejecución de otra réplica sigue viva
+	svc = NewSyncService(nil, nil, nil, runRepo, nil, 0, "api-1")
+	require.NoError(t, svc.Recover(context.Background()))
+	assert.True(t, runRepo.staleBefore.IsZero())
+}
+
+func TestSyncService_SyncAllStocksQuarantinesInvalidPages(t *testing.T) {
+	valid := `{"ticker":"GOOD","company":"Good Inc.","brokerage":"UBS","action":"target raised by","rating_from":"Buy","rating_to":"Buy","target_from":"$1.00","target_to":"$2.00","time":"2025-01-20T10:00:00Z"}`
+	invalid := `{"ticker":"BAD","company":"Bad Inc.","brokerage":"UBS","action":"target raised by","rating_from":"Buy","rating_to":"Buy","target_from":"$1.00","target_to":"$1,200.00","time":"2025-01-19T10:00:00Z"}`
+
+	tests := []struct {
+		name     string
+		pages    map[string]string
+		upserted int
+	}{
+		{
+			name: "invalid page between valid pages",
+			pages: map[string]string{
+				"":   `{"items":[` + valid + `],"next_page":"p2"}`,
+				"p2": `{"items":[` + invalid + `,` + invalid + `],"next_page":"p3"}`,
+				"p3": `{"items":[` + valid + `]}`,
+			},
+			upserted: 2,
+		},
+		{
+			name:  "every item invalid",
+			pages: map[string]string{"": `{"items":[` + invalid + `]}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.pages[r.URL.Query().Get("next_page")]))
+			}))
+			defer server.Close()
+
+			repo := &fakeStockRepository{}
+			quarRepo := &fakeQuarantineRepository{}
+			client := external.NewKarenAIClientWithOptions(server.URL, "key", 1000, 1, nil)
+			svc := NewSyncService(client, repo, &fakeEventRepository{}, &fakeRunRepository{}, quarRepo, 0, "test")
+
+			result, err := svc.SyncAllStocks(context.Background(), uuid.New(), syncrun.ModeFull)
+			require.NoError(t, err)
+			assert.Len(t, repo.upserted, tt.upserted)
+			assert.Equal(t, len(quarRepo.records), result.RowsRejected)
+			for _, rec := range quarRepo.records {
+				assert.Equal(t, "BAD", rec.Ticker)
+			}
+			assert.NotZero(t, result.RowsRejected)
+		})
+	}
+}
@@ -4,15 +4,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config contiene la configuración de la aplicación
 type Config struct {
-	Database DatabaseConfig
-	API      APIConfig
-	Server   ServerConfig
+	Database       DatabaseConfig
+	API            APIConfig
+	Server         ServerConfig
+	Sync           SyncConfig
+	Scoring        ScoringConfig
+	Recommendation RecommendationConfig
+	Auth           AuthConfig
+	CORS           CORSConfig
+	Security       SecurityConfig
+	Limits         LimitsConfig
+	Persisted      PersistedQueriesConfig
+	Cache          CacheConfig
 }
 
 // DatabaseConfig configuración de base de datos
@@ -36,8 +49,103 @@ type ServerConfig struct {
 	Port string
 }
 
+// SyncConfig configuración de la sincronización en segundo plano
+type SyncConfig struct {
+	Interval     time.Duration // 0 desactiva el scheduler
+	RunOnStartup bool
+	Timeout      time.Duration // Tiempo máximo de una ejecución
+	InstanceID   string        // Identifica este proceso en el ledger; distinto por réplica y estable entre reinicios
+}
+
+// ScoringConfig configuración del modelo de scoring
+type ScoringConfig struct {
+	ModelFile string // Archivo JSON con el modelo; si está vacío se usa la tabla scoring_parameters
+}
+
+// RecommendationConfig configuración de los algoritmos de recomendación
+type RecommendationConfig struct {
+	ConsensusWindow time.Duration // Antigüedad máxima de las llamadas que agrega el consenso
+}
+
+// AuthConfig configuración de la autenticación del endpoint GraphQL
+type AuthConfig struct {
+	APIKeys       []APIKeyConfig
+	JWTSecret     string // Secreto de los tokens HS256
+	JWKSFile      string // Archivo JWKS con las claves públicas de los tokens RS256
+	JWTIssuer     string
+	JWTAudience   string
+	AnonymousRole string // Rol de las peticiones sin credenciales; vacío las rechaza
+}
+
+// APIKeyConfig es una API key de un cliente de la API
+type APIKeyConfig struct {
+	Name string
+	Role string
+	Key  string
+}
+
+// CORSConfig configuración de CORS de las rutas HTTP
+type CORSConfig struct {
+	AllowedOrigins   []string // "*" permite cualquier origen
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // Tiempo que el navegador cachea un preflight; 0 no lo indica
+}
+
+// SecurityConfig configuración de los headers de seguridad de las respuestas HTTP
+type SecurityConfig struct {
+	ContentSecurityPolicy string        // Vacío no envía el header
+	HSTSMaxAge            time.Duration // 0 no envía Strict-Transport-Security (solo para despliegues con HTTPS)
+}
+
+// LimitsConfig límites de las operaciones GraphQL; 0 desactiva cada uno
+type LimitsConfig struct {
+	MaxDepth      int     // Niveles de campos anidados
+	MaxComplexity int     // Costo estimado: cada campo cuenta una vez por elemento del limit de sus padres
+	MaxLimit      int     // Valor máximo de los argumentos limit
+	RatePerSecond float64 // Operaciones por segundo por cliente (API key, sujeto del JWT o IP)
+	RateBurst     int     // Operaciones que un cliente puede hacer de golpe
+}
+
+// PersistedQueriesConfig configuración de las persisted queries de GraphQL
+type PersistedQueriesConfig struct {
+	Mode         string // "apq" (registro automático), "allowlist" (solo las del manifiesto) u "off"
+	ManifestFile string // Manifiesto con las queries registradas de antemano; obligatorio en modo allowlist
+	CacheSize    int    // Queries que conserva el registro automático; 0 sin límite
+}
+
+// CacheConfig configuración del cacheo HTTP de las queries GraphQL enviadas por GET
+type CacheConfig struct {
+	MaxAge time.Duration // max-age de Cache-Control; 0 obliga a revalidar con el ETag en cada uso
+}
+
+// defaultContentSecurityPolicy permite los recursos que cargan el Playground y Swagger UI desde sus CDN
+const defaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://unpkg.com https://fonts.googleapis.com; " +
+	"font-src 'self' data: https://fonts.gstatic.com https://cdn.jsdelivr.net; " +
+	"img-src 'self' data: https:; " +
+	"connect-src 'self' ws: wss:; " +
+	"frame-ancestors 'none'"
+
 // Load carga la configuración desde variables de entorno
 func Load() (*Config, error) {
+	cfg, err := LoadLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.API.APIKey == "" {
+		return nil, fmt.Errorf("API_KEY environment variable is required")
+	}
+
+	return cfg, nil
+}
+
+// LoadLocal carga la configuración sin exigir las credenciales de la API externa,
+// para herramientas que solo usan la base de datos local (ej: cmd/backtest)
+func LoadLocal() (*Config, error) {
 	// Intentar cargar archivos .env si existen
 	// Buscar desde el directorio actual hacia arriba
 	loadEnvFiles()
@@ -58,10 +166,88 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Port: getEnv("PORT", "8080"),
 		},
+		Scoring: ScoringConfig{
+			ModelFile: getEnv("SCORING_MODEL_FILE", ""),
+		},
+		Auth: AuthConfig{
+			JWTSecret:     getEnv("AUTH_JWT_SECRET", ""),
+			JWKSFile:      getEnv("AUTH_JWKS_FILE", ""),
+			JWTIssuer:     getEnv("AUTH_JWT_ISSUER", ""),
+			JWTAudience:   getEnv("AUTH_JWT_AUDIENCE", ""),
+			AnonymousRole: getEnv("AUTH_ANONYMOUS_ROLE", "viewer"),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", "*"),
+			AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", "GET, POST, OPTIONS"),
+			AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", "Content-Type, Authorization, X-API-Key"),
+		},
+		Security: SecurityConfig{
+			ContentSecurityPolicy: getEnv("SECURITY_CSP", defaultContentSecurityPolicy),
+		},
+		Persisted: PersistedQueriesConfig{
+			Mode:         getEnv("PERSISTED_QUERIES", "apq"),
+			ManifestFile: getEnv("PERSISTED_QUERIES_MANIFEST", ""),
+		},
+	}
+	if cfg.Security.ContentSecurityPolicy == "none" {
+		cfg.Security.ContentSecurityPolicy = ""
+	}
+	if cfg.Auth.AnonymousRole == "none" {
+		cfg.Auth.AnonymousRole = ""
 	}
 
-	if cfg.API.APIKey == "" {
-		return nil, fmt.Errorf("API_KEY environment variable is required")
+	var err error
+	if cfg.Sync.Interval, err = getEnvDuration("SYNC_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.Sync.Timeout, err = getEnvDuration("SYNC_TIMEOUT", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.Sync.RunOnStartup, err = getEnvBool("SYNC_ON_STARTUP", false); err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	cfg.Sync.InstanceID = getEnv("SYNC_INSTANCE_ID", hostname)
+	if cfg.Recommendation.ConsensusWindow, err = getEnvDuration("RECOMMENDATION_CONSENSUS_WINDOW", 90*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.APIKeys, err = getEnvAPIKeys("AUTH_API_KEYS"); err != nil {
+		return nil, err
+	}
+	if cfg.CORS.AllowCredentials, err = getEnvBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return nil, err
+	}
+	if cfg.CORS.MaxAge, err = getEnvDuration("CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	// Con credenciales el navegador exige un origen explícito; reflejar cualquiera expondría
+	// las respuestas autenticadas a todos los sitios
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		return nil, fmt.Errorf("CORS_ALLOW_CREDENTIALS requires explicit CORS_ALLOWED_ORIGINS, not *")
+	}
+	if cfg.Security.HSTSMaxAge, err = getEnvDuration("SECURITY_HSTS_MAX_AGE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.MaxDepth, err = getEnvInt("GRAPHQL_MAX_DEPTH", 10); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.MaxComplexity, err = getEnvInt("GRAPHQL_MAX_COMPLEXITY", 10000); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.MaxLimit, err = getEnvInt("GRAPHQL_MAX_LIMIT", 500); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.RatePerSecond, err = getEnvFloat("RATE_LIMIT_PER_SECOND", 10); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.RateBurst, err = getEnvInt("RATE_LIMIT_BURST", 20); err != nil {
+		return nil, err
+	}
+	if cfg.Cache.MaxAge, err = getEnvDuration("GRAPHQL_CACHE_MAX_AGE", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.Persisted.CacheSize, err = getEnvInt("PERSISTED_QUERIES_CACHE_SIZE", 1000); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
@@ -87,6 +273,88 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvDuration lee una duración (ej: "30m", "1h"); "0" la desactiva
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if value == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvBool lee un booleano ("true", "false", "1", "0")
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvInt lee un entero
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvFloat lee un número decimal
+func getEnvFloat(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// getEnvList lee una lista de valores separados por comas
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAPIKeys lee una lista de API keys separadas por comas con el formato nombre:rol:key
+func getEnvAPIKeys(key string) ([]APIKeyConfig, error) {
+	var keys []APIKeyConfig
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid %s: entries must be name:role:key", key)
+		}
+		keys = append(keys, APIKeyConfig{Name: parts[0], Role: parts[1], Key: parts[2]})
+	}
+	return keys, nil
+}
+
 // loadEnvFiles intenta cargar archivos .env desde el directorio del proyecto
 func loadEnvFiles() {
 	// Buscar el directorio api/ desde el directorio actual
@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// FixtureEvent es la representación JSON de un evento de rating en un archivo de fixture
+type FixtureEvent struct {
+	Ticker     string    `json:"ticker"`
+	Company    string    `json:"company"`
+	Brokerage  string    `json:"brokerage"`
+	Action     string    `json:"action"`
+	RatingFrom string    `json:"ratingFrom"`
+	RatingTo   string    `json:"ratingTo"`
+	TargetFrom float64   `json:"targetFrom"`
+	TargetTo   float64   `json:"targetTo"`
+	EventTime  time.Time `json:"eventTime"`
+}
+
+// LoadFixture lee un arreglo JSON de eventos de rating (ratings canónicos, fechas RFC3339);
+// los scores de los ratings salen del modelo de scoring del backtest
+func LoadFixture(r io.Reader, model *scoring.Model) ([]*stock.RatingEvent, error) {
+	var fixtures []FixtureEvent
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixture: %w", err)
+	}
+
+	events := make([]*stock.RatingEvent, len(fixtures))
+	for i, f := range fixtures {
+		e, err := f.toEvent(model)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture event %d (%s): %w", i, f.Ticker, err)
+		}
+		events[i] = e
+	}
+	return events, nil
+}
+
+// WriteFixture escribe los eventos en el formato que lee LoadFixture
+func WriteFixture(w io.Writer, events []*stock.RatingEvent) error {
+	fixtures := make([]FixtureEvent, len(events))
+	for i, e := range events {
+		fixtures[i] = FixtureEvent{
+			Ticker:     e.Ticker,
+			Company:    e.CompanyName,
+			Brokerage:  e.Brokerage,
+			Action:     e.Action,
+			RatingFrom: e.RatingFrom.String(),
+			RatingTo:   e.RatingTo.String(),
+			TargetFrom: e.TargetFrom.Value(),
+			TargetTo:   e.TargetTo.Value(),
+			EventTime:  e.EventTime.UTC(),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fixtures)
+}
+
+// toEvent valida el evento del fixture y lo convierte en entidad de dominio
+func (f FixtureEvent) toEvent(model *scoring.Model) (*stock.RatingEvent, error) {
+	if f.Ticker == "" {
+		return nil, fmt.Errorf("ticker cannot be empty")
+	}
+	if f.EventTime.IsZero() {
+		return nil, fmt.Errorf("eventTime cannot be empty")
+	}
+
+	ratingFrom, ratingTo := stock.Rating(f.RatingFrom), stock.Rating(f.RatingTo)
+	if !ratingFrom.IsValid() {
+		return nil, fmt.Errorf("invalid ratingFrom: %s", f.RatingFrom)
+	}
+	if !ratingTo.IsValid() {
+		return nil, fmt.Errorf("invalid ratingTo: %s", f.RatingTo)
+	}
+
+	targetFrom, err := stock.NewPrice(f.TargetFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid targetFrom: %w", err)
+	}
+	targetTo, err := stock.NewPrice(f.TargetTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid targetTo: %w", err)
+	}
+
+	return &stock.RatingEvent{
+		ID:              uuid.New(),
+		Ticker:          f.Ticker,
+		CompanyName:     f.Company,
+		Brokerage:       f.Brokerage,
+		Action:          f.Action,
+		RatingFrom:      ratingFrom,
+		RatingTo:        ratingTo,
+		TargetFrom:      targetFrom,
+		TargetTo:        targetTo,
+		EventTime:       f.EventTime.UTC(),
+		CreatedAt:       f.EventTime.UTC(),
+		RatingFromRaw:   f.RatingFrom,
+		RatingToRaw:     f.RatingTo,
+		RatingFromScore: model.RatingScore(ratingFrom.String()),
+		RatingToScore:   model.RatingScore(ratingTo.String()),
+	}, nil
+}
@@ -0,0 +1,191 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// Config son los parámetros de un backtest
+type Config struct {
+	Start    time.Time     // Primera fecha simulada; cero usa el día del primer evento
+	End      time.Time     // Última fecha simulada; cero usa la fecha del último evento
+	Step     time.Duration // Paso entre evaluaciones; cero usa un día
+	Horizon  time.Duration // Ventana en la que se mide la evolución de cada recomendación
+	Limit    int           // Recomendaciones por fecha
+	Criteria recommendation.Criteria
+}
+
+// Pick es un ticker recomendado en una fecha simulada y cómo evolucionó después
+type Pick struct {
+	Date         time.Time
+	Rank         int
+	Ticker       string
+	Brokerage    string
+	Score        float64
+	Rating       stock.Rating
+	Target       float64
+	FutureRating stock.Rating // Último rating al final del horizonte
+	FutureTarget float64      // Último precio objetivo al final del horizonte
+	TargetChange float64      // Cambio porcentual del precio objetivo en el horizonte
+	RatingChange float64      // Cambio del score del rating en el horizonte
+	Upgrades     int          // Llamadas con upgrade dentro del horizonte
+	Downgrades   int          // Llamadas con downgrade dentro del horizonte
+	Complete     bool         // El historial cubre el horizonte completo
+}
+
+// Summary resume la evolución de las recomendaciones con horizonte completo
+type Summary struct {
+	Steps           int
+	Picks           int
+	CompletePicks   int
+	AvgTargetChange float64
+	AvgRatingChange float64
+	HitRate         float64 // Proporción de recomendaciones cuyo precio objetivo subió
+}
+
+// Report es el resultado de un backtest
+type Report struct {
+	Algorithm string
+	Config    Config
+	Picks     []Pick
+	Summary   Summary
+}
+
+// Run reproduce el historial del store fecha a fecha a través del algoritmo, que debe usar
+// el mismo reloj (para el decaimiento y la ventana de consenso) y el store como historial
+func Run(
+	ctx context.Context,
+	name string,
+	algorithm recommendation.Algorithm,
+	store *Store,
+	clock *Clock,
+	model *scoring.Model,
+	cfg Config,
+) (*Report, error) {
+	events := store.Events()
+	if len(events) == 0 {
+		return nil, errors.New("no rating events to replay")
+	}
+	if cfg.Limit <= 0 {
+		return nil, fmt.Errorf("invalid limit: %d", cfg.Limit)
+	}
+	if cfg.Horizon < 0 {
+		return nil, fmt.Errorf("invalid horizon: %s", cfg.Horizon)
+	}
+
+	first, last := events[0].EventTime, events[len(events)-1].EventTime
+	if cfg.Start.IsZero() {
+		cfg.Start = first.Truncate(24 * time.Hour)
+	}
+	if cfg.End.IsZero() {
+		cfg.End = last
+	}
+	if cfg.Step <= 0 {
+		cfg.Step = 24 * time.Hour
+	}
+	if cfg.End.Before(cfg.Start) {
+		return nil, fmt.Errorf("end %s is before start %s", cfg.End.Format(time.RFC3339), cfg.Start.Format(time.RFC3339))
+	}
+
+	// Historial completo por ticker para medir la evolución posterior
+	byTicker := make(map[string][]*stock.RatingEvent)
+	for _, e := range events {
+		byTicker[e.Ticker] = append(byTicker[e.Ticker], e)
+	}
+
+	report := &Report{Algorithm: name, Config: cfg, Picks: []Pick{}}
+	for date := cfg.Start; !date.After(cfg.End); date = date.Add(cfg.Step) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		clock.Set(date)
+		recommendations, err := algorithm.CalculateWithCriteria(ctx, store.Latest(), cfg.Criteria, cfg.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate recommendations at %s: %w", date.Format(time.RFC3339), err)
+		}
+
+		report.Summary.Steps++
+		for i, rec := range recommendations {
+			pick := Pick{
+				Date:      date,
+				Rank:      i + 1,
+				Ticker:    rec.Stock.Ticker,
+				Brokerage: rec.Stock.Brokerage,
+				Score:     rec.Score,
+				Rating:    rec.Stock.RatingTo,
+				Target:    rec.Stock.TargetTo.Value(),
+				Complete:  !date.Add(cfg.Horizon).After(last),
+			}
+			measure(&pick, byTicker[pick.Ticker], date.Add(cfg.Horizon), model)
+			report.Picks = append(report.Picks, pick)
+		}
+	}
+
+	report.Summary.Picks = len(report.Picks)
+	summarize(report)
+
+	return report, nil
+}
+
+// measure completa la evolución de pick con las llamadas del ticker hasta until
+func measure(pick *Pick, events []*stock.RatingEvent, until time.Time, model *scoring.Model) {
+	pick.FutureRating = pick.Rating
+	pick.FutureTarget = pick.Target
+
+	for _, e := range events {
+		if !e.EventTime.After(pick.Date) {
+			continue
+		}
+		if e.EventTime.After(until) {
+			break
+		}
+
+		pick.FutureRating = e.RatingTo
+		pick.FutureTarget = e.TargetTo.Value()
+
+		from, to := e.RatingFrom.String(), e.RatingTo.String()
+		switch {
+		case model.IsUpgrade(from, to):
+			pick.Upgrades++
+		case model.IsUpgrade(to, from):
+			pick.Downgrades++
+		}
+	}
+
+	if pick.Target != 0 {
+		pick.TargetChange = (pick.FutureTarget - pick.Target) / pick.Target * 100
+	}
+	pick.RatingChange = model.RatingScore(pick.FutureRating.String()) - model.RatingScore(pick.Rating.String())
+}
+
+// summarize calcula las métricas agregadas sobre las recomendaciones con horizonte completo
+func summarize(report *Report) {
+	s := &report.Summary
+	var targetSum, ratingSum float64
+	hits := 0
+	for _, p := range report.Picks {
+		if !p.Complete {
+			continue
+		}
+		s.CompletePicks++
+		targetSum += p.TargetChange
+		ratingSum += p.RatingChange
+		if p.TargetChange > 0 {
+			hits++
+		}
+	}
+
+	if s.CompletePicks > 0 {
+		n := float64(s.CompletePicks)
+		s.AvgTargetChange = targetSum / n
+		s.AvgRatingChange = ratingSum / n
+		s.HitRate = float64(hits) / n
+	}
+}
@@ -0,0 +1,154 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/recommendation"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFixture = `[
+  {"ticker": "AAPL", "company": "Apple Inc.", "brokerage": "Broker A", "action": "upgraded by",
+   "ratingFrom": "Neutral", "ratingTo": "Buy", "targetFrom": 100, "targetTo": 120, "eventTime": "2025-01-01T00:00:00Z"},
+  {"ticker": "NFLX", "company": "Netflix Inc.", "brokerage": "Broker B", "action": "downgraded by",
+   "ratingFrom": "Buy", "ratingTo": "Sell", "targetFrom": 100, "targetTo": 80, "eventTime": "2025-01-01T00:00:00Z"},
+  {"ticker": "AAPL", "company": "Apple Inc.", "brokerage": "Broker C", "action": "target raised by",
+   "ratingFrom": "Buy", "ratingTo": "Strong Buy", "targetFrom": 120, "targetTo": 150, "eventTime": "2025-01-03T00:00:00Z"}
+]`
+
+func loadTestEvents(t *testing.T) []*stock.RatingEvent {
+	events, err := LoadFixture(strings.NewReader(testFixture), scoring.DefaultModel())
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	return events
+}
+
+func TestRun(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	clock := &Clock{}
+	store := NewStore(clock, loadTestEvents(t))
+	model := scoring.DefaultModel()
+	algorithm := recommendation.NewRecommendationAlgorithm(stock.NewDomainServiceWithClock(model, clock.Now))
+
+	report, err := Run(context.Background(), recommendation.AlgorithmLatest, algorithm, store, clock, model, Config{
+		End:     start.Add(day),
+		Horizon: 2 * day,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, start, report.Config.Start)
+	assert.Equal(t, 2, report.Summary.Steps)
+	require.Len(t, report.Picks, 2)
+
+	// La primera fecha no ve la llamada del día 3 (sin lookahead), pero la mide en el horizonte
+	first := report.Picks[0]
+	assert.Equal(t, start, first.Date)
+	assert.Equal(t, "AAPL", first.Ticker)
+	assert.Equal(t, "Broker A", first.Brokerage)
+	assert.Equal(t, 120.0, first.Target)
+	assert.Equal(t, stock.RatingBuy, first.Rating)
+	assert.Equal(t, 150.0, first.FutureTarget)
+	assert.Equal(t, stock.RatingStrongBuy, first.FutureRating)
+	assert.InDelta(t, 25.0, first.TargetChange, 0.001)
+	assert.Greater(t, first.RatingChange, 0.0)
+	assert.Equal(t, 1, first.Upgrades)
+	assert.True(t, first.Complete)
+
+	// El horizonte de la segunda fecha va más allá del historial
+	second := report.Picks[1]
+	assert.Equal(t, start.Add(day), second.Date)
+	assert.False(t, second.Complete)
+
+	assert.Equal(t, 2, report.Summary.Picks)
+	assert.Equal(t, 1, report.Summary.CompletePicks)
+	assert.InDelta(t, 25.0, report.Summary.AvgTargetChange, 0.001)
+	assert.Equal(t, 1.0, report.Summary.HitRate)
+}
+
+func TestRun_Errors(t *testing.T) {
+	clock := &Clock{}
+	model := scoring.DefaultModel()
+	algorithm := recommendation.NewRecommendationAlgorithm(stock.NewDomainServiceWithClock(model, clock.Now))
+
+	_, err := Run(context.Background(), "latest", algorithm, NewStore(clock, nil), clock, model, Config{Limit: 1})
+	assert.Error(t, err)
+
+	_, err = Run(context.Background(), "latest", algorithm, NewStore(clock, loadTestEvents(t)), clock, model, Config{})
+	assert.Error(t, err)
+}
+
+func TestStore_HidesFutureEvents(t *testing.T) {
+	clock := &Clock{}
+	store := NewStore(clock, loadTestEvents(t))
+
+	clock.Set(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	events, err := store.FindSince(context.Background(), []string{"AAPL"}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Broker A", events[0].Brokerage)
+
+	latest := store.Latest()
+	assert.Len(t, latest, 2)
+
+	clock.Set(time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC))
+	events, err = store.FindByTicker(context.Background(), "AAPL", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "Broker C", events[0].Brokerage)
+}
+
+func TestFixture_RoundTrip(t *testing.T) {
+	events := loadTestEvents(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFixture(&buf, events))
+
+	reloaded, err := LoadFixture(&buf, scoring.DefaultModel())
+	require.NoError(t, err)
+	require.Len(t, reloaded, len(events))
+	for i := range events {
+		assert.Equal(t, events[i].Ticker, reloaded[i].Ticker)
+		assert.Equal(t, events[i].RatingTo, reloaded[i].RatingTo)
+		assert.Equal(t, events[i].RatingToScore, reloaded[i].RatingToScore)
+		assert.Equal(t, events[i].TargetTo.Value(), reloaded[i].TargetTo.Value())
+		assert.True(t, events[i].EventTime.Equal(reloaded[i].EventTime))
+	}
+
+	_, err = LoadFixture(strings.NewReader(`[{"ticker": "AAPL", "ratingFrom": "Bogus", "ratingTo": "Buy", "eventTime": "2025-01-01T00:00:00Z"}]`), scoring.DefaultModel())
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	report := &Report{Picks: []Pick{{
+		Date:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Rank:         1,
+		Ticker:       "AAPL",
+		Rating:       stock.RatingBuy,
+		Target:       120,
+		FutureRating: stock.RatingStrongBuy,
+		FutureTarget: 150,
+		TargetChange: 25,
+		Complete:     true,
+	}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, report))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, "AAPL", records[1][2])
+	assert.Equal(t, "true", records[1][len(records[1])-1])
+}
@@ -0,0 +1,128 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader son las columnas del reporte CSV (una fila por recomendación)
+var csvHeader = []string{
+	"date", "rank", "ticker", "brokerage", "score", "rating", "target",
+	"future_rating", "future_target", "target_change", "rating_change",
+	"upgrades", "downgrades", "complete",
+}
+
+// WriteCSV escribe una fila por recomendación simulada
+func WriteCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, p := range report.Picks {
+		record := []string{
+			p.Date.UTC().Format(time.RFC3339),
+			strconv.Itoa(p.Rank),
+			p.Ticker,
+			p.Brokerage,
+			formatFloat(p.Score),
+			p.Rating.String(),
+			formatFloat(p.Target),
+			p.FutureRating.String(),
+			formatFloat(p.FutureTarget),
+			formatFloat(p.TargetChange),
+			formatFloat(p.RatingChange),
+			strconv.Itoa(p.Upgrades),
+			strconv.Itoa(p.Downgrades),
+			strconv.FormatBool(p.Complete),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonReport es la representación JSON de un reporte
+type jsonReport struct {
+	Algorithm string      `json:"algorithm"`
+	Start     time.Time   `json:"start"`
+	End       time.Time   `json:"end"`
+	Step      string      `json:"step"`
+	Horizon   string      `json:"horizon"`
+	Limit     int         `json:"limit"`
+	Summary   jsonSummary `json:"summary"`
+	Picks     []jsonPick  `json:"picks"`
+}
+
+type jsonSummary struct {
+	Steps           int     `json:"steps"`
+	Picks           int     `json:"picks"`
+	CompletePicks   int     `json:"completePicks"`
+	AvgTargetChange float64 `json:"avgTargetChange"`
+	AvgRatingChange float64 `json:"avgRatingChange"`
+	HitRate         float64 `json:"hitRate"`
+}
+
+type jsonPick struct {
+	Date         time.Time `json:"date"`
+	Rank         int       `json:"rank"`
+	Ticker       string    `json:"ticker"`
+	Brokerage    string    `json:"brokerage"`
+	Score        float64   `json:"score"`
+	Rating       string    `json:"rating"`
+	Target       float64   `json:"target"`
+	FutureRating string    `json:"futureRating"`
+	FutureTarget float64   `json:"futureTarget"`
+	TargetChange float64   `json:"targetChange"`
+	RatingChange float64   `json:"ratingChange"`
+	Upgrades     int       `json:"upgrades"`
+	Downgrades   int       `json:"downgrades"`
+	Complete     bool      `json:"complete"`
+}
+
+// WriteJSON escribe el reporte completo (parámetros, resumen y recomendaciones)
+func WriteJSON(w io.Writer, report *Report) error {
+	out := jsonReport{
+		Algorithm: report.Algorithm,
+		Start:     report.Config.Start.UTC(),
+		End:       report.Config.End.UTC(),
+		Step:      report.Config.Step.String(),
+		Horizon:   report.Config.Horizon.String(),
+		Limit:     report.Config.Limit,
+		Summary:   jsonSummary(report.Summary),
+		Picks:     make([]jsonPick, len(report.Picks)),
+	}
+	for i, p := range report.Picks {
+		out.Picks[i] = jsonPick{
+			Date:         p.Date.UTC(),
+			Rank:         p.Rank,
+			Ticker:       p.Ticker,
+			Brokerage:    p.Brokerage,
+			Score:        p.Score,
+			Rating:       p.Rating.String(),
+			Target:       p.Target,
+			FutureRating: p.FutureRating.String(),
+			FutureTarget: p.FutureTarget,
+			TargetChange: p.TargetChange,
+			RatingChange: p.RatingChange,
+			Upgrades:     p.Upgrades,
+			Downgrades:   p.Downgrades,
+			Complete:     p.Complete,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// formatFloat formatea un número con la precisión mínima necesaria
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
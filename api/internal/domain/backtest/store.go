@@ -0,0 +1,181 @@
+package backtest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// Clock es el reloj simulado de un backtest
+type Clock struct {
+	now time.Time
+}
+
+// Now retorna la fecha simulada
+func (c *Clock) Now() time.Time {
+	return c.now
+}
+
+// Set avanza el reloj a la fecha indicada
+func (c *Clock) Set(t time.Time) {
+	c.now = t
+}
+
+// Store es un historial de eventos en memoria que solo expone los eventos anteriores
+// o iguales a la fecha del reloj, de modo que los algoritmos no vean el futuro.
+// Implementa stock.RatingEventRepository.
+type Store struct {
+	clock  *Clock
+	events []*stock.RatingEvent // Ordenados por fecha del evento ascendente
+}
+
+// NewStore crea un historial en memoria con los eventos indicados
+func NewStore(clock *Clock, events []*stock.RatingEvent) *Store {
+	s := &Store{clock: clock}
+	_, _ = s.BatchInsert(context.Background(), events)
+	return s
+}
+
+// BatchInsert agrega eventos ignorando los duplicados (ticker, brokerage, action, event_time)
+func (s *Store) BatchInsert(ctx context.Context, events []*stock.RatingEvent) (int, error) {
+	seen := make(map[string]bool, len(s.events))
+	for _, e := range s.events {
+		seen[eventKey(e)] = true
+	}
+
+	inserted := 0
+	for _, e := range events {
+		key := eventKey(e)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		s.events = append(s.events, e)
+		inserted++
+	}
+
+	sort.SliceStable(s.events, func(i, j int) bool {
+		return s.events[i].EventTime.Before(s.events[j].EventTime)
+	})
+
+	return inserted, nil
+}
+
+// FindByTicker retorna el historial visible de un ticker, del más reciente al más antiguo
+func (s *Store) FindByTicker(ctx context.Context, ticker string, limit int) ([]*stock.RatingEvent, error) {
+	result := make([]*stock.RatingEvent, 0)
+	for _, e := range s.visible(time.Time{}) {
+		if e.Ticker == ticker {
+			result = append(result, e)
+		}
+	}
+	reverse(result)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindByTickers retorna los últimos limit eventos visibles de cada ticker,
+// ordenados por ticker y del más reciente al más antiguo
+func (s *Store) FindByTickers(ctx context.Context, tickers []string, limit int) ([]*stock.RatingEvent, error) {
+	events, err := s.FindSince(ctx, tickers, time.Time{})
+	if err != nil || len(tickers) == 0 {
+		return []*stock.RatingEvent{}, err
+	}
+
+	result := make([]*stock.RatingEvent, 0, len(events))
+	count := make(map[string]int)
+	for _, e := range events {
+		if limit > 0 && count[e.Ticker] >= limit {
+			continue
+		}
+		count[e.Ticker]++
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// FindSince retorna los eventos visibles de los tickers indicados (todos si está vacío)
+// desde since, ordenados por ticker y del más reciente al más antiguo
+func (s *Store) FindSince(ctx context.Context, tickers []string, since time.Time) ([]*stock.RatingEvent, error) {
+	wanted := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		wanted[t] = true
+	}
+
+	result := make([]*stock.RatingEvent, 0)
+	for _, e := range s.visible(since) {
+		if len(wanted) == 0 || wanted[e.Ticker] {
+			result = append(result, e)
+		}
+	}
+	reverse(result)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Ticker < result[j].Ticker
+	})
+	return result, nil
+}
+
+// LastChange retorna la fecha del último evento visible: el historial cambia al avanzar el reloj
+func (s *Store) LastChange(ctx context.Context) (time.Time, error) {
+	visible := s.visible(time.Time{})
+	if len(visible) == 0 {
+		return time.Time{}, nil
+	}
+	return visible[len(visible)-1].EventTime, nil
+}
+
+// Events retorna todos los eventos, del más antiguo al más reciente
+func (s *Store) Events() []*stock.RatingEvent {
+	return s.events
+}
+
+// Latest retorna el último estado de cada ticker a la fecha del reloj,
+// equivalente a la tabla stocks en ese momento
+func (s *Store) Latest() []*stock.Stock {
+	latest := make(map[string]*stock.RatingEvent)
+	order := make([]string, 0)
+	for _, e := range s.visible(time.Time{}) {
+		if _, ok := latest[e.Ticker]; !ok {
+			order = append(order, e.Ticker)
+		}
+		latest[e.Ticker] = e
+	}
+
+	stocks := make([]*stock.Stock, len(order))
+	for i, ticker := range order {
+		stocks[i] = stock.NewStockFromRatingEvent(latest[ticker])
+	}
+	return stocks
+}
+
+// visible retorna los eventos entre since y la fecha del reloj, del más antiguo al más reciente
+func (s *Store) visible(since time.Time) []*stock.RatingEvent {
+	now := s.clock.Now()
+	result := make([]*stock.RatingEvent, 0)
+	for _, e := range s.events {
+		if e.EventTime.After(now) {
+			break
+		}
+		if !e.EventTime.Before(since) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// eventKey identifica un evento como la restricción única de rating_events
+func eventKey(e *stock.RatingEvent) string {
+	return strings.Join([]string{e.Ticker, e.Brokerage, e.Action, e.EventTime.UTC().Format(time.RFC3339Nano)}, "|")
+}
+
+// reverse invierte el orden de los eventos
+func reverse(events []*stock.RatingEvent) {
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+}
@@ -0,0 +1,167 @@
+package brokerage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// reliabilityPrior es el número de llamadas neutrales (50% de aciertos) que se suman
+// al historial para que los brokerages con pocas llamadas evaluadas no resulten extremos
+const reliabilityPrior = 5.0
+
+// Stats es el historial de un brokerage calculado a partir de sus llamadas sucesivas
+// sobre un mismo ticker. Una llamada se evalúa si tiene dirección (upgrade, downgrade,
+// subida o bajada del precio objetivo) y el brokerage volvió a opinar sobre el ticker.
+type Stats struct {
+	Name              string
+	Calls             int // Llamadas registradas
+	Tickers           int // Tickers distintos cubiertos
+	EvaluatedCalls    int // Llamadas con dirección seguidas de otra llamada
+	Hits              int // La llamada siguiente fue en la misma dirección
+	Reversals         int // La llamada siguiente fue en la dirección contraria
+	HitRate           float64
+	AvgTargetRevision float64 // Cambio porcentual medio del precio objetivo en la llamada siguiente
+	Reliability       float64 // HitRate suavizado hacia 0.5 según el número de llamadas evaluadas
+}
+
+// Reliability contiene el peso por brokerage (clave normalizada) con que se ponderan sus llamadas
+type Reliability map[string]float64
+
+// Weight retorna el peso de las llamadas de un brokerage: 0.5 + fiabilidad,
+// de modo que un brokerage sin historial (o con 50% de aciertos) pesa 1
+func (r Reliability) Weight(name string) float64 {
+	if w, ok := r[NormalizeName(name)]; ok {
+		return w
+	}
+	return 1
+}
+
+// NewReliability construye los pesos de fiabilidad a partir de las estadísticas
+func NewReliability(stats []*Stats) Reliability {
+	r := make(Reliability, len(stats))
+	for _, s := range stats {
+		r[NormalizeName(s.Name)] = 0.5 + s.Reliability
+	}
+	return r
+}
+
+// NormalizeName normaliza el nombre de un brokerage para compararlo
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// ComputeStats calcula el historial de cada brokerage a partir de los eventos de rating,
+// ordenado por número de llamadas descendente
+func ComputeStats(events []*stock.RatingEvent, model *scoring.Model) []*Stats {
+	type key struct{ brokerage, ticker string }
+
+	byCall := make(map[key][]*stock.RatingEvent)
+	byName := make(map[string]*Stats)
+	tickers := make(map[string]map[string]bool)
+	revisions := make(map[string][]float64)
+
+	for _, e := range events {
+		name := NormalizeName(e.Brokerage)
+		if _, ok := byName[name]; !ok {
+			byName[name] = &Stats{Name: strings.TrimSpace(e.Brokerage)}
+			tickers[name] = make(map[string]bool)
+		}
+		byName[name].Calls++
+		tickers[name][e.Ticker] = true
+
+		k := key{name, e.Ticker}
+		byCall[k] = append(byCall[k], e)
+	}
+
+	for k, calls := range byCall {
+		sort.SliceStable(calls, func(i, j int) bool {
+			return calls[i].EventTime.Before(calls[j].EventTime)
+		})
+
+		s := byName[k.brokerage]
+		for i := 1; i < len(calls); i++ {
+			prev, next := calls[i-1], calls[i]
+
+			if !prev.TargetTo.IsZero() && !next.TargetTo.IsZero() {
+				revision := (next.TargetTo.Value() - prev.TargetTo.Value()) / prev.TargetTo.Value() * 100
+				revisions[k.brokerage] = append(revisions[k.brokerage], revision)
+			}
+
+			direction := callDirection(prev, model)
+			if direction == 0 {
+				continue
+			}
+			s.EvaluatedCalls++
+			switch followUpDirection(prev, next, model) {
+			case direction:
+				s.Hits++
+			case -direction:
+				s.Reversals++
+			}
+		}
+	}
+
+	result := make([]*Stats, 0, len(byName))
+	for name, s := range byName {
+		s.Tickers = len(tickers[name])
+		if s.EvaluatedCalls > 0 {
+			s.HitRate = float64(s.Hits) / float64(s.EvaluatedCalls)
+		}
+		s.Reliability = (float64(s.Hits) + reliabilityPrior*0.5) / (float64(s.EvaluatedCalls) + reliabilityPrior)
+		if r := revisions[name]; len(r) > 0 {
+			var sum float64
+			for _, v := range r {
+				sum += v
+			}
+			s.AvgTargetRevision = sum / float64(len(r))
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// callDirection retorna 1 si la llamada es alcista, -1 si es bajista y 0 si no tiene dirección.
+// El cambio de rating tiene prioridad sobre el del precio objetivo.
+func callDirection(e *stock.RatingEvent, model *scoring.Model) int {
+	from, to := e.RatingFrom.String(), e.RatingTo.String()
+	switch {
+	case model.IsUpgrade(from, to):
+		return 1
+	case model.IsUpgrade(to, from):
+		return -1
+	}
+	if e.TargetFrom.IsZero() {
+		return 0
+	}
+	return compare(e.TargetTo.Value(), e.TargetFrom.Value())
+}
+
+// followUpDirection retorna la dirección de next respecto al estado que dejó prev
+func followUpDirection(prev, next *stock.RatingEvent, model *scoring.Model) int {
+	if d := compare(model.RatingScore(next.RatingTo.String()), model.RatingScore(prev.RatingTo.String())); d != 0 {
+		return d
+	}
+	return compare(next.TargetTo.Value(), prev.TargetTo.Value())
+}
+
+// compare retorna el signo de a - b
+func compare(a, b float64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
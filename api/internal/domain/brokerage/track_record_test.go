@@ -0,0 +1,74 @@
+package brokerage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvent(ticker, brokerage string, ratingFrom, ratingTo stock.Rating, targetFrom, targetTo float64, day int) *stock.RatingEvent {
+	tf, _ := stock.NewPrice(targetFrom)
+	tt, _ := stock.NewPrice(targetTo)
+	return &stock.RatingEvent{
+		Ticker:     ticker,
+		Brokerage:  brokerage,
+		RatingFrom: ratingFrom,
+		RatingTo:   ratingTo,
+		TargetFrom: tf,
+		TargetTo:   tt,
+		EventTime:  time.Date(2025, 1, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	events := []*stock.RatingEvent{
+		// Broker A: upgrade seguido de una subida (acierto), subida seguida de un downgrade (reversión)
+		newEvent("AAPL", "Broker A", stock.RatingNeutral, stock.RatingBuy, 100, 110, 1),
+		newEvent("AAPL", "broker a", stock.RatingBuy, stock.RatingBuy, 110, 121, 5),
+		newEvent("AAPL", "Broker A", stock.RatingBuy, stock.RatingNeutral, 121, 121, 9),
+		// Broker A en otro ticker: sin llamada siguiente, no se evalúa
+		newEvent("MSFT", "Broker A", stock.RatingBuy, stock.RatingStrongBuy, 300, 330, 2),
+		// Broker B: llamada sin dirección seguida de una bajada, no se evalúa
+		newEvent("AAPL", "Broker B", stock.RatingBuy, stock.RatingBuy, 100, 100, 1),
+		newEvent("AAPL", "Broker B", stock.RatingBuy, stock.RatingBuy, 100, 90, 3),
+	}
+
+	stats := ComputeStats(events, scoring.DefaultModel())
+	require.Len(t, stats, 2)
+
+	a := stats[0]
+	assert.Equal(t, "Broker A", a.Name)
+	assert.Equal(t, 4, a.Calls)
+	assert.Equal(t, 2, a.Tickers)
+	assert.Equal(t, 2, a.EvaluatedCalls)
+	assert.Equal(t, 1, a.Hits)
+	assert.Equal(t, 1, a.Reversals)
+	assert.InDelta(t, 0.5, a.HitRate, 1e-9)
+	// (110 -> 121) = 10%, (121 -> 121) = 0%
+	assert.InDelta(t, 5.0, a.AvgTargetRevision, 1e-9)
+	assert.InDelta(t, 0.5, a.Reliability, 1e-9)
+
+	b := stats[1]
+	assert.Equal(t, "Broker B", b.Name)
+	assert.Equal(t, 0, b.EvaluatedCalls)
+	assert.InDelta(t, -10.0, b.AvgTargetRevision, 1e-9)
+	assert.InDelta(t, 0.5, b.Reliability, 1e-9)
+}
+
+func TestReliability_Weight(t *testing.T) {
+	r := NewReliability([]*Stats{
+		{Name: "Good Broker", Reliability: 0.8},
+		{Name: "Bad Broker", Reliability: 0.2},
+	})
+
+	assert.InDelta(t, 1.3, r.Weight("good  broker"), 1e-9)
+	assert.InDelta(t, 0.7, r.Weight("Bad Broker"), 1e-9)
+	assert.Equal(t, 1.0, r.Weight("Unknown"))
+
+	var none Reliability
+	assert.Equal(t, 1.0, none.Weight("Good Broker"))
+}
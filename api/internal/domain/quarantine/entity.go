@@ -0,0 +1,80 @@
+package quarantine
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status representa el estado de un registro en cuarentena
+type Status string
+
+const (
+	// StatusPending el registro sigue sin poder procesarse
+	StatusPending Status = "pending"
+	// StatusResolved el registro se reprocesó y se ingirió correctamente
+	StatusResolved Status = "resolved"
+)
+
+// IsValid valida si el estado es válido
+func (s Status) IsValid() bool {
+	return s == StatusPending || s == StatusResolved
+}
+
+// String retorna el string del estado
+func (s Status) String() string {
+	return string(s)
+}
+
+// Record representa un registro de la API externa que no pasó la validación
+type Record struct {
+	ID            uuid.UUID
+	SyncRunID     *uuid.UUID // nil si no se originó en una ejecución registrada
+	Ticker        string
+	RawPayload    []byte // Item original de la API en JSON
+	Error         string
+	Status        Status
+	Attempts      int
+	CreatedAt     time.Time
+	LastAttemptAt *time.Time
+	ResolvedAt    *time.Time
+}
+
+// NewRecord crea un nuevo registro en cuarentena
+func NewRecord(syncRunID uuid.UUID, ticker string, rawPayload []byte, validationErr error) *Record {
+	r := &Record{
+		ID:         uuid.New(),
+		Ticker:     ticker,
+		RawPayload: rawPayload,
+		Status:     StatusPending,
+		Attempts:   1,
+		CreatedAt:  time.Now(),
+	}
+	if syncRunID != uuid.Nil {
+		r.SyncRunID = &syncRunID
+	}
+	if validationErr != nil {
+		r.Error = validationErr.Error()
+	}
+	return r
+}
+
+// Resolve marca el registro como reprocesado correctamente
+func (r *Record) Resolve() {
+	now := time.Now()
+	r.Status = StatusResolved
+	r.Error = ""
+	r.Attempts++
+	r.LastAttemptAt = &now
+	r.ResolvedAt = &now
+}
+
+// RecordFailure registra un nuevo intento fallido de reprocesamiento
+func (r *Record) RecordFailure(err error) {
+	now := time.Now()
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.Attempts++
+	r.LastAttemptAt = &now
+}
@@ -0,0 +1,30 @@
+package quarantine
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Filter representa los filtros para búsqueda de registros en cuarentena
+type Filter struct {
+	Status    Status     // Vacío para cualquier estado
+	SyncRunID *uuid.UUID // nil para cualquier ejecución
+	Limit     int
+}
+
+// Repository define la interfaz del repositorio de cuarentena
+type Repository interface {
+	// SaveBatch guarda múltiples registros en cuarentena; los ya guardados con el mismo
+	// ticker y payload no se duplican
+	SaveBatch(ctx context.Context, records []*Record) error
+
+	// Update actualiza el estado de un registro tras un reprocesamiento
+	Update(ctx context.Context, record *Record) error
+
+	// Find busca registros con filtros, del más reciente al más antiguo
+	Find(ctx context.Context, filter Filter) ([]*Record, error)
+
+	// FindByIDs busca registros por sus IDs
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*Record, error)
+}
@@ -2,8 +2,10 @@ package recommendation
 
 import (
 	"context"
+	"fmt"
 	"sort"
 
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 )
 
@@ -11,6 +13,12 @@ import (
 type Algorithm interface {
 	// CalculateRecommendations calcula las recomendaciones basadas en stocks
 	CalculateRecommendations(ctx context.Context, stocks []*stock.Stock, limit int) ([]*Recommendation, error)
+
+	// CalculateWithCriteria calcula las recomendaciones con pesos y filtros ajustados
+	CalculateWithCriteria(ctx context.Context, stocks []*stock.Stock, criteria Criteria, limit int) ([]*Recommendation, error)
+
+	// Explain explica el score de un ticker y, si no está entre las limit primeras, por qué
+	Explain(ctx context.Context, stocks []*stock.Stock, ticker string, criteria Criteria, limit int) (*Explanation, error)
 }
 
 // RecommendationAlgorithm implementa el algoritmo de recomendación
@@ -25,21 +33,51 @@ func NewRecommendationAlgorithm(stockService stock.Service) Algorithm {
 	}
 }
 
-// CalculateRecommendations calcula las recomendaciones
+// CalculateRecommendations calcula las recomendaciones con los criterios por defecto
 // Complejidad: O(n log n) donde n = número de stocks
 func (a *RecommendationAlgorithm) CalculateRecommendations(
 	ctx context.Context,
 	stocks []*stock.Stock,
 	limit int,
 ) ([]*Recommendation, error) {
-	// Paso 1: Filtrar stocks con rating positivo
-	filteredStocks := a.filterPositiveRatings(stocks)
+	return a.CalculateWithCriteria(ctx, stocks, Criteria{}, limit)
+}
+
+// CalculateWithCriteria calcula las recomendaciones con pesos y filtros ajustados
+// Complejidad: O(n log n) donde n = número de stocks
+func (a *RecommendationAlgorithm) CalculateWithCriteria(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	criteria Criteria,
+	limit int,
+) ([]*Recommendation, error) {
+	ranked, err := a.rank(stocks, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paso 4: Seleccionar top N con las restricciones de diversificación
+	recommendations, _ := diversify(ranked, criteria.Diversification, limit)
+	return recommendations, nil
+}
+
+// rank retorna todas las recomendaciones que cumplen los criterios, ordenadas por score descendente
+func (a *RecommendationAlgorithm) rank(stocks []*stock.Stock, criteria Criteria) ([]*Recommendation, error) {
+	if err := criteria.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Paso 1: Filtrar stocks por rating (positivos por defecto), brokerage y tickers excluidos
+	filteredStocks := a.filterStocks(stocks, criteria)
 
 	// Paso 2: Calcular scores para cada stock (O(n))
 	recommendations := make([]*Recommendation, 0, len(filteredStocks))
 	for _, s := range filteredStocks {
-		score := a.calculateScore(s)
-		recommendations = append(recommendations, score)
+		rec := a.calculateScore(s, criteria)
+		if minPriceChangeExclusion(criteria, rec.PriceChange) != "" {
+			continue
+		}
+		recommendations = append(recommendations, rec)
 	}
 
 	// Paso 3: Ordenar por score descendente (O(n log n))
@@ -47,71 +85,98 @@ func (a *RecommendationAlgorithm) CalculateRecommendations(
 		return recommendations[i].Score > recommendations[j].Score
 	})
 
-	// Paso 4: Retornar top N
-	if len(recommendations) > limit {
-		recommendations = recommendations[:limit]
+	return recommendations, nil
+}
+
+// Explain explica el score de un ticker y los motivos por los que no aparece en las recomendaciones
+func (a *RecommendationAlgorithm) Explain(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	ticker string,
+	criteria Criteria,
+	limit int,
+) (*Explanation, error) {
+	if err := criteria.Validate(); err != nil {
+		return nil, err
 	}
 
-	return recommendations, nil
+	target := findStock(stocks, ticker)
+	if target == nil {
+		return nil, fmt.Errorf("stock not found: %s", ticker)
+	}
+
+	rec := a.calculateScore(target, criteria)
+	exclusions := criteria.Exclusions(target)
+	if reason := minPriceChangeExclusion(criteria, rec.PriceChange); reason != "" {
+		exclusions = append(exclusions, reason)
+	}
+
+	ranked, err := a.rank(stocks, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	return newExplanation(target.Ticker, rec, exclusions, ranked, criteria.Diversification, limit), nil
 }
 
-// filterPositiveRatings filtra stocks con rating positivo
-func (a *RecommendationAlgorithm) filterPositiveRatings(stocks []*stock.Stock) []*stock.Stock {
+// filterStocks filtra los stocks que cumplen los criterios
+func (a *RecommendationAlgorithm) filterStocks(stocks []*stock.Stock, criteria Criteria) []*stock.Stock {
 	filtered := make([]*stock.Stock, 0)
 	for _, s := range stocks {
-		if s.RatingTo.IsPositive() {
+		if criteria.Matches(s) {
 			filtered = append(filtered, s)
 		}
 	}
 	return filtered
 }
 
-// calculateScore calcula el score de recomendación para un stock
-func (a *RecommendationAlgorithm) calculateScore(s *stock.Stock) *Recommendation {
-	// Calcular cambio porcentual
-	priceChange := s.CalculatePriceChange()
-
-	// Calcular score de rating
-	ratingScore := a.getRatingScore(s.RatingTo)
-	if s.IsRatingUpgrade() {
-		ratingScore += 2 // Bonus por upgrade
+// calculateScore calcula el score de recomendación para un stock con el modelo de scoring del servicio
+// (los pesos y la vida media de los criterios, si se indican, reemplazan a los del modelo)
+func (a *RecommendationAlgorithm) calculateScore(s *stock.Stock, criteria Criteria) *Recommendation {
+	model := a.stockService.ScoringModel()
+	weights := model.Weights
+	if criteria.Weights != nil {
+		weights = *criteria.Weights
+	}
+	halfLife := model.HalfLife()
+	if criteria.HalfLife != nil {
+		halfLife = *criteria.HalfLife
 	}
 
-	// Calcular score de acción
-	actionScore := a.getActionScore(s.Action)
-
-	// Score final con pesos: 50% price change, 30% rating, 20% action
-	finalScore := (priceChange * 0.5) + (ratingScore * 0.3) + (actionScore * 0.2)
-
-	return NewRecommendation(s, finalScore, priceChange, ratingScore, actionScore)
-}
+	b := a.stockService.CalculateScoreBreakdown(s)
+	if criteria.Weights != nil || criteria.HalfLife != nil {
+		b.Decay = scoring.Decay(b.Age, halfLife)
+		b.Total = weights.Combine(b.PriceChange, b.RatingScore, b.ActionScore) * b.Decay
+	}
 
-// getRatingScore retorna un score numérico para el rating
-func (a *RecommendationAlgorithm) getRatingScore(rating stock.Rating) float64 {
-	switch rating {
-	case stock.RatingStrongBuy:
-		return 5
-	case stock.RatingBuy:
-		return 3
-	case stock.RatingSpeculativeBuy:
-		return 2
-	case stock.RatingMarketPerform:
-		return 1
-	default:
-		return 0
+	rec := NewRecommendation(s, b.Total, b.PriceChange, b.RatingScore, b.ActionScore)
+	rec.DecayFactor = b.Decay
+	if criteria.Reliability != nil {
+		rec.Reliability = criteria.Reliability.Weight(s.Brokerage)
+		rec.Score *= rec.Reliability
 	}
-}
 
-// getActionScore retorna un score numérico para la acción
-func (a *RecommendationAlgorithm) getActionScore(action string) float64 {
-	switch action {
-	case "target raised by", "target raised":
-		return 3
-	case "target lowered by", "target lowered":
-		return -2
-	case "initiated coverage":
-		return 1
-	default:
-		return 0
+	// Explicación: el bonus por upgrade se separa del score de rating
+	bonus := 0.0
+	if b.Upgrade {
+		bonus = model.UpgradeBonus
+	}
+	upgradeReason := fmt.Sprintf("Sin mejora de rating (%s → %s)", s.RatingFrom, s.RatingTo)
+	if b.Upgrade {
+		upgradeReason = fmt.Sprintf("Mejora de rating de %s a %s", s.RatingFrom, s.RatingTo)
 	}
+	rec.Explanation = withMultipliers([]Factor{
+		additiveFactor(FactorPriceChange, b.PriceChange, weights.PriceChange,
+			fmt.Sprintf("El precio objetivo pasó de %s a %s (%+.2f%%)", s.TargetFrom, s.TargetTo, b.PriceChange)),
+		additiveFactor(FactorRating, b.RatingScore-bonus, weights.Rating,
+			fmt.Sprintf("Rating %s de %s", s.RatingTo, s.Brokerage)),
+		additiveFactor(FactorUpgradeBonus, bonus, weights.Rating, upgradeReason),
+		additiveFactor(FactorAction, b.ActionScore, weights.Action,
+			fmt.Sprintf("Acción %q", s.Action)),
+	},
+		b.Decay, decayReason(b.Age, halfLife),
+		rec.Reliability, reliabilityReason(s.Brokerage, criteria.Reliability != nil, rec.Reliability),
+	)
+
+	return rec
 }
@@ -2,8 +2,12 @@ package recommendation
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
 
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 	"github.com/stretchr/testify/assert"
 )
@@ -190,3 +194,139 @@ func createTestStock(
 	
 	return s
 }
+
+func TestRecommendationAlgorithm_CalculateWithCriteria(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+
+	aapl := createTestStock("AAPL", 100.0, 150.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	msft := createTestStock("MSFT", 100.0, 105.0, stock.RatingBuy, stock.RatingStrongBuy, "target raised by")
+	msft.Brokerage = "Other Brokerage"
+	nflx := createTestStock("NFLX", 100.0, 130.0, stock.RatingBuy, stock.RatingNeutral, "target raised by")
+	stocks := []*stock.Stock{aapl, msft, nflx}
+
+	minChange := 10.0
+
+	tests := []struct {
+		name            string
+		criteria        Criteria
+		expectedTickers []string
+	}{
+		{
+			name:            "default criteria",
+			criteria:        Criteria{},
+			expectedTickers: []string{"AAPL", "MSFT"},
+		},
+		{
+			name:            "rating weight only",
+			criteria:        Criteria{Weights: &scoring.Weights{Rating: 1}},
+			expectedTickers: []string{"MSFT", "AAPL"},
+		},
+		{
+			name:            "minimum price change",
+			criteria:        Criteria{MinPriceChange: &minChange},
+			expectedTickers: []string{"AAPL"},
+		},
+		{
+			name:            "allowed ratings include neutral",
+			criteria:        Criteria{Ratings: []stock.Rating{stock.RatingNeutral}},
+			expectedTickers: []string{"NFLX"},
+		},
+		{
+			name:            "allowed brokerages",
+			criteria:        Criteria{Brokerages: []string{"other brokerage"}},
+			expectedTickers: []string{"MSFT"},
+		},
+		{
+			name:            "excluded tickers",
+			criteria:        Criteria{ExcludedTickers: []string{"aapl"}},
+			expectedTickers: []string{"MSFT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks, tt.criteria, 10)
+			assert.NoError(t, err)
+
+			tickers := make([]string, len(recommendations))
+			for i, rec := range recommendations {
+				tickers[i] = rec.Stock.Ticker
+			}
+			assert.Equal(t, tt.expectedTickers, tickers)
+		})
+	}
+
+	t.Run("invalid criteria", func(t *testing.T) {
+		invalid := []Criteria{
+			{Weights: &scoring.Weights{}},
+			{Weights: &scoring.Weights{PriceChange: -1, Rating: 1}},
+			{Ratings: []stock.Rating{"Great"}},
+			{Brokerages: []string{" "}},
+		}
+		for _, c := range invalid {
+			_, err := algorithm.CalculateWithCriteria(context.Background(), stocks, c, 10)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestRecommendationAlgorithm_RecencyDecay(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+
+	fresh := createTestStock("FRESH", 100.0, 120.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	stale := createTestStock("STALE", 100.0, 130.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	stale.EventTime = time.Now().Add(-180 * 24 * time.Hour)
+	stocks := []*stock.Stock{stale, fresh}
+
+	t.Run("older calls weigh less", func(t *testing.T) {
+		recommendations, err := algorithm.CalculateRecommendations(context.Background(), stocks, 10)
+		assert.NoError(t, err)
+		assert.Len(t, recommendations, 2)
+		assert.Equal(t, "FRESH", recommendations[0].Stock.Ticker)
+		assert.InDelta(t, 1.0, recommendations[0].DecayFactor, 0.001)
+		// Dos vidas medias del modelo por defecto (90 días)
+		assert.InDelta(t, 0.25, recommendations[1].DecayFactor, 0.001)
+	})
+
+	t.Run("half-life per query", func(t *testing.T) {
+		noDecay := time.Duration(0)
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks, Criteria{HalfLife: &noDecay}, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, "STALE", recommendations[0].Stock.Ticker)
+		assert.Equal(t, 1.0, recommendations[0].DecayFactor)
+
+		longHalfLife := 360 * 24 * time.Hour
+		recommendations, err = algorithm.CalculateWithCriteria(context.Background(), stocks, Criteria{HalfLife: &longHalfLife}, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, "STALE", recommendations[0].Stock.Ticker)
+		assert.InDelta(t, math.Sqrt(0.5), recommendations[0].DecayFactor, 0.001)
+	})
+
+	t.Run("negative half-life is invalid", func(t *testing.T) {
+		negative := -time.Hour
+		_, err := algorithm.CalculateWithCriteria(context.Background(), stocks, Criteria{HalfLife: &negative}, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestRecommendationAlgorithm_BrokerageReliability(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+
+	reliable := createTestStock("AAPL", 100.0, 120.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	reliable.Brokerage = "Reliable Broker"
+	unreliable := createTestStock("MSFT", 100.0, 125.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	unreliable.Brokerage = "Unreliable Broker"
+	stocks := []*stock.Stock{reliable, unreliable}
+
+	recommendations, err := algorithm.CalculateRecommendations(context.Background(), stocks, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "MSFT", recommendations[0].Stock.Ticker)
+	assert.Equal(t, 1.0, recommendations[0].Reliability)
+
+	criteria := Criteria{Reliability: brokerage.Reliability{"reliable broker": 1.3, "unreliable broker": 0.7}}
+	recommendations, err = algorithm.CalculateWithCriteria(context.Background(), stocks, criteria, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "AAPL", recommendations[0].Stock.Ticker)
+	assert.Equal(t, 1.3, recommendations[0].Reliability)
+	assert.Equal(t, 0.7, recommendations[1].Reliability)
+}
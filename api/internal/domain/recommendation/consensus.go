@@ -0,0 +1,374 @@
+package recommendation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// Nombres de los algoritmos de recomendación disponibles
+const (
+	AlgorithmLatest    = "latest"    // Puntúa la última llamada guardada por ticker
+	AlgorithmConsensus = "consensus" // Agrega las llamadas recientes de todos los brokerages
+)
+
+// Consensus resume las llamadas recientes de varios brokerages sobre un ticker.
+// Los precios objetivo usan la última llamada de cada brokerage dentro de la ventana.
+type Consensus struct {
+	Calls            int // Llamadas dentro de la ventana
+	Brokerages       int // Brokerages distintos
+	MeanTarget       float64
+	MedianTarget     float64
+	TargetStdDev     float64
+	TargetDispersion float64 // Coeficiente de variación: desviación estándar / media
+	Upgrades         int
+	Downgrades       int
+	MeanRatingScore  float64
+}
+
+// ConsensusAlgorithm puntúa cada ticker agregando las llamadas recientes de todos los brokerages
+// en lugar de usar solo la última fila guardada en stocks
+type ConsensusAlgorithm struct {
+	stockService stock.Service
+	eventRepo    stock.RatingEventRepository
+	window       time.Duration
+	now          func() time.Time
+}
+
+// NewConsensusAlgorithm crea un algoritmo de consenso que considera las llamadas de la última ventana
+func NewConsensusAlgorithm(stockService stock.Service, eventRepo stock.RatingEventRepository, window time.Duration) Algorithm {
+	return NewConsensusAlgorithmWithClock(stockService, eventRepo, window, time.Now)
+}
+
+// NewConsensusAlgorithmWithClock crea un algoritmo de consenso cuya ventana termina en el momento
+// que indica el reloj (por ejemplo, la fecha simulada de un backtest)
+func NewConsensusAlgorithmWithClock(
+	stockService stock.Service,
+	eventRepo stock.RatingEventRepository,
+	window time.Duration,
+	now func() time.Time,
+) Algorithm {
+	return &ConsensusAlgorithm{
+		stockService: stockService,
+		eventRepo:    eventRepo,
+		window:       window,
+		now:          now,
+	}
+}
+
+// CalculateRecommendations calcula las recomendaciones de consenso con los criterios por defecto
+func (a *ConsensusAlgorithm) CalculateRecommendations(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	limit int,
+) ([]*Recommendation, error) {
+	return a.CalculateWithCriteria(ctx, stocks, Criteria{}, limit)
+}
+
+// CalculateWithCriteria calcula las recomendaciones de consenso de los tickers de stocks.
+// Los filtros de brokerages restringen las llamadas agregadas; el de ratings exige que al menos
+// un brokerage tenga uno de los ratings indicados (por defecto, un rating medio positivo).
+func (a *ConsensusAlgorithm) CalculateWithCriteria(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	criteria Criteria,
+	limit int,
+) ([]*Recommendation, error) {
+	results, err := a.evaluate(ctx, stocks, criteria)
+	if err != nil {
+		return nil, err
+	}
+	recommendations, _ := diversify(rankConsensus(results), criteria.Diversification, limit)
+	return recommendations, nil
+}
+
+// Explain explica el consenso de un ticker y los motivos por los que no aparece en las recomendaciones
+func (a *ConsensusAlgorithm) Explain(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	ticker string,
+	criteria Criteria,
+	limit int,
+) (*Explanation, error) {
+	target := findStock(stocks, ticker)
+	if target == nil {
+		return nil, fmt.Errorf("stock not found: %s", ticker)
+	}
+
+	results, err := a.evaluate(ctx, stocks, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.ticker == target.Ticker {
+			return newExplanation(r.ticker, r.rec, r.exclusions, rankConsensus(results), criteria.Diversification, limit), nil
+		}
+	}
+	return nil, fmt.Errorf("stock not found: %s", ticker)
+}
+
+// consensusResult es el consenso de un ticker junto con los motivos por los que se descarta
+type consensusResult struct {
+	ticker     string
+	rec        *Recommendation // nil si no hay llamadas que agregar
+	exclusions []string
+}
+
+// evaluate calcula el consenso de cada ticker de stocks
+func (a *ConsensusAlgorithm) evaluate(
+	ctx context.Context,
+	stocks []*stock.Stock,
+	criteria Criteria,
+) ([]*consensusResult, error) {
+	if err := criteria.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Paso 1: Candidatos (la fila de stocks aporta los datos de la empresa)
+	candidates := make(map[string]*stock.Stock, len(stocks))
+	tickers := make([]string, 0, len(stocks))
+	for _, s := range stocks {
+		if _, ok := candidates[s.Ticker]; !ok {
+			tickers = append(tickers, s.Ticker)
+		}
+		candidates[s.Ticker] = s
+	}
+	if len(tickers) == 0 {
+		return []*consensusResult{}, nil
+	}
+
+	// Paso 2: Llamadas recientes de los candidatos, agrupadas por ticker
+	events, err := a.eventRepo.FindSince(ctx, tickers, a.now().Add(-a.window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent rating events: %w", err)
+	}
+
+	byTicker := make(map[string][]*stock.RatingEvent)
+	for _, e := range events {
+		if len(criteria.Brokerages) > 0 && !containsFold(criteria.Brokerages, e.Brokerage) {
+			continue
+		}
+		byTicker[e.Ticker] = append(byTicker[e.Ticker], e)
+	}
+
+	// Paso 3: Puntuar el consenso de cada ticker
+	model := a.stockService.ScoringModel()
+	weights := model.Weights
+	if criteria.Weights != nil {
+		weights = *criteria.Weights
+	}
+	halfLife := model.HalfLife()
+	if criteria.HalfLife != nil {
+		halfLife = *criteria.HalfLife
+	}
+	now := a.now()
+
+	results := make([]*consensusResult, 0, len(tickers))
+	for _, ticker := range tickers {
+		r := &consensusResult{ticker: ticker}
+		results = append(results, r)
+
+		if containsFold(criteria.ExcludedTickers, ticker) {
+			r.exclusions = append(r.exclusions, fmt.Sprintf("El ticker %s está excluido", ticker))
+		}
+
+		tickerEvents := byTicker[ticker]
+		if len(tickerEvents) == 0 {
+			r.exclusions = append(r.exclusions, fmt.Sprintf("Sin llamadas de los brokerages permitidos en los últimos %s", formatDays(a.window)))
+			continue
+		}
+
+		var latest []*stock.RatingEvent
+		r.rec, latest = a.score(candidates[ticker], tickerEvents, model, weights, halfLife, now, criteria.Reliability)
+		if reason := a.ratingExclusion(latest, r.rec.Consensus, criteria, model); reason != "" {
+			r.exclusions = append(r.exclusions, reason)
+		}
+		if reason := minPriceChangeExclusion(criteria, r.rec.PriceChange); reason != "" {
+			r.exclusions = append(r.exclusions, reason)
+		}
+	}
+
+	return results, nil
+}
+
+// rankConsensus retorna las recomendaciones no descartadas ordenadas por score descendente (top N)
+func rankConsensus(results []*consensusResult) []*Recommendation {
+	recommendations := make([]*Recommendation, 0, len(results))
+	for _, r := range results {
+		if len(r.exclusions) == 0 {
+			recommendations = append(recommendations, r.rec)
+		}
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+
+	return recommendations
+}
+
+// score agrega las llamadas de un ticker (ordenadas de la más reciente a la más antigua)
+// y retorna la recomendación junto con la última llamada de cada brokerage.
+// El factor de decaimiento es la media del de la última llamada de cada brokerage; si se indica
+// reliability, los componentes se promedian ponderando cada brokerage por su fiabilidad.
+func (a *ConsensusAlgorithm) score(
+	s *stock.Stock,
+	events []*stock.RatingEvent,
+	model *scoring.Model,
+	weights scoring.Weights,
+	halfLife time.Duration,
+	now time.Time,
+	reliability brokerage.Reliability,
+) (*Recommendation, []*stock.RatingEvent) {
+	c := &Consensus{Calls: len(events)}
+
+	// Upgrades y downgrades de todas las llamadas de la ventana
+	for _, e := range events {
+		from, to := e.RatingFrom.String(), e.RatingTo.String()
+		switch {
+		case model.IsUpgrade(from, to):
+			c.Upgrades++
+		case model.IsUpgrade(to, from):
+			c.Downgrades++
+		}
+	}
+
+	// Última llamada de cada brokerage
+	seen := make(map[string]bool)
+	latest := make([]*stock.RatingEvent, 0)
+	for _, e := range events {
+		key := strings.ToLower(strings.TrimSpace(e.Brokerage))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		latest = append(latest, e)
+	}
+	c.Brokerages = len(latest)
+
+	targets := make([]float64, len(latest))
+	var ratingSum, weightedRating, weightedAction, weightedChange, weightedDecay, weightSum, changeWeights float64
+	for i, e := range latest {
+		targets[i] = e.TargetTo.Value()
+		ratingSum += model.RatingScore(e.RatingTo.String())
+
+		w := reliability.Weight(e.Brokerage)
+		weightSum += w
+		weightedRating += w * model.RatingScore(e.RatingTo.String())
+		weightedAction += w * model.ActionScore(e.Action)
+		weightedDecay += w * scoring.Decay(now.Sub(e.EventTime), halfLife)
+		if !e.TargetFrom.IsZero() {
+			weightedChange += w * (e.TargetTo.Value() - e.TargetFrom.Value()) / e.TargetFrom.Value() * 100
+			changeWeights += w
+		}
+	}
+
+	n := float64(len(latest))
+	c.MeanRatingScore = ratingSum / n
+	c.MeanTarget, c.MedianTarget, c.TargetStdDev = describe(targets)
+	if c.MeanTarget != 0 {
+		c.TargetDispersion = c.TargetStdDev / c.MeanTarget
+	}
+
+	priceChange := 0.0
+	if changeWeights > 0 {
+		priceChange = weightedChange / changeWeights
+	}
+	baseRating := weightedRating / weightSum
+	balance := model.UpgradeBonus * float64(c.Upgrades-c.Downgrades) / float64(c.Calls)
+	ratingScore := baseRating + balance
+	actionScore := weightedAction / weightSum
+	decay := weightedDecay / weightSum
+
+	// Como en el algoritmo por última llamada, el score se multiplica por el peso (medio) de fiabilidad
+	weight := weightSum / n
+	total := weights.Combine(priceChange, ratingScore, actionScore) * decay * weight
+
+	rec := NewRecommendation(s, total, priceChange, ratingScore, actionScore)
+	rec.DecayFactor = decay
+	rec.Reliability = weight
+	rec.Explanation = withMultipliers([]Factor{
+		additiveFactor(FactorPriceChange, priceChange, weights.PriceChange,
+			fmt.Sprintf("Cambio medio del precio objetivo de %d brokerages (objetivo medio %.2f)", c.Brokerages, c.MeanTarget)),
+		additiveFactor(FactorRating, baseRating, weights.Rating,
+			fmt.Sprintf("Score medio del rating actual de %d brokerages", c.Brokerages)),
+		additiveFactor(FactorUpgradeBonus, balance, weights.Rating,
+			fmt.Sprintf("%d upgrades y %d downgrades en %d llamadas", c.Upgrades, c.Downgrades, c.Calls)),
+		additiveFactor(FactorAction, actionScore, weights.Action,
+			fmt.Sprintf("Score medio de la acción de %d brokerages", c.Brokerages)),
+	},
+		decay, consensusDecayReason(halfLife),
+		weight, reliabilityReason(fmt.Sprintf("%d brokerages", c.Brokerages), reliability != nil, weight),
+	)
+	rec.Consensus = c
+	return rec, latest
+}
+
+// ratingExclusion aplica el filtro de ratings al consenso de un ticker
+// y retorna el motivo de exclusión (vacío si lo cumple)
+func (a *ConsensusAlgorithm) ratingExclusion(
+	latest []*stock.RatingEvent,
+	c *Consensus,
+	criteria Criteria,
+	model *scoring.Model,
+) string {
+	if len(criteria.Ratings) == 0 {
+		if c.MeanRatingScore > model.RatingScore(stock.RatingNeutral.String()) {
+			return ""
+		}
+		return fmt.Sprintf("El rating medio de los brokerages (%.2f) no es positivo", c.MeanRatingScore)
+	}
+	for _, e := range latest {
+		if containsRating(criteria.Ratings, e.RatingTo) {
+			return ""
+		}
+	}
+	return "Ningún brokerage tiene un rating entre los permitidos"
+}
+
+// consensusDecayReason describe el decaimiento aplicado a un consenso
+func consensusDecayReason(halfLife time.Duration) string {
+	if halfLife <= 0 {
+		return "Sin decaimiento por antigüedad"
+	}
+	return fmt.Sprintf("Decaimiento medio de la última llamada de cada brokerage con una vida media de %s", formatDays(halfLife))
+}
+
+// describe retorna la media, la mediana y la desviación estándar (poblacional) de values
+func describe(values []float64) (mean, median, stdDev float64) {
+	if len(values) == 0 {
+		return 0, 0, 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean = sum / float64(len(sorted))
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	} else {
+		median = sorted[mid]
+	}
+
+	var sq float64
+	for _, v := range sorted {
+		sq += (v - mean) * (v - mean)
+	}
+	stdDev = math.Sqrt(sq / float64(len(sorted)))
+
+	return mean, median, stdDev
+}
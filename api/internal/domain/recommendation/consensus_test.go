@@ -0,0 +1,151 @@
+package recommendation
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEventRepository retorna los eventos en memoria (ya ordenados del más reciente al más antiguo)
+type fakeEventRepository struct {
+	stock.RatingEventRepository
+	events []*stock.RatingEvent
+}
+
+func (f *fakeEventRepository) FindSince(ctx context.Context, tickers []string, since time.Time) ([]*stock.RatingEvent, error) {
+	result := make([]*stock.RatingEvent, 0)
+	for _, e := range f.events {
+		if !e.EventTime.Before(since) && containsFold(tickers, e.Ticker) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func createTestEvent(
+	ticker, brokerage string,
+	targetFrom, targetTo float64,
+	ratingFrom, ratingTo stock.Rating,
+	action string,
+	eventTime time.Time,
+) *stock.RatingEvent {
+	s := createTestStock(ticker, targetFrom, targetTo, ratingFrom, ratingTo, action)
+	s.Brokerage = brokerage
+	s.EventTime = eventTime
+	return stock.NewRatingEventFromStock(s)
+}
+
+func TestConsensusAlgorithm_CalculateWithCriteria(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	repo := &fakeEventRepository{events: []*stock.RatingEvent{
+		createTestEvent("AAPL", "Broker A", 100, 120, stock.RatingNeutral, stock.RatingBuy, "target raised by", now.Add(-1*day)),
+		createTestEvent("AAPL", "Broker B", 100, 140, stock.RatingBuy, stock.RatingStrongBuy, "target raised by", now.Add(-2*day)),
+		createTestEvent("AAPL", "broker a", 110, 100, stock.RatingBuy, stock.RatingNeutral, "target lowered by", now.Add(-3*day)),
+		createTestEvent("AAPL", "Broker C", 100, 10, stock.RatingBuy, stock.RatingSell, "target lowered by", now.Add(-60*day)),
+		createTestEvent("NFLX", "Broker A", 100, 80, stock.RatingBuy, stock.RatingSell, "target lowered by", now.Add(-1*day)),
+	}}
+
+	algorithm := NewConsensusAlgorithm(stock.NewDomainService(), repo, 30*day).(*ConsensusAlgorithm)
+	algorithm.now = func() time.Time { return now }
+
+	stocks := []*stock.Stock{
+		createTestStock("AAPL", 100, 120, stock.RatingNeutral, stock.RatingBuy, "target raised by"),
+		createTestStock("NFLX", 100, 80, stock.RatingBuy, stock.RatingSell, "target lowered by"),
+		createTestStock("MSFT", 100, 110, stock.RatingBuy, stock.RatingBuy, "target raised by"),
+	}
+
+	t.Run("aggregates recent calls across brokerages", func(t *testing.T) {
+		recommendations, err := algorithm.CalculateRecommendations(context.Background(), stocks, 10)
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+
+		rec := recommendations[0]
+		assert.Equal(t, "AAPL", rec.Stock.Ticker)
+		require.NotNil(t, rec.Consensus)
+
+		c := rec.Consensus
+		assert.Equal(t, 3, c.Calls)
+		assert.Equal(t, 2, c.Brokerages)
+		assert.InDelta(t, 130.0, c.MeanTarget, 1e-9)
+		assert.InDelta(t, 130.0, c.MedianTarget, 1e-9)
+		assert.InDelta(t, 10.0, c.TargetStdDev, 1e-9)
+		assert.InDelta(t, 10.0/130.0, c.TargetDispersion, 1e-9)
+		assert.Equal(t, 2, c.Upgrades)
+		assert.Equal(t, 1, c.Downgrades)
+		assert.InDelta(t, 4.0, c.MeanRatingScore, 1e-9)
+
+		assert.InDelta(t, 30.0, rec.PriceChange, 1e-9)
+		assert.InDelta(t, 4.0+2.0/3.0, rec.RatingScore, 1e-9)
+		assert.InDelta(t, 3.0, rec.ActionScore, 1e-9)
+
+		// Decaimiento medio de las últimas llamadas de Broker A (1 día) y Broker B (2 días)
+		decay := (math.Pow(0.5, 1.0/90.0) + math.Pow(0.5, 2.0/90.0)) / 2
+		assert.InDelta(t, decay, rec.DecayFactor, 1e-9)
+		assert.InDelta(t, (0.5*30.0+0.3*(4.0+2.0/3.0)+0.2*3.0)*decay, rec.Score, 1e-9)
+	})
+
+	t.Run("brokerage filter restricts aggregated calls", func(t *testing.T) {
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks,
+			Criteria{Brokerages: []string{"broker b"}}, 10)
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+		assert.Equal(t, 1, recommendations[0].Consensus.Calls)
+		assert.InDelta(t, 140.0, recommendations[0].Consensus.MeanTarget, 1e-9)
+	})
+
+	t.Run("brokerage reliability weights calls", func(t *testing.T) {
+		reliability := brokerage.Reliability{"broker a": 0.5, "broker b": 1.5}
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks,
+			Criteria{Reliability: reliability}, 10)
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+
+		rec := recommendations[0]
+		assert.InDelta(t, 1.0, rec.Reliability, 1e-9)
+		// (0.5 * 20% + 1.5 * 40%) / 2
+		assert.InDelta(t, 35.0, rec.PriceChange, 1e-9)
+		// Las estadísticas del consenso no se ponderan
+		assert.InDelta(t, 4.0, rec.Consensus.MeanRatingScore, 1e-9)
+	})
+
+	t.Run("rating filter matches any brokerage", func(t *testing.T) {
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks,
+			Criteria{Ratings: []stock.Rating{stock.RatingSell}}, 10)
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+		assert.Equal(t, "NFLX", recommendations[0].Stock.Ticker)
+	})
+
+	t.Run("excluded tickers and minimum price change", func(t *testing.T) {
+		minChange := 50.0
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks,
+			Criteria{MinPriceChange: &minChange}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, recommendations)
+
+		recommendations, err = algorithm.CalculateWithCriteria(context.Background(), stocks,
+			Criteria{ExcludedTickers: []string{"aapl"}}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, recommendations)
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	mean, median, stdDev := describe([]float64{4, 1, 3, 2})
+	assert.InDelta(t, 2.5, mean, 1e-9)
+	assert.InDelta(t, 2.5, median, 1e-9)
+	assert.InDelta(t, 1.118033988, stdDev, 1e-6)
+
+	mean, median, stdDev = describe(nil)
+	assert.Zero(t, mean)
+	assert.Zero(t, median)
+	assert.Zero(t, stdDev)
+}
@@ -0,0 +1,109 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// Criteria contiene los parámetros ajustables de un cálculo de recomendaciones.
+// El valor cero reproduce el comportamiento por defecto.
+type Criteria struct {
+	Weights         *scoring.Weights      // nil usa los pesos del modelo de scoring
+	MinPriceChange  *float64              // Cambio porcentual mínimo del precio objetivo; nil no filtra
+	Ratings         []stock.Rating        // Ratings permitidos; vacío usa los ratings positivos
+	Brokerages      []string              // Brokerages permitidos (sin distinguir mayúsculas); vacío permite todos
+	ExcludedTickers []string              // Tickers excluidos (sin distinguir mayúsculas)
+	HalfLife        *time.Duration        // Vida media del decaimiento por antigüedad; nil usa la del modelo y 0 lo desactiva
+	Reliability     brokerage.Reliability // Pondera las llamadas por la fiabilidad del brokerage; nil no pondera
+	Diversification Diversification       // Restricciones de la selección final; el valor cero toma las N primeras
+}
+
+// Validate verifica que los criterios sean coherentes
+func (c Criteria) Validate() error {
+	if c.Weights != nil {
+		if err := c.Weights.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.MinPriceChange != nil && (math.IsNaN(*c.MinPriceChange) || math.IsInf(*c.MinPriceChange, 0)) {
+		return fmt.Errorf("invalid minPriceChange: %v", *c.MinPriceChange)
+	}
+	if c.HalfLife != nil && *c.HalfLife < 0 {
+		return fmt.Errorf("invalid halfLife: %s", *c.HalfLife)
+	}
+	if err := c.Diversification.Validate(); err != nil {
+		return err
+	}
+	for _, r := range c.Ratings {
+		if !r.IsValid() {
+			return fmt.Errorf("invalid rating: %s", r)
+		}
+	}
+	for _, b := range c.Brokerages {
+		if strings.TrimSpace(b) == "" {
+			return fmt.Errorf("brokerage cannot be empty")
+		}
+	}
+	for _, t := range c.ExcludedTickers {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("excluded ticker cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Matches retorna true si el stock cumple los filtros de los criterios
+// (el cambio de precio mínimo se evalúa aparte, sobre el score calculado)
+func (c Criteria) Matches(s *stock.Stock) bool {
+	return len(c.Exclusions(s)) == 0
+}
+
+// Exclusions retorna los motivos por los que el stock no cumple los filtros de los criterios
+func (c Criteria) Exclusions(s *stock.Stock) []string {
+	var reasons []string
+
+	if len(c.Ratings) == 0 {
+		if !s.RatingTo.IsPositive() {
+			reasons = append(reasons, fmt.Sprintf("El rating %s no es positivo", s.RatingTo))
+		}
+	} else if !containsRating(c.Ratings, s.RatingTo) {
+		reasons = append(reasons, fmt.Sprintf("El rating %s no está entre los permitidos", s.RatingTo))
+	}
+
+	if len(c.Brokerages) > 0 && !containsFold(c.Brokerages, s.Brokerage) {
+		reasons = append(reasons, fmt.Sprintf("El brokerage %s no está entre los permitidos", s.Brokerage))
+	}
+
+	if containsFold(c.ExcludedTickers, s.Ticker) {
+		reasons = append(reasons, fmt.Sprintf("El ticker %s está excluido", s.Ticker))
+	}
+
+	return reasons
+}
+
+// containsRating retorna true si rating está en ratings
+func containsRating(ratings []stock.Rating, rating stock.Rating) bool {
+	for _, r := range ratings {
+		if r == rating {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold retorna true si value está en values sin distinguir mayúsculas ni espacios extremos
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package recommendation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+)
+
+// Diversification son las restricciones que se aplican al seleccionar las recomendaciones
+// una vez ordenadas por score. El valor cero toma simplemente las N primeras.
+type Diversification struct {
+	MaxPerBrokerage int // Máximo de recomendaciones de un mismo brokerage; 0 sin límite
+	MaxPerCompany   int // Máximo de recomendaciones de una misma empresa (p. ej. GOOG y GOOGL); 0 sin límite
+	MinBrokerages   int // Mínimo de brokerages distintos en la lista, si hay suficientes candidatos; 0 sin mínimo
+}
+
+// Validate verifica que las restricciones no sean negativas
+func (d Diversification) Validate() error {
+	if d.MaxPerBrokerage < 0 {
+		return fmt.Errorf("invalid maxPerBrokerage: %d", d.MaxPerBrokerage)
+	}
+	if d.MaxPerCompany < 0 {
+		return fmt.Errorf("invalid maxPerCompany: %d", d.MaxPerCompany)
+	}
+	if d.MinBrokerages < 0 {
+		return fmt.Errorf("invalid minBrokerages: %d", d.MinBrokerages)
+	}
+	return nil
+}
+
+// diversify selecciona hasta limit recomendaciones de ranked (ordenadas por score descendente)
+// respetando las restricciones. Retorna la selección ordenada por score y el motivo por el que
+// se descartó cada recomendación que se evaluó y no entró.
+//
+// Los máximos por brokerage y por empresa son estrictos. El mínimo de brokerages distintos
+// reserva los últimos puestos para brokerages que aún no aparecen; si no hay suficientes,
+// los puestos reservados se completan con los candidatos descartados por la reserva.
+func diversify(ranked []*Recommendation, d Diversification, limit int) ([]*Recommendation, map[*Recommendation]string) {
+	reasons := make(map[*Recommendation]string)
+	if d == (Diversification{}) {
+		if len(ranked) > limit {
+			return ranked[:limit], reasons
+		}
+		return ranked, reasons
+	}
+
+	selected := make([]*Recommendation, 0, limit)
+	perBrokerage := make(map[string]int)
+	perCompany := make(map[string]int)
+
+	// capReason retorna el motivo si rec supera un máximo por brokerage o empresa
+	capReason := func(rec *Recommendation) string {
+		b, c := brokerage.NormalizeName(rec.Stock.Brokerage), companyKey(rec)
+		if d.MaxPerBrokerage > 0 && perBrokerage[b] >= d.MaxPerBrokerage {
+			return fmt.Sprintf("Ya hay %d recomendaciones de %s (máximo por brokerage)", perBrokerage[b], rec.Stock.Brokerage)
+		}
+		if d.MaxPerCompany > 0 && perCompany[c] >= d.MaxPerCompany {
+			return fmt.Sprintf("Ya hay %d recomendaciones de la empresa %s (máximo por empresa)", perCompany[c], rec.Stock.CompanyName)
+		}
+		return ""
+	}
+	add := func(rec *Recommendation) {
+		selected = append(selected, rec)
+		perBrokerage[brokerage.NormalizeName(rec.Stock.Brokerage)]++
+		perCompany[companyKey(rec)]++
+		delete(reasons, rec)
+	}
+
+	deferred := make([]*Recommendation, 0)
+	for _, rec := range ranked {
+		if len(selected) >= limit {
+			break
+		}
+		if reason := capReason(rec); reason != "" {
+			reasons[rec] = reason
+			continue
+		}
+
+		// Reservar los puestos restantes para brokerages que aún no aparecen
+		missing := d.MinBrokerages - len(perBrokerage)
+		if perBrokerage[brokerage.NormalizeName(rec.Stock.Brokerage)] > 0 && missing > 0 && limit-len(selected) <= missing {
+			reasons[rec] = fmt.Sprintf("Puesto reservado para alcanzar %d brokerages distintos", d.MinBrokerages)
+			deferred = append(deferred, rec)
+			continue
+		}
+
+		add(rec)
+	}
+
+	// No hay suficientes brokerages distintos: completar con los candidatos aplazados
+	for _, rec := range deferred {
+		if len(selected) >= limit {
+			break
+		}
+		if capReason(rec) == "" {
+			add(rec)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Score > selected[j].Score
+	})
+
+	return selected, reasons
+}
+
+// companySuffixes son las palabras finales que no distinguen a una empresa de sus otras clases de acciones
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"ltd": true, "limited": true, "plc": true, "llc": true, "lp": true, "sa": true, "ag": true, "nv": true,
+	"holdings": true, "group": true, "class": true, "a": true, "b": true, "c": true,
+	"common": true, "stock": true, "shares": true, "ordinary": true, "adr": true,
+}
+
+// companyKey normaliza el nombre de la empresa de una recomendación para agrupar sus distintos tickers
+// ("Alphabet Inc. Class A" y "Alphabet Inc. Class C" comparten clave); sin nombre, usa el ticker
+func companyKey(rec *Recommendation) string {
+	words := strings.FieldsFunc(strings.ToLower(rec.Stock.CompanyName), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for len(words) > 1 && companySuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return "ticker:" + strings.ToUpper(rec.Stock.Ticker)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,97 @@
+package recommendation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createDiversifiedStock(ticker, company, brokerage string, targetTo float64) *stock.Stock {
+	s := createTestStock(ticker, 100, targetTo, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	s.CompanyName = company
+	s.Brokerage = brokerage
+	return s
+}
+
+func diversificationTestStocks() []*stock.Stock {
+	return []*stock.Stock{
+		createDiversifiedStock("GOOGL", "Alphabet Inc. Class A", "Broker A", 200),
+		createDiversifiedStock("GOOG", "Alphabet Inc. Class C", "Broker A", 190),
+		createDiversifiedStock("MSFT", "Microsoft Corporation", "broker a", 180),
+		createDiversifiedStock("AAPL", "Apple Inc.", "Broker B", 170),
+		createDiversifiedStock("NVDA", "NVIDIA Corp", "Broker C", 110),
+	}
+}
+
+func tickers(recommendations []*Recommendation) []string {
+	result := make([]string, len(recommendations))
+	for i, r := range recommendations {
+		result[i] = r.Stock.Ticker
+	}
+	return result
+}
+
+func TestCalculateWithCriteria_Diversification(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		diversification Diversification
+		limit           int
+		expected        []string
+	}{
+		{"sin restricciones", Diversification{}, 3, []string{"GOOGL", "GOOG", "MSFT"}},
+		{"máximo por brokerage", Diversification{MaxPerBrokerage: 1}, 3, []string{"GOOGL", "AAPL", "NVDA"}},
+		{"máximo por empresa", Diversification{MaxPerCompany: 1}, 3, []string{"GOOGL", "MSFT", "AAPL"}},
+		{"mínimo de brokerages", Diversification{MinBrokerages: 3}, 3, []string{"GOOGL", "AAPL", "NVDA"}},
+		{"mínimo de brokerages inalcanzable", Diversification{MinBrokerages: 5}, 4, []string{"GOOGL", "GOOG", "AAPL", "NVDA"}},
+		{"restricciones combinadas", Diversification{MaxPerBrokerage: 2, MaxPerCompany: 1}, 4, []string{"GOOGL", "MSFT", "AAPL", "NVDA"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := algorithm.CalculateWithCriteria(ctx, diversificationTestStocks(), Criteria{Diversification: tt.diversification}, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tickers(recs))
+		})
+	}
+}
+
+func TestCalculateWithCriteria_InvalidDiversification(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+
+	_, err := algorithm.CalculateWithCriteria(context.Background(), diversificationTestStocks(),
+		Criteria{Diversification: Diversification{MaxPerBrokerage: -1}}, 3)
+	assert.Error(t, err)
+}
+
+func TestExplain_Diversification(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+	criteria := Criteria{Diversification: Diversification{MaxPerBrokerage: 1}}
+
+	explanation, err := algorithm.Explain(context.Background(), diversificationTestStocks(), "GOOG", criteria, 3)
+	require.NoError(t, err)
+	assert.False(t, explanation.Included)
+	assert.Equal(t, 2, explanation.Rank)
+	require.Len(t, explanation.Exclusions, 1)
+	assert.Contains(t, explanation.Exclusions[0], "máximo por brokerage")
+
+	explanation, err = algorithm.Explain(context.Background(), diversificationTestStocks(), "NVDA", criteria, 3)
+	require.NoError(t, err)
+	assert.True(t, explanation.Included)
+	assert.Equal(t, 5, explanation.Rank)
+}
+
+func TestCompanyKey(t *testing.T) {
+	a := &Recommendation{Stock: &stock.Stock{Ticker: "GOOGL", CompanyName: "Alphabet Inc. Class A"}}
+	c := &Recommendation{Stock: &stock.Stock{Ticker: "GOOG", CompanyName: "Alphabet Inc. (Class C)"}}
+	empty := &Recommendation{Stock: &stock.Stock{Ticker: "xyz"}}
+
+	assert.Equal(t, "alphabet", companyKey(a))
+	assert.Equal(t, companyKey(a), companyKey(c))
+	assert.Equal(t, "ticker:XYZ", companyKey(empty))
+}
@@ -11,6 +11,10 @@ type Recommendation struct {
 	PriceChange float64
 	RatingScore float64
 	ActionScore float64
+	DecayFactor float64    // Factor por antigüedad de la llamada ya aplicado a Score (1 = sin decaimiento)
+	Reliability float64    // Peso por fiabilidad del brokerage ya aplicado a Score (1 = sin ponderar)
+	Consensus   *Consensus // Solo en recomendaciones de consenso
+	Explanation []Factor   // Aporte de cada factor al score
 }
 
 // NewRecommendation crea una nueva recomendación
@@ -27,5 +31,7 @@ func NewRecommendation(
 		PriceChange: priceChange,
 		RatingScore: ratingScore,
 		ActionScore: actionScore,
+		DecayFactor: 1,
+		Reliability: 1,
 	}
 }
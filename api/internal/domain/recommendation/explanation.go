@@ -0,0 +1,159 @@
+package recommendation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+)
+
+// Nombres de los factores de una explicación
+const (
+	FactorPriceChange  = "priceChange"
+	FactorRating       = "rating"
+	FactorUpgradeBonus = "upgradeBonus"
+	FactorAction       = "action"
+	FactorDecay        = "decay"
+	FactorReliability  = "reliability"
+)
+
+// Factor es un componente del score de una recomendación.
+// La suma de los aportes de todos los factores es el score final.
+type Factor struct {
+	Name         string
+	Value        float64 // Valor crudo; en los factores multiplicativos (decay, reliability) es el multiplicador
+	Weight       float64 // Peso del modelo; 1 en los factores multiplicativos
+	Contribution float64 // Aporte al score
+	Reason       string
+}
+
+// Explanation explica por qué un stock aparece o no en las recomendaciones
+type Explanation struct {
+	Ticker         string
+	Included       bool
+	Rank           int // Posición por score entre los stocks que pasan los filtros; 0 si no los pasa
+	Exclusions     []string
+	Recommendation *Recommendation // nil si no se pudo puntuar
+}
+
+// additiveFactor crea un factor que se suma al score con su peso
+func additiveFactor(name string, value, weight float64, reason string) Factor {
+	return Factor{
+		Name:         name,
+		Value:        value,
+		Weight:       weight,
+		Contribution: value * weight,
+		Reason:       reason,
+	}
+}
+
+// withMultipliers agrega los factores de decaimiento y fiabilidad; su aporte es
+// lo que cada multiplicador cambia el score acumulado hasta ese momento
+func withMultipliers(factors []Factor, decay float64, decayReason string, reliability float64, reliabilityReason string) []Factor {
+	var base float64
+	for _, f := range factors {
+		base += f.Contribution
+	}
+
+	decayed := base * decay
+	return append(factors,
+		Factor{Name: FactorDecay, Value: decay, Weight: 1, Contribution: decayed - base, Reason: decayReason},
+		Factor{Name: FactorReliability, Value: reliability, Weight: 1, Contribution: decayed*reliability - decayed, Reason: reliabilityReason},
+	)
+}
+
+// decayReason describe el decaimiento aplicado a una llamada de la antigüedad indicada
+func decayReason(age, halfLife time.Duration) string {
+	if halfLife <= 0 {
+		return "Sin decaimiento por antigüedad"
+	}
+	return fmt.Sprintf("Llamada de hace %s con una vida media de %s", formatDays(age), formatDays(halfLife))
+}
+
+// reliabilityReason describe el peso de fiabilidad aplicado a un brokerage
+func reliabilityReason(brokerage string, weighted bool, weight float64) string {
+	if !weighted {
+		return "Sin ponderar por la fiabilidad del brokerage"
+	}
+	return fmt.Sprintf("Peso %.2f por el historial de aciertos de %s", weight, brokerage)
+}
+
+// formatDays formatea una duración en días
+func formatDays(d time.Duration) string {
+	days := d.Hours() / 24
+	if days == 1 {
+		return "1 día"
+	}
+	return fmt.Sprintf("%.0f días", days)
+}
+
+// minPriceChangeExclusion retorna el motivo de exclusión por cambio de precio mínimo (vacío si lo cumple)
+func minPriceChangeExclusion(criteria Criteria, priceChange float64) string {
+	if criteria.MinPriceChange == nil || priceChange >= *criteria.MinPriceChange {
+		return ""
+	}
+	return fmt.Sprintf("El cambio del precio objetivo (%.2f%%) es menor que el mínimo (%.2f%%)",
+		priceChange, *criteria.MinPriceChange)
+}
+
+// newExplanation construye la explicación de rec a partir de sus motivos de exclusión,
+// de la lista completa ordenada de recomendaciones y de la selección diversificada de limit
+func newExplanation(
+	ticker string,
+	rec *Recommendation,
+	exclusions []string,
+	ranked []*Recommendation,
+	diversification Diversification,
+	limit int,
+) *Explanation {
+	e := &Explanation{
+		Ticker:         ticker,
+		Exclusions:     exclusions,
+		Recommendation: rec,
+	}
+	if len(exclusions) == 0 {
+		var candidate *Recommendation
+		for i, r := range ranked {
+			if strings.EqualFold(r.Stock.Ticker, ticker) {
+				e.Rank = i + 1
+				candidate = r
+				break
+			}
+		}
+
+		selected, reasons := diversify(ranked, diversification, limit)
+		if !containsRecommendation(selected, candidate) {
+			if reason, ok := reasons[candidate]; ok {
+				e.Exclusions = append(e.Exclusions, reason)
+			} else {
+				e.Exclusions = append(e.Exclusions, fmt.Sprintf("Ocupa el puesto %d, fuera de los %d primeros", e.Rank, limit))
+			}
+		}
+	}
+	if e.Exclusions == nil {
+		e.Exclusions = []string{}
+	}
+	e.Included = len(e.Exclusions) == 0
+	return e
+}
+
+// containsRecommendation retorna true si rec está en recommendations
+func containsRecommendation(recommendations []*Recommendation, rec *Recommendation) bool {
+	for _, r := range recommendations {
+		if r == rec {
+			return true
+		}
+	}
+	return false
+}
+
+// findStock busca un stock por ticker sin distinguir mayúsculas
+func findStock(stocks []*stock.Stock, ticker string) *stock.Stock {
+	for _, s := range stocks {
+		if strings.EqualFold(s.Ticker, strings.TrimSpace(ticker)) {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package recommendation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/brokerage"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sumContributions suma los aportes de los factores de una recomendación
+func sumContributions(factors []Factor) float64 {
+	var total float64
+	for _, f := range factors {
+		total += f.Contribution
+	}
+	return total
+}
+
+func TestRecommendationAlgorithm_Explanation(t *testing.T) {
+	algorithm := NewRecommendationAlgorithm(stock.NewDomainService())
+
+	aapl := createTestStock("AAPL", 100.0, 150.0, stock.RatingNeutral, stock.RatingStrongBuy, "target raised by")
+	aapl.EventTime = time.Now().Add(-45 * 24 * time.Hour)
+	msft := createTestStock("MSFT", 100.0, 110.0, stock.RatingBuy, stock.RatingBuy, "target raised by")
+	nflx := createTestStock("NFLX", 100.0, 90.0, stock.RatingBuy, stock.RatingNeutral, "target lowered by")
+	stocks := []*stock.Stock{aapl, msft, nflx}
+
+	criteria := Criteria{Reliability: brokerage.Reliability{"test brokerage": 1.2}}
+
+	t.Run("factors add up to the score", func(t *testing.T) {
+		recommendations, err := algorithm.CalculateWithCriteria(context.Background(), stocks, criteria, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, recommendations)
+
+		for _, rec := range recommendations {
+			require.Len(t, rec.Explanation, 6)
+			assert.InDelta(t, rec.Score, sumContributions(rec.Explanation), 1e-9)
+		}
+
+		factors := recommendations[0].Explanation
+		assert.Equal(t, FactorPriceChange, factors[0].Name)
+		assert.Equal(t, 50.0, factors[0].Value)
+		assert.Equal(t, 0.5, factors[0].Weight)
+		assert.Equal(t, 25.0, factors[0].Contribution)
+		assert.Equal(t, FactorUpgradeBonus, factors[2].Name)
+		assert.Equal(t, 2.0, factors[2].Value)
+		assert.Contains(t, factors[2].Reason, "Neutral")
+		assert.Equal(t, FactorDecay, factors[4].Name)
+		assert.Contains(t, factors[4].Reason, "45 días")
+		assert.Equal(t, 1.2, factors[5].Value)
+	})
+
+	t.Run("included stock", func(t *testing.T) {
+		explanation, err := algorithm.Explain(context.Background(), stocks, "msft", criteria, 10)
+		require.NoError(t, err)
+		assert.True(t, explanation.Included)
+		assert.Equal(t, "MSFT", explanation.Ticker)
+		assert.Equal(t, 2, explanation.Rank)
+		assert.Empty(t, explanation.Exclusions)
+	})
+
+	t.Run("filtered out stock", func(t *testing.T) {
+		minChange := 5.0
+		explanation, err := algorithm.Explain(context.Background(), stocks, "NFLX", Criteria{MinPriceChange: &minChange}, 10)
+		require.NoError(t, err)
+		assert.False(t, explanation.Included)
+		assert.Zero(t, explanation.Rank)
+		assert.Len(t, explanation.Exclusions, 2)
+		assert.Contains(t, explanation.Exclusions[0], "no es positivo")
+		assert.Contains(t, explanation.Exclusions[1], "menor que el mínimo")
+		require.NotNil(t, explanation.Recommendation)
+		assert.NotEmpty(t, explanation.Recommendation.Explanation)
+	})
+
+	t.Run("ranked beyond the limit", func(t *testing.T) {
+		explanation, err := algorithm.Explain(context.Background(), stocks, "MSFT", criteria, 1)
+		require.NoError(t, err)
+		assert.False(t, explanation.Included)
+		assert.Equal(t, 2, explanation.Rank)
+		assert.Equal(t, []string{"Ocupa el puesto 2, fuera de los 1 primeros"}, explanation.Exclusions)
+	})
+
+	t.Run("unknown ticker", func(t *testing.T) {
+		_, err := algorithm.Explain(context.Background(), stocks, "TSLA", criteria, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestConsensusAlgorithm_Explain(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeEventRepository{events: []*stock.RatingEvent{
+		createTestEvent("AAPL", "Broker A", 100, 120, stock.RatingNeutral, stock.RatingBuy, "target raised by", now.Add(-24*time.Hour)),
+	}}
+
+	algorithm := NewConsensusAlgorithm(stock.NewDomainService(), repo, 30*24*time.Hour).(*ConsensusAlgorithm)
+	algorithm.now = func() time.Time { return now }
+
+	stocks := []*stock.Stock{
+		createTestStock("AAPL", 100, 120, stock.RatingNeutral, stock.RatingBuy, "target raised by"),
+		createTestStock("MSFT", 100, 110, stock.RatingBuy, stock.RatingBuy, "target raised by"),
+	}
+
+	explanation, err := algorithm.Explain(context.Background(), stocks, "AAPL", Criteria{}, 10)
+	require.NoError(t, err)
+	assert.True(t, explanation.Included)
+	assert.Equal(t, 1, explanation.Rank)
+	assert.InDelta(t, explanation.Recommendation.Score, sumContributions(explanation.Recommendation.Explanation), 1e-9)
+
+	explanation, err = algorithm.Explain(context.Background(), stocks, "MSFT", Criteria{}, 10)
+	require.NoError(t, err)
+	assert.False(t, explanation.Included)
+	assert.Nil(t, explanation.Recommendation)
+	assert.Equal(t, []string{"Sin llamadas de los brokerages permitidos en los últimos 30 días"}, explanation.Exclusions)
+}
@@ -0,0 +1,214 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Weights son los pesos de cada componente del score de recomendación
+type Weights struct {
+	PriceChange float64 `json:"priceChange"`
+	Rating      float64 `json:"rating"`
+	Action      float64 `json:"action"`
+}
+
+// Combine aplica los pesos a los componentes de un score
+func (w Weights) Combine(priceChange, ratingScore, actionScore float64) float64 {
+	return (priceChange * w.PriceChange) + (ratingScore * w.Rating) + (actionScore * w.Action)
+}
+
+// Validate verifica que los pesos sean finitos, no negativos y no todos cero
+func (w Weights) Validate() error {
+	for name, v := range map[string]float64{
+		"priceChange": w.PriceChange,
+		"rating":      w.Rating,
+		"action":      w.Action,
+	} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("invalid %s weight: %v", name, v)
+		}
+	}
+	if w.PriceChange == 0 && w.Rating == 0 && w.Action == 0 {
+		return errors.New("at least one weight must be greater than zero")
+	}
+	return nil
+}
+
+// Model es la única fuente de los scores de ratings, acciones y pesos usados
+// para puntuar recomendaciones. Un Model no debe modificarse una vez compartido.
+type Model struct {
+	RatingScores map[string]float64 `json:"ratingScores"` // Por rating canónico, ej: "Strong Buy"
+	ActionScores map[string]float64 `json:"actionScores"` // Por acción en minúsculas, ej: "target raised by"
+	UpgradeBonus float64            `json:"upgradeBonus"` // Se suma al score de rating cuando el rating mejora
+	Weights      Weights            `json:"weights"`
+	HalfLifeDays float64            `json:"halfLifeDays"` // Vida media de una llamada en días; 0 desactiva el decaimiento
+}
+
+// Breakdown contiene los componentes de un score calculado
+type Breakdown struct {
+	PriceChange float64
+	RatingScore float64 // Incluye el bonus por upgrade
+	ActionScore float64
+	Upgrade     bool
+	Age         time.Duration // Antigüedad de la llamada
+	Decay       float64       // Factor de decaimiento por antigüedad aplicado al total (1 = sin decaimiento)
+	Total       float64
+}
+
+// Inputs son los datos de una llamada necesarios para puntuarla
+type Inputs struct {
+	PriceChange float64
+	RatingFrom  string
+	RatingTo    string
+	Action      string
+	Age         time.Duration // Antigüedad de la llamada; 0 no aplica decaimiento
+}
+
+// DefaultModel crea un modelo con los scores y pesos por defecto
+func DefaultModel() *Model {
+	return &Model{
+		RatingScores: map[string]float64{
+			"Strong Buy":      5,
+			"Buy":             3,
+			"Speculative Buy": 2,
+			"Market Perform":  1,
+			"Neutral":         0,
+			"Underperform":    -1,
+			"Sell":            -2,
+			"Strong Sell":     -3,
+		},
+		ActionScores: map[string]float64{
+			"target raised by":   3,
+			"target raised":      3,
+			"target lowered by":  -2,
+			"target lowered":     -2,
+			"initiated coverage": 1,
+		},
+		UpgradeBonus: 2,
+		Weights: Weights{
+			PriceChange: 0.5,
+			Rating:      0.3,
+			Action:      0.2,
+		},
+		HalfLifeDays: 90,
+	}
+}
+
+// RatingScore retorna el score de un rating canónico (0 si no está definido)
+func (m *Model) RatingScore(rating string) float64 {
+	return m.RatingScores[rating]
+}
+
+// ActionScore retorna el score de una acción (0 si no está definida).
+// No distingue mayúsculas ni espacios repetidos.
+func (m *Model) ActionScore(action string) float64 {
+	return m.ActionScores[normalizeAction(action)]
+}
+
+// IsUpgrade retorna true si el rating destino puntúa más que el de origen
+func (m *Model) IsUpgrade(ratingFrom, ratingTo string) bool {
+	return m.RatingScore(ratingTo) > m.RatingScore(ratingFrom)
+}
+
+// HalfLife retorna la vida media de una llamada (0 si el decaimiento está desactivado)
+func (m *Model) HalfLife() time.Duration {
+	return time.Duration(m.HalfLifeDays * float64(24*time.Hour))
+}
+
+// Evaluate calcula el score de una llamada con sus componentes
+// Score = ((priceChange * w.PriceChange) + (ratingScore * w.Rating) + (actionScore * w.Action)) * decay
+func (m *Model) Evaluate(in Inputs) Breakdown {
+	b := Breakdown{
+		PriceChange: in.PriceChange,
+		RatingScore: m.RatingScore(in.RatingTo),
+		ActionScore: m.ActionScore(in.Action),
+		Upgrade:     m.IsUpgrade(in.RatingFrom, in.RatingTo),
+		Age:         in.Age,
+		Decay:       Decay(in.Age, m.HalfLife()),
+	}
+	if b.Upgrade {
+		b.RatingScore += m.UpgradeBonus
+	}
+
+	b.Total = m.Weights.Combine(b.PriceChange, b.RatingScore, b.ActionScore) * b.Decay
+
+	return b
+}
+
+// Decay retorna el factor de decaimiento exponencial de una llamada: 1 para una llamada
+// actual y la mitad por cada vida media transcurrida. Una vida media de 0 lo desactiva.
+func Decay(age, halfLife time.Duration) float64 {
+	if halfLife <= 0 || age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// Validate verifica que el modelo sea utilizable
+func (m *Model) Validate() error {
+	if len(m.RatingScores) == 0 {
+		return errors.New("scoring model must define rating scores")
+	}
+	if m.HalfLifeDays < 0 || math.IsNaN(m.HalfLifeDays) || math.IsInf(m.HalfLifeDays, 0) {
+		return fmt.Errorf("invalid halfLifeDays: %v", m.HalfLifeDays)
+	}
+	return m.Weights.Validate()
+}
+
+// Clone retorna una copia independiente del modelo
+func (m *Model) Clone() *Model {
+	c := &Model{
+		RatingScores: make(map[string]float64, len(m.RatingScores)),
+		ActionScores: make(map[string]float64, len(m.ActionScores)),
+		UpgradeBonus: m.UpgradeBonus,
+		Weights:      m.Weights,
+		HalfLifeDays: m.HalfLifeDays,
+	}
+	for k, v := range m.RatingScores {
+		c.RatingScores[k] = v
+	}
+	for k, v := range m.ActionScores {
+		c.ActionScores[k] = v
+	}
+	return c
+}
+
+// SetActionScore define el score de una acción normalizando su clave
+func (m *Model) SetActionScore(action string, score float64) {
+	m.ActionScores[normalizeAction(action)] = score
+}
+
+// RatingNames retorna los ratings definidos, del score más alto al más bajo
+func (m *Model) RatingNames() []string {
+	names := make([]string, 0, len(m.RatingScores))
+	for name := range m.RatingScores {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := m.RatingScores[names[i]], m.RatingScores[names[j]]
+		if si != sj {
+			return si > sj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// ActionNames retorna las acciones definidas en orden alfabético
+func (m *Model) ActionNames() []string {
+	names := make([]string, 0, len(m.ActionScores))
+	for name := range m.ActionScores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeAction pasa a minúsculas y colapsa espacios
+func normalizeAction(action string) string {
+	return strings.ToLower(strings.Join(strings.Fields(action), " "))
+}
@@ -0,0 +1,101 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_Evaluate(t *testing.T) {
+	model := DefaultModel()
+
+	tests := []struct {
+		name     string
+		inputs   Inputs
+		expected Breakdown
+	}{
+		{
+			name:   "upgrade with target raised",
+			inputs: Inputs{PriceChange: 50, RatingFrom: "Neutral", RatingTo: "Strong Buy", Action: "target raised by"},
+			// (50 * 0.5) + ((5 + 2) * 0.3) + (3 * 0.2) = 27.7
+			expected: Breakdown{PriceChange: 50, RatingScore: 7, ActionScore: 3, Upgrade: true, Total: 27.7},
+		},
+		{
+			name:     "downgrade with target lowered",
+			inputs:   Inputs{PriceChange: -20, RatingFrom: "Buy", RatingTo: "Underperform", Action: "Target Lowered By"},
+			expected: Breakdown{PriceChange: -20, RatingScore: -1, ActionScore: -2, Total: -10.7},
+		},
+		{
+			name:     "unknown rating and action",
+			inputs:   Inputs{PriceChange: 10, RatingFrom: "Buy", RatingTo: "Moonshot", Action: "reiterated by"},
+			expected: Breakdown{PriceChange: 10, RatingScore: 0, ActionScore: 0, Total: 5},
+		},
+		{
+			name:   "call one half-life old",
+			inputs: Inputs{PriceChange: 50, RatingFrom: "Neutral", RatingTo: "Strong Buy", Action: "target raised by", Age: 90 * 24 * time.Hour},
+			// 27.7 * 0.5
+			expected: Breakdown{PriceChange: 50, RatingScore: 7, ActionScore: 3, Upgrade: true, Decay: 0.5, Total: 13.85},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := model.Evaluate(tt.inputs)
+			assert.Equal(t, tt.expected.RatingScore, result.RatingScore)
+			assert.Equal(t, tt.expected.ActionScore, result.ActionScore)
+			assert.Equal(t, tt.expected.Upgrade, result.Upgrade)
+			assert.InDelta(t, tt.expected.Total, result.Total, 0.0001)
+		})
+	}
+}
+
+func TestDecay(t *testing.T) {
+	day := 24 * time.Hour
+
+	assert.Equal(t, 1.0, Decay(0, 30*day))
+	assert.Equal(t, 1.0, Decay(-day, 30*day))
+	assert.Equal(t, 1.0, Decay(365*day, 0))
+	assert.InDelta(t, 0.5, Decay(30*day, 30*day), 1e-9)
+	assert.InDelta(t, 0.25, Decay(60*day, 30*day), 1e-9)
+}
+
+func TestParseJSON(t *testing.T) {
+	t.Run("overrides defaults", func(t *testing.T) {
+		model, err := ParseJSON([]byte(`{
+			"ratingScores": {"Underperform": -4},
+			"actionScores": {"Upgraded By": 2.5},
+			"weights": {"priceChange": 0.6, "rating": 0.3, "action": 0.1}
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, -4.0, model.RatingScore("Underperform"))
+		assert.Equal(t, 5.0, model.RatingScore("Strong Buy"))
+		assert.Equal(t, 2.5, model.ActionScore("upgraded by"))
+		assert.Equal(t, 3.0, model.ActionScore("target raised by"))
+		assert.Equal(t, 2.0, model.UpgradeBonus)
+		assert.Equal(t, 0.6, model.Weights.PriceChange)
+	})
+
+	t.Run("rejects negative weights", func(t *testing.T) {
+		_, err := ParseJSON([]byte(`{"weights": {"priceChange": -1}}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects negative half-life", func(t *testing.T) {
+		_, err := ParseJSON([]byte(`{"halfLifeDays": -1}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects invalid json", func(t *testing.T) {
+		_, err := ParseJSON([]byte(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestModel_RatingNames(t *testing.T) {
+	names := DefaultModel().RatingNames()
+	assert.Equal(t, "Strong Buy", names[0])
+	assert.Equal(t, "Strong Sell", names[len(names)-1])
+}
@@ -0,0 +1,34 @@
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Repository define una fuente del modelo de scoring (archivo de configuración o tabla)
+type Repository interface {
+	// Load retorna el modelo: los valores definidos en la fuente sobrescriben a los por defecto
+	Load(ctx context.Context) (*Model, error)
+}
+
+// ParseJSON construye un modelo a partir de JSON, partiendo del modelo por defecto.
+// Las claves ausentes conservan su valor por defecto.
+func ParseJSON(data []byte) (*Model, error) {
+	m := DefaultModel()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid scoring model: %w", err)
+	}
+
+	// Las acciones se comparan normalizadas
+	actions := m.ActionScores
+	m.ActionScores = make(map[string]float64, len(actions))
+	for action, score := range actions {
+		m.SetActionScore(action, score)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 )
 
 // Errores del dominio
@@ -24,11 +25,19 @@ type Stock struct {
 	RatingTo    Rating
 	TargetFrom  Price
 	TargetTo    Price
+	EventTime   time.Time // Momento en que el analista hizo la llamada
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+
+	// Rating original enviado por el broker y score asignado al normalizarlo
+	RatingFromRaw   string
+	RatingToRaw     string
+	RatingFromScore float64
+	RatingToScore   float64
 }
 
-// NewStock crea una nueva entidad Stock con validaciones
+// NewStock crea una nueva entidad Stock con validaciones. Los scores de los ratings quedan
+// en cero hasta asignarlos con SetNormalizedRatings o ScoreRatings.
 func NewStock(
 	ticker string,
 	companyName string,
@@ -55,21 +64,25 @@ func NewStock(
 
 	now := time.Now()
 	return &Stock{
-		ID:          uuid.New(),
-		Ticker:      ticker,
-		CompanyName: companyName,
-		Brokerage:   brokerage,
-		Action:      action,
-		RatingFrom:  ratingFrom,
-		RatingTo:    ratingTo,
-		TargetFrom:  targetFrom,
-		TargetTo:    targetTo,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:            uuid.New(),
+		Ticker:        ticker,
+		CompanyName:   companyName,
+		Brokerage:     brokerage,
+		Action:        action,
+		RatingFrom:    ratingFrom,
+		RatingTo:      ratingTo,
+		TargetFrom:    targetFrom,
+		TargetTo:      targetTo,
+		EventTime:     now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		RatingFromRaw: ratingFrom.String(),
+		RatingToRaw:   ratingTo.String(),
 	}, nil
 }
 
-// Update actualiza los campos de la acción
+// Update actualiza los campos de la acción; como en NewStock, los scores de los ratings
+// quedan en cero hasta volver a asignarlos
 func (s *Stock) Update(
 	companyName string,
 	brokerage string,
@@ -94,6 +107,10 @@ func (s *Stock) Update(
 	s.Action = action
 	s.RatingFrom = ratingFrom
 	s.RatingTo = ratingTo
+	s.RatingFromRaw = ratingFrom.String()
+	s.RatingToRaw = ratingTo.String()
+	s.RatingFromScore = 0
+	s.RatingToScore = 0
 	s.TargetFrom = targetFrom
 	s.TargetTo = targetTo
 	s.UpdatedAt = time.Now()
@@ -101,6 +118,42 @@ func (s *Stock) Update(
 	return nil
 }
 
+// SetEventTime establece el momento en que el analista hizo la llamada
+func (s *Stock) SetEventTime(eventTime time.Time) error {
+	if eventTime.IsZero() {
+		return fmt.Errorf("event time cannot be zero")
+	}
+	s.EventTime = eventTime
+	return nil
+}
+
+// Age retorna la antigüedad de la llamada respecto a now (0 si no hay hora de evento o es futura)
+func (s *Stock) Age(now time.Time) time.Duration {
+	if s.EventTime.IsZero() || s.EventTime.After(now) {
+		return 0
+	}
+	return now.Sub(s.EventTime)
+}
+
+// SetNormalizedRatings establece los ratings a partir de su normalización,
+// conservando el string original del broker
+func (s *Stock) SetNormalizedRatings(from, to NormalizedRating) error {
+	if !from.Rating.IsValid() {
+		return fmt.Errorf("invalid rating_from: %s", from.Rating)
+	}
+	if !to.Rating.IsValid() {
+		return fmt.Errorf("invalid rating_to: %s", to.Rating)
+	}
+
+	s.RatingFrom = from.Rating
+	s.RatingTo = to.Rating
+	s.RatingFromRaw = from.Raw
+	s.RatingToRaw = to.Raw
+	s.RatingFromScore = from.Score
+	s.RatingToScore = to.Score
+	return nil
+}
+
 // CalculatePriceChange calcula el cambio porcentual del precio objetivo
 func (s *Stock) CalculatePriceChange() float64 {
 	if s.TargetFrom.IsZero() {
@@ -111,33 +164,20 @@ func (s *Stock) CalculatePriceChange() float64 {
 	return percentChange
 }
 
-// IsRatingUpgrade retorna true si el rating mejoró
-func (s *Stock) IsRatingUpgrade() bool {
-	fromScore := s.getRatingScore(s.RatingFrom)
-	toScore := s.getRatingScore(s.RatingTo)
-	return toScore > fromScore
+// ScoreRatings asigna a los ratings canónicos los scores del modelo de scoring
+// (para stocks que no pasaron por el normalizador de ratings)
+func (s *Stock) ScoreRatings(model *scoring.Model) {
+	s.RatingFromScore = model.RatingScore(s.RatingFrom.String())
+	s.RatingToScore = model.RatingScore(s.RatingTo.String())
 }
 
-// getRatingScore retorna un score numérico para el rating
-func (s *Stock) getRatingScore(rating Rating) int {
-	switch rating {
-	case RatingStrongBuy:
-		return 5
-	case RatingBuy:
-		return 3
-	case RatingSpeculativeBuy:
-		return 2
-	case RatingMarketPerform:
-		return 1
-	case RatingNeutral:
-		return 0
-	case RatingUnderperform:
-		return -1
-	case RatingSell:
-		return -2
-	case RatingStrongSell:
-		return -3
-	default:
-		return 0
-	}
+// HasMaterialChange retorna true si next cambia algo relevante respecto a s para los clientes:
+// el brokerage, la acción, los ratings o los precios objetivo (no las marcas de tiempo)
+func (s *Stock) HasMaterialChange(next *Stock) bool {
+	return s.Brokerage != next.Brokerage ||
+		s.Action != next.Action ||
+		s.RatingFrom != next.RatingFrom ||
+		s.RatingTo != next.RatingTo ||
+		!s.TargetFrom.Decimal().Equal(next.TargetFrom.Decimal()) ||
+		!s.TargetTo.Decimal().Equal(next.TargetTo.Decimal())
 }
@@ -0,0 +1,216 @@
+package stock
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Cambios de rating por los que se puede filtrar
+const (
+	RatingChangeUpgrade   = "upgrade"
+	RatingChangeDowngrade = "downgrade"
+)
+
+// MaxFilterDepth es el máximo de niveles de anidamiento de And, Or y Not
+const MaxFilterDepth = 5
+
+// Filter representa los filtros para búsqueda de stocks.
+// Todas las condiciones indicadas deben cumplirse; el valor cero no filtra.
+type Filter struct {
+	Ticker        string
+	TickerPrefix  string // Prefijo del ticker, sin distinguir mayúsculas
+	CompanyName   string
+	Ratings       []Rating
+	Brokerages    []string // Brokerages permitidos, sin distinguir mayúsculas
+	Action        string
+	EventTimeFrom time.Time // Inclusivo; se ignora si es cero
+	EventTimeTo   time.Time // Inclusivo; se ignora si es cero
+	CreatedFrom   time.Time // Inclusivo; se ignora si es cero
+	CreatedTo     time.Time // Inclusivo; se ignora si es cero
+
+	// Rangos inclusivos de precios objetivo; nil no filtra
+	TargetToMin   *float64
+	TargetToMax   *float64
+	TargetFromMin *float64
+	TargetFromMax *float64
+
+	// Rango inclusivo del cambio porcentual del precio objetivo (0 si target_from es 0); nil no filtra
+	MinTargetChange *float64
+	MaxTargetChange *float64
+
+	// RatingChange limita a mejoras o empeoramientos del rating según su score normalizado
+	RatingChange string
+
+	// Composición: todos los de And, al menos uno de Or (si hay) y ninguno de Not
+	And []Filter
+	Or  []Filter
+	Not *Filter
+}
+
+// Validate verifica que los filtros sean coherentes
+func (f Filter) Validate() error {
+	return f.validate(1)
+}
+
+// validate verifica el filtro y sus subfiltros hasta MaxFilterDepth niveles
+func (f Filter) validate(depth int) error {
+	if depth > MaxFilterDepth {
+		return fmt.Errorf("filter nesting exceeds %d levels", MaxFilterDepth)
+	}
+
+	for _, r := range f.Ratings {
+		if !r.IsValid() {
+			return fmt.Errorf("invalid rating: %s", r)
+		}
+	}
+	for _, b := range f.Brokerages {
+		if strings.TrimSpace(b) == "" {
+			return fmt.Errorf("brokerage cannot be empty")
+		}
+	}
+	if f.RatingChange != "" && f.RatingChange != RatingChangeUpgrade && f.RatingChange != RatingChangeDowngrade {
+		return fmt.Errorf("invalid ratingChange: %s", f.RatingChange)
+	}
+
+	if err := validateRange("targetTo", f.TargetToMin, f.TargetToMax); err != nil {
+		return err
+	}
+	if err := validateRange("targetFrom", f.TargetFromMin, f.TargetFromMax); err != nil {
+		return err
+	}
+	if err := validateRange("targetChange", f.MinTargetChange, f.MaxTargetChange); err != nil {
+		return err
+	}
+	if !f.EventTimeFrom.IsZero() && !f.EventTimeTo.IsZero() && f.EventTimeTo.Before(f.EventTimeFrom) {
+		return fmt.Errorf("eventTimeTo is before eventTimeFrom")
+	}
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && f.CreatedTo.Before(f.CreatedFrom) {
+		return fmt.Errorf("createdTo is before createdFrom")
+	}
+
+	for _, sub := range f.And {
+		if err := sub.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	for _, sub := range f.Or {
+		if err := sub.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	if f.Not != nil {
+		if err := f.Not.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRange verifica que los extremos de un rango sean finitos y estén en orden
+func validateRange(name string, min, max *float64) error {
+	for _, v := range []*float64{min, max} {
+		if v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return fmt.Errorf("invalid %s range: %v", name, *v)
+		}
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("invalid %s range: min %v is greater than max %v", name, *min, *max)
+	}
+	return nil
+}
+
+// Matches evalúa el filtro en memoria con la misma semántica que el repositorio
+func (f Filter) Matches(s *Stock) bool {
+	if f.Ticker != "" && s.Ticker != f.Ticker {
+		return false
+	}
+	if f.TickerPrefix != "" && !strings.HasPrefix(strings.ToLower(s.Ticker), strings.ToLower(f.TickerPrefix)) {
+		return false
+	}
+	if f.CompanyName != "" && !strings.Contains(strings.ToLower(s.CompanyName), strings.ToLower(f.CompanyName)) {
+		return false
+	}
+	if len(f.Ratings) > 0 && !containsRating(f.Ratings, s.RatingTo) {
+		return false
+	}
+	if len(f.Brokerages) > 0 && !containsFold(f.Brokerages, s.Brokerage) {
+		return false
+	}
+	if f.Action != "" && s.Action != f.Action {
+		return false
+	}
+	if !inTimeRange(s.EventTime, f.EventTimeFrom, f.EventTimeTo) || !inTimeRange(s.CreatedAt, f.CreatedFrom, f.CreatedTo) {
+		return false
+	}
+	if !inRange(s.TargetTo.Value(), f.TargetToMin, f.TargetToMax) ||
+		!inRange(s.TargetFrom.Value(), f.TargetFromMin, f.TargetFromMax) ||
+		!inRange(s.CalculatePriceChange(), f.MinTargetChange, f.MaxTargetChange) {
+		return false
+	}
+
+	switch f.RatingChange {
+	case RatingChangeUpgrade:
+		if s.RatingToScore <= s.RatingFromScore {
+			return false
+		}
+	case RatingChangeDowngrade:
+		if s.RatingToScore >= s.RatingFromScore {
+			return false
+		}
+	}
+
+	for _, sub := range f.And {
+		if !sub.Matches(s) {
+			return false
+		}
+	}
+	if len(f.Or) > 0 {
+		matched := false
+		for _, sub := range f.Or {
+			if sub.Matches(s) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Not != nil && f.Not.Matches(s) {
+		return false
+	}
+	return true
+}
+
+// inRange retorna true si value está en el rango inclusivo [min, max] (nil no limita)
+func inRange(value float64, min, max *float64) bool {
+	return (min == nil || value >= *min) && (max == nil || value <= *max)
+}
+
+// inTimeRange retorna true si t está en el rango inclusivo [from, to] (cero no limita)
+func inTimeRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// containsRating retorna true si rating está en ratings
+func containsRating(ratings []Rating, rating Rating) bool {
+	for _, r := range ratings {
+		if r == rating {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold retorna true si value está en values sin distinguir mayúsculas ni espacios extremos
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
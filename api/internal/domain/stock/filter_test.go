@@ -0,0 +1,91 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func createFilterTestStock(ticker, brokerage string, targetFrom, targetTo float64, from, to Rating) *Stock {
+	tf, _ := NewPrice(targetFrom)
+	tt, _ := NewPrice(targetTo)
+	s, _ := NewStock(ticker, ticker+" Inc.", brokerage, "target raised by", from, to, tf, tt)
+	s.ScoreRatings(scoring.DefaultModel())
+	return s
+}
+
+func TestFilter_Matches(t *testing.T) {
+	aapl := createFilterTestStock("AAPL", "Goldman Sachs", 100, 150, RatingNeutral, RatingBuy)
+	amd := createFilterTestStock("AMD", "Morgan Stanley", 100, 90, RatingBuy, RatingSell)
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []bool // aapl, amd
+	}{
+		{"sin filtros", Filter{}, []bool{true, true}},
+		{"prefijo de ticker", Filter{TickerPrefix: "aa"}, []bool{true, false}},
+		{"brokerages", Filter{Brokerages: []string{" morgan stanley "}}, []bool{false, true}},
+		{"rango de targetTo", Filter{TargetToMin: float(100), TargetToMax: float(150)}, []bool{true, false}},
+		{"rango de targetFrom", Filter{TargetFromMax: float(99)}, []bool{false, false}},
+		{"cambio porcentual mínimo", Filter{MinTargetChange: float(50)}, []bool{true, false}},
+		{"cambio porcentual máximo", Filter{MaxTargetChange: float(0)}, []bool{false, true}},
+		{"solo upgrades", Filter{RatingChange: RatingChangeUpgrade}, []bool{true, false}},
+		{"solo downgrades", Filter{RatingChange: RatingChangeDowngrade}, []bool{false, true}},
+		{"rango de fechas", Filter{EventTimeFrom: time.Now().Add(time.Hour)}, []bool{false, false}},
+		{"or", Filter{Or: []Filter{{Ticker: "AMD"}, {RatingChange: RatingChangeUpgrade}}}, []bool{true, true}},
+		{"not", Filter{Not: &Filter{TickerPrefix: "A", Ratings: []Rating{RatingSell}}}, []bool{true, false}},
+		{"and con or", Filter{
+			And: []Filter{{TargetToMin: float(80)}},
+			Or:  []Filter{{Brokerages: []string{"Goldman Sachs"}}, {Ticker: "MSFT"}},
+		}, []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected[0], tt.filter.Matches(aapl))
+			assert.Equal(t, tt.expected[1], tt.filter.Matches(amd))
+		})
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	now := time.Now()
+	deep := Filter{}
+	for i := 0; i < MaxFilterDepth; i++ {
+		deep = Filter{Not: &deep}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		valid  bool
+	}{
+		{"vacío", Filter{}, true},
+		{"compuesto", Filter{Or: []Filter{{TickerPrefix: "A"}, {Not: &Filter{Ratings: []Rating{RatingBuy}}}}}, true},
+		{"rating inválido", Filter{Ratings: []Rating{"Meh"}}, false},
+		{"rating inválido anidado", Filter{And: []Filter{{Ratings: []Rating{"Meh"}}}}, false},
+		{"rango invertido", Filter{TargetToMin: float(10), TargetToMax: float(5)}, false},
+		{"cambio de rating inválido", Filter{RatingChange: "sideways"}, false},
+		{"fechas invertidas", Filter{EventTimeFrom: now, EventTimeTo: now.Add(-time.Hour)}, false},
+		{"brokerage vacío", Filter{Brokerages: []string{" "}}, false},
+		{"demasiado anidado", deep, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
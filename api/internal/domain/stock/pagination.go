@@ -0,0 +1,161 @@
+package stock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidCursor indica un cursor mal formado o que no corresponde al ordenamiento pedido
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Ordenamiento por defecto de las búsquedas de stocks
+const (
+	DefaultSortField     = "created_at"
+	DefaultSortDirection = "desc"
+)
+
+// Campos calculados por los que se puede ordenar
+const (
+	SortPriceChange = "price_change" // Cambio porcentual del precio objetivo (CalculatePriceChange)
+	SortRatingDelta = "rating_delta" // Diferencia entre el score normalizado del rating destino y el de origen
+	SortScore       = "score"        // Score de recomendación según el modelo de scoring
+)
+
+// sortFields son los campos por los que se puede ordenar; true si se almacenan en una columna
+var sortFields = map[string]bool{
+	"ticker":        true,
+	"company_name":  true,
+	"rating_to":     true,
+	"target_to":     true,
+	"created_at":    true,
+	"event_time":    true,
+	SortPriceChange: false,
+	SortRatingDelta: false,
+	SortScore:       false,
+}
+
+// IsComputedSortField retorna true si field es un campo de orden válido que se calcula en la consulta
+func IsComputedSortField(field string) bool {
+	stored, ok := sortFields[field]
+	return ok && !stored
+}
+
+// Normalized retorna el ordenamiento con un campo válido (created_at desc por defecto)
+// y la dirección en minúsculas ("asc" salvo que se pida "desc")
+func (s Sort) Normalized() Sort {
+	if _, ok := sortFields[s.Field]; !ok {
+		return Sort{Field: DefaultSortField, Direction: DefaultSortDirection}
+	}
+	if s.Direction != "desc" {
+		s.Direction = "asc"
+	}
+	return s
+}
+
+// Page representa una página de una búsqueda de stocks.
+// El valor cero retorna todas las filas.
+type Page struct {
+	Limit  int     // Máximo de filas; 0 sin límite
+	Offset int     // Filas a omitir; se ignora si hay cursor
+	After  *Cursor // La página empieza después de esta posición (keyset); nil desde el principio
+}
+
+// Cursor es la posición de un stock en un ordenamiento: el valor del campo de orden y el ID,
+// que desempata los valores repetidos. En los campos calculados el valor se recalcula en la
+// consulta a partir del ID, y At fija el momento de referencia del score entre páginas.
+type Cursor struct {
+	Field     string    `json:"f"`
+	Direction string    `json:"d"`
+	Value     string    `json:"v,omitempty"`
+	ID        uuid.UUID `json:"id"`
+	At        time.Time `json:"at"`
+}
+
+// NewCursor crea el cursor de s en el ordenamiento indicado
+func NewCursor(s *Stock, sort Sort) Cursor {
+	sort = sort.Normalized()
+	c := Cursor{Field: sort.Field, Direction: sort.Direction, ID: s.ID}
+	if sort.Field == SortScore {
+		c.At = sort.At.UTC()
+	}
+
+	switch sort.Field {
+	case "ticker":
+		c.Value = s.Ticker
+	case "company_name":
+		c.Value = s.CompanyName
+	case "rating_to":
+		c.Value = s.RatingTo.String()
+	case "target_to":
+		c.Value = s.TargetTo.String()
+	case "created_at":
+		c.Value = s.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "event_time":
+		c.Value = s.EventTime.UTC().Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// Encode retorna el cursor como string opaco para los clientes
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor lee un cursor generado por Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := sortFields[c.Field]; !ok || (c.Direction != "asc" && c.Direction != "desc") || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Field == SortScore && c.At.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := c.SortValue(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Matches retorna true si el cursor se generó con el ordenamiento indicado
+func (c Cursor) Matches(sort Sort) bool {
+	sort = sort.Normalized()
+	return c.Field == sort.Field && c.Direction == sort.Direction
+}
+
+// SortValue retorna el valor del campo de orden con el tipo de su columna
+// (los precios se mantienen como string decimal para no perder precisión; nil en los campos calculados)
+func (c Cursor) SortValue() (interface{}, error) {
+	if IsComputedSortField(c.Field) {
+		return nil, nil
+	}
+
+	switch c.Field {
+	case "target_to":
+		if _, err := decimal.NewFromString(c.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return c.Value, nil
+	case "created_at", "event_time":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	default:
+		return c.Value, nil
+	}
+}
@@ -0,0 +1,87 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSort_Normalized(t *testing.T) {
+	assert.Equal(t, Sort{Field: "created_at", Direction: "desc"}, Sort{}.Normalized())
+	assert.Equal(t, Sort{Field: "created_at", Direction: "desc"}, Sort{Field: "id; DROP TABLE stocks", Direction: "asc"}.Normalized())
+	assert.Equal(t, Sort{Field: "ticker", Direction: "asc"}, Sort{Field: "ticker"}.Normalized())
+	assert.Equal(t, Sort{Field: "target_to", Direction: "desc"}, Sort{Field: "target_to", Direction: "desc"}.Normalized())
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	price, _ := NewPrice(123.45)
+	s, err := NewStock("AAPL", "Apple Inc.", "Broker", "target raised by", RatingBuy, RatingBuy, price, price)
+	require.NoError(t, err)
+	s.EventTime = time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tests := []struct {
+		sort  Sort
+		value interface{}
+	}{
+		{Sort{Field: "ticker", Direction: "asc"}, "AAPL"},
+		{Sort{Field: "target_to", Direction: "desc"}, "123.45"},
+		{Sort{Field: "event_time", Direction: "desc"}, s.EventTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort.Field, func(t *testing.T) {
+			cursor, err := DecodeCursor(NewCursor(s, tt.sort).Encode())
+			require.NoError(t, err)
+			assert.Equal(t, s.ID, cursor.ID)
+			assert.True(t, cursor.Matches(tt.sort))
+			assert.False(t, cursor.Matches(Sort{Field: "company_name"}))
+
+			value, err := cursor.SortValue()
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	invalid := []string{
+		"not base64!",
+		"bm90IGpzb24",
+		Cursor{Field: "password", Direction: "asc", Value: "x", ID: uuid.New()}.Encode(),
+		Cursor{Field: "ticker", Direction: "asc", Value: "AAPL"}.Encode(),
+		Cursor{Field: "event_time", Direction: "desc", Value: "yesterday", ID: uuid.New()}.Encode(),
+	}
+
+	for _, encoded := range invalid {
+		_, err := DecodeCursor(encoded)
+		assert.ErrorIs(t, err, ErrInvalidCursor, encoded)
+	}
+}
+
+func TestCursor_ComputedFields(t *testing.T) {
+	price, _ := NewPrice(10)
+	s, err := NewStock("AAPL", "Apple Inc.", "Broker", "upgraded by", RatingNeutral, RatingBuy, price, price)
+	require.NoError(t, err)
+
+	assert.True(t, IsComputedSortField(SortScore))
+	assert.True(t, IsComputedSortField(SortPriceChange))
+	assert.False(t, IsComputedSortField("ticker"))
+	assert.False(t, IsComputedSortField("unknown"))
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cursor, err := DecodeCursor(NewCursor(s, Sort{Field: SortScore, Direction: "desc", At: at}).Encode())
+	require.NoError(t, err)
+	assert.Equal(t, at, cursor.At)
+	assert.Empty(t, cursor.Value)
+
+	value, err := cursor.SortValue()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	// Un cursor de score sin momento de referencia no puede reproducir el orden
+	_, err = DecodeCursor(Cursor{Field: SortScore, Direction: "desc", ID: s.ID}.Encode())
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
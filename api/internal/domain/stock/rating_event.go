@@ -0,0 +1,78 @@
+package stock
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Límites del historial de eventos de un ticker
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 500
+)
+
+// RatingEvent representa una llamada de un analista sobre un ticker en un momento dado.
+// A diferencia de Stock, que guarda solo el último estado por ticker, los eventos
+// se conservan todos para poder reconstruir el historial de cobertura.
+type RatingEvent struct {
+	ID          uuid.UUID
+	Ticker      string
+	CompanyName string
+	Brokerage   string
+	Action      string
+	RatingFrom  Rating
+	RatingTo    Rating
+	TargetFrom  Price
+	TargetTo    Price
+	EventTime   time.Time
+	CreatedAt   time.Time
+
+	RatingFromRaw   string
+	RatingToRaw     string
+	RatingFromScore float64
+	RatingToScore   float64
+}
+
+// NewRatingEventFromStock crea un evento de rating a partir de una entidad Stock
+func NewRatingEventFromStock(s *Stock) *RatingEvent {
+	return &RatingEvent{
+		ID:              uuid.New(),
+		Ticker:          s.Ticker,
+		CompanyName:     s.CompanyName,
+		Brokerage:       s.Brokerage,
+		Action:          s.Action,
+		RatingFrom:      s.RatingFrom,
+		RatingTo:        s.RatingTo,
+		TargetFrom:      s.TargetFrom,
+		TargetTo:        s.TargetTo,
+		EventTime:       s.EventTime,
+		CreatedAt:       time.Now(),
+		RatingFromRaw:   s.RatingFromRaw,
+		RatingToRaw:     s.RatingToRaw,
+		RatingFromScore: s.RatingFromScore,
+		RatingToScore:   s.RatingToScore,
+	}
+}
+
+// NewStockFromRatingEvent reconstruye el estado de un stock tras la llamada del evento
+func NewStockFromRatingEvent(e *RatingEvent) *Stock {
+	return &Stock{
+		ID:              uuid.New(),
+		Ticker:          e.Ticker,
+		CompanyName:     e.CompanyName,
+		Brokerage:       e.Brokerage,
+		Action:          e.Action,
+		RatingFrom:      e.RatingFrom,
+		RatingTo:        e.RatingTo,
+		TargetFrom:      e.TargetFrom,
+		TargetTo:        e.TargetTo,
+		EventTime:       e.EventTime,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.CreatedAt,
+		RatingFromRaw:   e.RatingFromRaw,
+		RatingToRaw:     e.RatingToRaw,
+		RatingFromScore: e.RatingFromScore,
+		RatingToScore:   e.RatingToScore,
+	}
+}
@@ -0,0 +1,173 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+)
+
+// RatingAlias mapea un rating tal como lo envía un broker a un rating canónico con su score
+type RatingAlias struct {
+	Alias  string
+	Rating Rating
+	Score  float64
+}
+
+// NormalizedRating es el resultado de normalizar un rating crudo
+type NormalizedRating struct {
+	Raw    string // String original enviado por el broker
+	Rating Rating // Rating canónico
+	Score  float64
+}
+
+// canonicalRatings son los ratings canónicos, cada uno es alias de sí mismo
+var canonicalRatings = []Rating{
+	RatingStrongBuy,
+	RatingBuy,
+	RatingSpeculativeBuy,
+	RatingMarketPerform,
+	RatingNeutral,
+	RatingUnderperform,
+	RatingSell,
+	RatingStrongSell,
+}
+
+// defaultRatingAliases son los vocabularios de brokers conocidos
+var defaultRatingAliases = map[string]Rating{
+	"conviction buy":      RatingStrongBuy,
+	"top pick":            RatingStrongBuy,
+	"outperform":          RatingBuy,
+	"market outperform":   RatingBuy,
+	"sector outperform":   RatingBuy,
+	"overweight":          RatingBuy,
+	"positive":            RatingBuy,
+	"accumulate":          RatingBuy,
+	"add":                 RatingBuy,
+	"moderate buy":        RatingBuy,
+	"sector perform":      RatingMarketPerform,
+	"peer perform":        RatingMarketPerform,
+	"in-line":             RatingMarketPerform,
+	"in line":             RatingMarketPerform,
+	"equal weight":        RatingMarketPerform,
+	"equal-weight":        RatingMarketPerform,
+	"sector weight":       RatingMarketPerform,
+	"hold":                RatingNeutral,
+	"mixed":               RatingNeutral,
+	"underperform":        RatingUnderperform,
+	"market underperform": RatingUnderperform,
+	"sector underperform": RatingUnderperform,
+	"underweight":         RatingUnderperform,
+	"reduce":              RatingUnderperform,
+	"negative":            RatingUnderperform,
+	"moderate sell":       RatingSell,
+}
+
+// builtinRatingAliases retorna los alias incorporados con los scores del modelo indicado
+func builtinRatingAliases(model *scoring.Model) []RatingAlias {
+	aliases := make([]RatingAlias, 0, len(canonicalRatings)+len(defaultRatingAliases))
+	for _, rating := range canonicalRatings {
+		aliases = append(aliases, RatingAlias{Alias: rating.String(), Rating: rating, Score: model.RatingScore(rating.String())})
+	}
+	for alias, rating := range defaultRatingAliases {
+		aliases = append(aliases, RatingAlias{Alias: alias, Rating: rating, Score: model.RatingScore(rating.String())})
+	}
+	return aliases
+}
+
+// RatingNormalizer traduce los ratings crudos de los brokers a ratings canónicos.
+// La comparación no distingue mayúsculas ni espacios repetidos. Es seguro para uso concurrente.
+type RatingNormalizer struct {
+	mu         sync.RWMutex
+	model      *scoring.Model
+	configured []RatingAlias
+	aliases    map[string]RatingAlias
+}
+
+// NewRatingNormalizer crea un normalizador con los alias incorporados, puntuados con model,
+// más los indicados (los indicados tienen prioridad sobre los incorporados)
+func NewRatingNormalizer(model *scoring.Model, aliases []RatingAlias) *RatingNormalizer {
+	n := &RatingNormalizer{model: model}
+	n.SetAliases(aliases)
+	return n
+}
+
+// SetAliases reemplaza los alias configurados manteniendo los incorporados
+func (n *RatingNormalizer) SetAliases(aliases []RatingAlias) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.configured = aliases
+	n.rebuild()
+}
+
+// BuiltinAliases retorna los alias incorporados (incluye los propios ratings canónicos)
+// con los scores del modelo del normalizador
+func (n *RatingNormalizer) BuiltinAliases() []RatingAlias {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return builtinRatingAliases(n.model)
+}
+
+// RatingScore retorna el score de un rating canónico según el modelo del normalizador
+func (n *RatingNormalizer) RatingScore(rating Rating) float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.model.RatingScore(rating.String())
+}
+
+// rebuild reconstruye la tabla de alias; debe llamarse con el lock tomado
+func (n *RatingNormalizer) rebuild() {
+	table := make(map[string]RatingAlias)
+	for _, a := range builtinRatingAliases(n.model) {
+		table[normalizeRatingKey(a.Alias)] = a
+	}
+	for _, a := range n.configured {
+		table[normalizeRatingKey(a.Alias)] = a
+	}
+	n.aliases = table
+}
+
+// Normalize traduce un rating crudo a su rating canónico y score
+func (n *RatingNormalizer) Normalize(raw string) (NormalizedRating, error) {
+	key := normalizeRatingKey(raw)
+	if key == "" {
+		return NormalizedRating{}, fmt.Errorf("rating cannot be empty")
+	}
+
+	n.mu.RLock()
+	alias, ok := n.aliases[key]
+	n.mu.RUnlock()
+
+	if !ok {
+		return NormalizedRating{}, fmt.Errorf("unknown rating: %s", raw)
+	}
+
+	return NormalizedRating{
+		Raw:    strings.TrimSpace(raw),
+		Rating: alias.Rating,
+		Score:  alias.Score,
+	}, nil
+}
+
+// ValidateRatingAlias valida un alias antes de guardarlo
+func ValidateRatingAlias(alias RatingAlias) error {
+	if normalizeRatingKey(alias.Alias) == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if !alias.Rating.IsValid() {
+		return fmt.Errorf("invalid rating: %s", alias.Rating)
+	}
+	return nil
+}
+
+// NormalizeRatingKey normaliza un rating crudo para comparar alias
+func NormalizeRatingKey(raw string) string {
+	return normalizeRatingKey(raw)
+}
+
+// normalizeRatingKey pasa a minúsculas y colapsa espacios
+func normalizeRatingKey(raw string) string {
+	return strings.ToLower(strings.Join(strings.Fields(raw), " "))
+}
@@ -0,0 +1,98 @@
+package stock
+
+import (
+	"testing"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingNormalizer_Normalize(t *testing.T) {
+	normalizer := NewRatingNormalizer(scoring.DefaultModel(), nil)
+
+	tests := []struct {
+		name           string
+		raw            string
+		expectedRating Rating
+		expectedScore  float64
+	}{
+		{name: "canonical rating", raw: "Buy", expectedRating: RatingBuy, expectedScore: 3},
+		{name: "case insensitive", raw: "STRONG buy", expectedRating: RatingStrongBuy, expectedScore: 5},
+		{name: "extra whitespace", raw: "  Market   Perform ", expectedRating: RatingMarketPerform, expectedScore: 1},
+		{name: "broker alias", raw: "Outperform", expectedRating: RatingBuy, expectedScore: 3},
+		{name: "hyphenated alias", raw: "Equal-Weight", expectedRating: RatingMarketPerform, expectedScore: 1},
+		{name: "negative alias", raw: "Underweight", expectedRating: RatingUnderperform, expectedScore: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := normalizer.Normalize(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRating, result.Rating)
+			assert.Equal(t, tt.expectedScore, result.Score)
+			assert.Equal(t, normalizeRatingKey(tt.raw), normalizeRatingKey(result.Raw))
+		})
+	}
+
+	t.Run("unknown rating", func(t *testing.T) {
+		_, err := normalizer.Normalize("Moonshot")
+		assert.Error(t, err)
+	})
+
+	t.Run("empty rating", func(t *testing.T) {
+		_, err := normalizer.Normalize("  ")
+		assert.Error(t, err)
+	})
+}
+
+func TestRatingNormalizer_SetAliases(t *testing.T) {
+	normalizer := NewRatingNormalizer(scoring.DefaultModel(), nil)
+
+	normalizer.SetAliases([]RatingAlias{
+		{Alias: "Moonshot", Rating: RatingSpeculativeBuy, Score: 2.5},
+		{Alias: "hold", Rating: RatingMarketPerform, Score: 0.5}, // Sobrescribe un alias incorporado
+	})
+
+	result, err := normalizer.Normalize("moonshot")
+	require.NoError(t, err)
+	assert.Equal(t, RatingSpeculativeBuy, result.Rating)
+	assert.Equal(t, 2.5, result.Score)
+	assert.Equal(t, "moonshot", result.Raw)
+
+	result, err = normalizer.Normalize("Hold")
+	require.NoError(t, err)
+	assert.Equal(t, RatingMarketPerform, result.Rating)
+	assert.Equal(t, 0.5, result.Score)
+
+	// Reemplazar los alias configurados descarta los anteriores pero conserva los incorporados
+	normalizer.SetAliases(nil)
+	_, err = normalizer.Normalize("moonshot")
+	assert.Error(t, err)
+
+	result, err = normalizer.Normalize("Hold")
+	require.NoError(t, err)
+	assert.Equal(t, RatingNeutral, result.Rating)
+}
+
+func TestRatingNormalizer_ScoringModel(t *testing.T) {
+	model := scoring.DefaultModel()
+	model.RatingScores["Buy"] = 4
+	normalizer := NewRatingNormalizer(model, nil)
+
+	// Los ratings canónicos y los alias incorporados toman el score del modelo inyectado
+	result, err := normalizer.Normalize("Buy")
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, result.Score)
+
+	result, err = normalizer.Normalize("Outperform")
+	require.NoError(t, err)
+	assert.Equal(t, RatingBuy, result.Rating)
+	assert.Equal(t, 4.0, result.Score)
+}
+
+func TestValidateRatingAlias(t *testing.T) {
+	assert.NoError(t, ValidateRatingAlias(RatingAlias{Alias: "Top Pick", Rating: RatingStrongBuy}))
+	assert.Error(t, ValidateRatingAlias(RatingAlias{Alias: " ", Rating: RatingStrongBuy}))
+	assert.Error(t, ValidateRatingAlias(RatingAlias{Alias: "Top Pick", Rating: Rating("Great")}))
+}
@@ -2,22 +2,21 @@ package stock
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 )
 
-// Filter representa los filtros para búsqueda de stocks
-type Filter struct {
-	Ticker      string
-	CompanyName string
-	Ratings     []Rating
-	Action      string
-}
-
 // Sort representa el ordenamiento para búsqueda de stocks
 type Sort struct {
-	Field     string // "ticker", "company_name", "rating_to", "target_to", "created_at"
+	Field     string // "ticker", "company_name", "rating_to", "target_to", "created_at", "event_time" o un campo calculado
 	Direction string // "asc", "desc"
+
+	// Solo para ordenar por score: modelo de scoring (nil usa el por defecto) y momento
+	// de referencia del decaimiento por antigüedad (cero usa la hora actual)
+	Model *scoring.Model
+	At    time.Time
 }
 
 // Repository define la interfaz del repositorio de stocks
@@ -34,9 +33,62 @@ type Repository interface {
 	// FindByTicker busca una acción por ticker
 	FindByTicker(ctx context.Context, ticker string) (*Stock, error)
 
-	// FindAll busca todas las acciones con filtros y ordenamiento
-	FindAll(ctx context.Context, filter Filter, sort Sort) ([]*Stock, error)
+	// FindByTickers busca las acciones de los tickers indicados (los que no existen se omiten)
+	FindByTickers(ctx context.Context, tickers []string) ([]*Stock, error)
+
+	// FindAll busca las acciones con filtros y ordenamiento, limitadas a la página indicada
+	// (Page{} retorna todas); el cursor de la página debe corresponder al ordenamiento
+	FindAll(ctx context.Context, filter Filter, sort Sort, page Page) ([]*Stock, error)
 
 	// Count cuenta el número de acciones que coinciden con el filtro
 	Count(ctx context.Context, filter Filter) (int, error)
+
+	// Stats calcula las agregaciones de las acciones que coinciden con el filtro
+	Stats(ctx context.Context, filter Filter) (*Stats, error)
+
+	// Search busca acciones por ticker, empresa o brokerage tolerando errores de escritura,
+	// ordenadas por relevancia descendente
+	Search(ctx context.Context, query string, limit int) ([]*SearchResult, error)
+}
+
+// RatingEventRepository define la interfaz del repositorio de eventos de rating
+type RatingEventRepository interface {
+	// BatchInsert inserta múltiples eventos ignorando los duplicados
+	// (ticker, brokerage, action, event_time) y retorna cuántos eran nuevos
+	BatchInsert(ctx context.Context, events []*RatingEvent) (int, error)
+
+	// FindByTicker retorna los últimos limit eventos de un ticker (limit debe ser positivo),
+	// del más reciente al más antiguo
+	FindByTicker(ctx context.Context, ticker string, limit int) ([]*RatingEvent, error)
+
+	// FindByTickers retorna los últimos limit eventos de cada ticker (limit debe ser positivo),
+	// ordenados por ticker y del más reciente al más antiguo
+	FindByTickers(ctx context.Context, tickers []string, limit int) ([]*RatingEvent, error)
+
+	// FindSince retorna los eventos de los tickers indicados (todos si está vacío) posteriores
+	// o iguales a since, del más reciente al más antiguo
+	FindSince(ctx context.Context, tickers []string, since time.Time) ([]*RatingEvent, error)
+
+	// LastChange retorna el momento en que se registró el último evento (cero si no hay);
+	// permite reutilizar los cálculos sobre todo el historial mientras no cambie
+	LastChange(ctx context.Context) (time.Time, error)
+}
+
+// RatingAliasRepository define la interfaz del repositorio de alias de ratings
+type RatingAliasRepository interface {
+	// FindAll retorna todos los alias configurados
+	FindAll(ctx context.Context) ([]RatingAlias, error)
+
+	// Save guarda o actualiza un alias
+	Save(ctx context.Context, alias RatingAlias) error
+
+	// Delete elimina un alias; retorna false si no existía
+	Delete(ctx context.Context, alias string) (bool, error)
+
+	// FindUnscoredRatings retorna los ratings originales de stocks y eventos que no tienen score
+	FindUnscoredRatings(ctx context.Context) ([]string, error)
+
+	// SetMissingScores asigna score a los stocks y eventos sin score cuyo rating original es raw
+	// y retorna cuántos ratings se actualizaron
+	SetMissingScores(ctx context.Context, raw string, score float64) (int, error)
 }
@@ -0,0 +1,216 @@
+package stock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Campos en los que busca Search
+const (
+	SearchFieldTicker      = "ticker"
+	SearchFieldCompanyName = "company_name"
+	SearchFieldBrokerage   = "brokerage"
+)
+
+// searchFields es el orden de preferencia entre campos con el mismo score
+var searchFields = []string{SearchFieldTicker, SearchFieldCompanyName, SearchFieldBrokerage}
+
+// Límites de la búsqueda
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 50
+	MaxSearchQueryLen  = 100
+)
+
+// fuzzyThreshold es la similitud de trigramas mínima para resaltar una palabra sin coincidencia literal
+// (el umbral por defecto del operador % de pg_trgm)
+const fuzzyThreshold = 0.3
+
+// TextRange es un tramo [Start, End) de un texto, en caracteres (runas)
+type TextRange struct {
+	Start int
+	End   int
+}
+
+// SearchResult es un stock encontrado por Search con el campo que mejor coincide
+type SearchResult struct {
+	Stock      *Stock
+	Field      string      // Campo con mejor coincidencia (SearchField*)
+	Score      float64     // Relevancia entre 0 y 1
+	Highlights []TextRange // Tramos de MatchedValue que coinciden con la búsqueda
+}
+
+// MatchedValue retorna el valor del campo que coincidió
+func (r *SearchResult) MatchedValue() string {
+	return searchFieldValue(r.Stock, r.Field)
+}
+
+// NormalizeSearchQuery limpia la búsqueda (espacios extremos y repetidos) y verifica su longitud
+func NormalizeSearchQuery(query string) (string, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return "", fmt.Errorf("search query cannot be empty")
+	}
+	if utf8.RuneCountInString(query) > MaxSearchQueryLen {
+		return "", fmt.Errorf("search query exceeds %d characters", MaxSearchQueryLen)
+	}
+	return query, nil
+}
+
+// NewSearchResult construye el resultado de s a partir del score de cada campo,
+// eligiendo el de mayor score y resaltando en él los tramos que coinciden con query
+func NewSearchResult(s *Stock, query string, scores map[string]float64) *SearchResult {
+	result := &SearchResult{Stock: s, Field: SearchFieldTicker, Score: -1}
+	for _, field := range searchFields {
+		if score, ok := scores[field]; ok && score > result.Score {
+			result.Field, result.Score = field, score
+		}
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	result.Highlights = Highlight(result.MatchedValue(), query)
+	return result
+}
+
+// Highlight retorna los tramos de value que coinciden con query sin distinguir mayúsculas:
+// las apariciones de la búsqueda completa o, si no hay, las de cada una de sus palabras;
+// si tampoco hay coincidencias literales, las palabras de value parecidas a alguna de la búsqueda
+func Highlight(value, query string) []TextRange {
+	text := lowerRunes(value)
+
+	if ranges := occurrences(text, lowerRunes(query)); len(ranges) > 0 {
+		return ranges
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	var ranges []TextRange
+	for _, term := range terms {
+		ranges = append(ranges, occurrences(text, lowerRunes(term))...)
+	}
+	if len(ranges) > 0 {
+		return mergeRanges(ranges)
+	}
+
+	for _, word := range words(text) {
+		for _, term := range terms {
+			if TrigramSimilarity(string(text[word.Start:word.End]), term) >= fuzzyThreshold {
+				ranges = append(ranges, word)
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// TrigramSimilarity calcula la similitud de trigramas entre a y b con la misma definición
+// que similarity() de pg_trgm: trigramas compartidos sobre trigramas distintos de ambos
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams retorna los trigramas de cada palabra de s en minúsculas, con dos espacios
+// de relleno al inicio y uno al final como pg_trgm
+func trigrams(s string) map[string]bool {
+	result := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = true
+		}
+	}
+	return result
+}
+
+// lowerRunes pasa s a minúsculas runa a runa, conservando las posiciones de cada carácter
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// occurrences retorna los tramos de text donde aparece term, sin solaparse
+func occurrences(text, term []rune) []TextRange {
+	if len(term) == 0 {
+		return nil
+	}
+
+	var ranges []TextRange
+	for i := 0; i+len(term) <= len(text); {
+		if string(text[i:i+len(term)]) == string(term) {
+			ranges = append(ranges, TextRange{Start: i, End: i + len(term)})
+			i += len(term)
+			continue
+		}
+		i++
+	}
+	return ranges
+}
+
+// words retorna los tramos de las palabras (letras y dígitos consecutivos) de text
+func words(text []rune) []TextRange {
+	var ranges []TextRange
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			ranges = append(ranges, TextRange{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		ranges = append(ranges, TextRange{Start: start, End: len(text)})
+	}
+	return ranges
+}
+
+// mergeRanges ordena los tramos y une los que se solapan o son contiguos
+func mergeRanges(ranges []TextRange) []TextRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// searchFieldValue retorna el valor del campo de búsqueda indicado
+func searchFieldValue(s *Stock, field string) string {
+	switch field {
+	case SearchFieldCompanyName:
+		return s.CompanyName
+	case SearchFieldBrokerage:
+		return s.Brokerage
+	default:
+		return s.Ticker
+	}
+}
@@ -0,0 +1,69 @@
+package stock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	query, err := NormalizeSearchQuery("  apple   inc ")
+	require.NoError(t, err)
+	assert.Equal(t, "apple inc", query)
+
+	_, err = NormalizeSearchQuery("   ")
+	assert.Error(t, err)
+
+	_, err = NormalizeSearchQuery(strings.Repeat("a", MaxSearchQueryLen+1))
+	assert.Error(t, err)
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		query    string
+		expected []TextRange
+	}{
+		{"búsqueda completa", "Apple Inc.", "APPLE", []TextRange{{0, 5}}},
+		{"varias apariciones", "Banana Bank", "ban", []TextRange{{0, 3}, {7, 10}}},
+		{"palabras por separado", "Goldman Sachs Group", "group goldman", []TextRange{{0, 7}, {14, 19}}},
+		{"palabras solapadas", "Microsoft", "micro soft crosoft", []TextRange{{0, 9}}},
+		{"error de escritura", "Microsoft Corporation", "microsfot", []TextRange{{0, 9}}},
+		{"caracteres multibyte", "Société Générale", "générale", []TextRange{{8, 16}}},
+		{"sin coincidencias", "Apple Inc.", "nvidia", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Highlight(tt.value, tt.query))
+		})
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, TrigramSimilarity("Apple", "apple"))
+	assert.Equal(t, 0.0, TrigramSimilarity("", "apple"))
+	assert.Greater(t, TrigramSimilarity("microsoft", "microsfot"), fuzzyThreshold)
+	assert.Less(t, TrigramSimilarity("microsoft", "nvidia"), fuzzyThreshold)
+}
+
+func TestNewSearchResult(t *testing.T) {
+	s := &Stock{Ticker: "GS", CompanyName: "Goldman Sachs Group", Brokerage: "Goldman Sachs"}
+
+	result := NewSearchResult(s, "goldman", map[string]float64{
+		SearchFieldTicker:      0.1,
+		SearchFieldCompanyName: 0.71,
+		SearchFieldBrokerage:   0.71,
+	})
+	assert.Equal(t, SearchFieldCompanyName, result.Field)
+	assert.Equal(t, 0.71, result.Score)
+	assert.Equal(t, "Goldman Sachs Group", result.MatchedValue())
+	assert.Equal(t, []TextRange{{0, 7}}, result.Highlights)
+
+	result = NewSearchResult(s, "gs", nil)
+	assert.Equal(t, SearchFieldTicker, result.Field)
+	assert.Equal(t, 0.0, result.Score)
+}
@@ -1,5 +1,11 @@
 package stock
 
+import (
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+)
+
 // Service define los servicios de dominio para stocks
 type Service interface {
 	// CalculatePriceChange calcula el cambio porcentual en el precio objetivo
@@ -10,14 +16,34 @@ type Service interface {
 
 	// CalculateRecommendationScore calcula un score de recomendación
 	CalculateRecommendationScore(stock *Stock) float64
+
+	// CalculateScoreBreakdown calcula el score de recomendación con sus componentes
+	CalculateScoreBreakdown(stock *Stock) scoring.Breakdown
+
+	// ScoringModel retorna el modelo de scoring en uso
+	ScoringModel() *scoring.Model
 }
 
 // DomainService implementa los servicios de dominio
-type DomainService struct{}
+type DomainService struct {
+	model *scoring.Model
+	now   func() time.Time
+}
 
-// NewDomainService crea un nuevo servicio de dominio
+// NewDomainService crea un nuevo servicio de dominio con el modelo de scoring por defecto
 func NewDomainService() Service {
-	return &DomainService{}
+	return NewDomainServiceWithModel(scoring.DefaultModel())
+}
+
+// NewDomainServiceWithModel crea un nuevo servicio de dominio con el modelo de scoring indicado
+func NewDomainServiceWithModel(model *scoring.Model) Service {
+	return NewDomainServiceWithClock(model, time.Now)
+}
+
+// NewDomainServiceWithClock crea un servicio de dominio que mide la antigüedad de las llamadas
+// respecto al reloj indicado (por ejemplo, la fecha simulada de un backtest)
+func NewDomainServiceWithClock(model *scoring.Model, now func() time.Time) Service {
+	return &DomainService{model: model, now: now}
 }
 
 // CalculatePriceChange calcula el cambio porcentual en el precio objetivo
@@ -27,56 +53,27 @@ func (s *DomainService) CalculatePriceChange(stock *Stock) float64 {
 
 // IsRatingUpgrade determina si el rating mejoró
 func (s *DomainService) IsRatingUpgrade(stock *Stock) bool {
-	return stock.IsRatingUpgrade()
+	return s.model.IsUpgrade(stock.RatingFrom.String(), stock.RatingTo.String())
 }
 
 // CalculateRecommendationScore calcula un score de recomendación
-// Score = (priceChange * 0.5) + (ratingScore * 0.3) + (actionScore * 0.2)
+// Score = (priceChange * 0.5) + (ratingScore * 0.3) + (actionScore * 0.2) con los pesos por defecto
 func (s *DomainService) CalculateRecommendationScore(stock *Stock) float64 {
-	// Calcular cambio porcentual
-	priceChange := stock.CalculatePriceChange()
-
-	// Calcular score de rating
-	ratingScore := s.getRatingScore(stock.RatingTo)
-	if stock.IsRatingUpgrade() {
-		ratingScore += 2 // Bonus por upgrade
-	}
-
-	// Calcular score de acción
-	actionScore := s.getActionScore(stock.Action)
-
-	// Score final con pesos
-	finalScore := (priceChange * 0.5) + (float64(ratingScore) * 0.3) + (float64(actionScore) * 0.2)
-
-	return finalScore
+	return s.CalculateScoreBreakdown(stock).Total
 }
 
-// getRatingScore retorna un score numérico para el rating
-func (s *DomainService) getRatingScore(rating Rating) float64 {
-	switch rating {
-	case RatingStrongBuy:
-		return 5
-	case RatingBuy:
-		return 3
-	case RatingSpeculativeBuy:
-		return 2
-	case RatingMarketPerform:
-		return 1
-	default:
-		return 0
-	}
+// CalculateScoreBreakdown calcula el score de recomendación con sus componentes
+func (s *DomainService) CalculateScoreBreakdown(stock *Stock) scoring.Breakdown {
+	return s.model.Evaluate(scoring.Inputs{
+		PriceChange: stock.CalculatePriceChange(),
+		RatingFrom:  stock.RatingFrom.String(),
+		RatingTo:    stock.RatingTo.String(),
+		Action:      stock.Action,
+		Age:         stock.Age(s.now()),
+	})
 }
 
-// getActionScore retorna un score numérico para la acción
-func (s *DomainService) getActionScore(action string) float64 {
-	switch action {
-	case "target raised by", "target raised":
-		return 3
-	case "target lowered by", "target lowered":
-		return -2
-	case "initiated coverage":
-		return 1
-	default:
-		return 0
-	}
+// ScoringModel retorna el modelo de scoring en uso
+func (s *DomainService) ScoringModel() *scoring.Model {
+	return s.model
 }
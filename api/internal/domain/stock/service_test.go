@@ -2,7 +2,9 @@ package stock
 
 import (
 	"testing"
+	"time"
 
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -249,3 +251,55 @@ func TestDomainService_getActionScore(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainService_UsesScoringModel(t *testing.T) {
+	targetFrom, _ := NewPrice(100.0)
+	targetTo, _ := NewPrice(100.0) // No change
+
+	stock := &Stock{
+		TargetFrom: targetFrom,
+		TargetTo:   targetTo,
+		RatingFrom: RatingUnderperform,
+		RatingTo:   RatingUnderperform,
+		Action:     "Target Raised By",
+	}
+
+	t.Run("default model scores every canonical rating", func(t *testing.T) {
+		b := NewDomainService().CalculateScoreBreakdown(stock)
+		assert.Equal(t, -1.0, b.RatingScore)
+		assert.Equal(t, 3.0, b.ActionScore)
+		assert.InDelta(t, (-1*0.3)+(3*0.2), b.Total, 0.0001)
+	})
+
+	t.Run("custom model", func(t *testing.T) {
+		model := scoring.DefaultModel()
+		model.RatingScores[RatingUnderperform.String()] = -4
+		model.Weights = scoring.Weights{PriceChange: 0, Rating: 1, Action: 0}
+
+		service := NewDomainServiceWithModel(model)
+		assert.Same(t, model, service.ScoringModel())
+		assert.Equal(t, -4.0, service.CalculateRecommendationScore(stock))
+	})
+}
+
+func TestStock_HasMaterialChange(t *testing.T) {
+	price := func(v float64) Price {
+		p, _ := NewPrice(v)
+		return p
+	}
+	base := &Stock{Ticker: "AAPL", Brokerage: "UBS", Action: "target raised by",
+		RatingFrom: RatingNeutral, RatingTo: RatingBuy, TargetFrom: price(100), TargetTo: price(120)}
+
+	same := *base
+	same.EventTime = time.Now()
+	same.TargetTo = price(120.00)
+	assert.False(t, base.HasMaterialChange(&same))
+
+	raised := *base
+	raised.TargetTo = price(130)
+	assert.True(t, base.HasMaterialChange(&raised))
+
+	upgraded := *base
+	upgraded.RatingTo = RatingStrongBuy
+	assert.True(t, base.HasMaterialChange(&upgraded))
+}
@@ -0,0 +1,51 @@
+package stock
+
+// TargetChangeBucketEdges son los límites (en %) de los rangos de la distribución del cambio
+// del precio objetivo: por debajo del primero, entre cada par consecutivo y desde el último
+var TargetChangeBucketEdges = []float64{-20, -10, -5, 0, 5, 10, 20}
+
+// Stats contiene las agregaciones de las acciones que coinciden con un filtro
+type Stats struct {
+	Total       int
+	ByRating    []GroupCount // Por rating destino
+	ByAction    []GroupCount
+	ByBrokerage []GroupCount
+	Upgrades    int // El score del rating destino supera al de origen
+	Downgrades  int // El score del rating destino es menor que el de origen
+
+	// TargetChanges tiene un rango por cada tramo de TargetChangeBucketEdges, en orden
+	TargetChanges []Bucket
+}
+
+// GroupCount es el número de acciones con un mismo valor
+type GroupCount struct {
+	Value string
+	Count int
+}
+
+// Bucket es el número de acciones cuyo valor está en [Min, Max); nil no limita
+type Bucket struct {
+	Min   *float64
+	Max   *float64
+	Count int
+}
+
+// NewTargetChangeBuckets crea los rangos vacíos de la distribución del cambio del precio objetivo
+func NewTargetChangeBuckets() []Bucket {
+	buckets := make([]Bucket, len(TargetChangeBucketEdges)+1)
+	for i := range TargetChangeBucketEdges {
+		edge := TargetChangeBucketEdges[i]
+		buckets[i].Max = &edge
+		buckets[i+1].Min = &edge
+	}
+	return buckets
+}
+
+// UpgradeDowngradeRatio retorna las mejoras por cada empeoramiento de rating;
+// false si no hay empeoramientos
+func (s *Stats) UpgradeDowngradeRatio() (float64, bool) {
+	if s.Downgrades == 0 {
+		return 0, false
+	}
+	return float64(s.Upgrades) / float64(s.Downgrades), true
+}
@@ -0,0 +1,29 @@
+package stock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTargetChangeBuckets(t *testing.T) {
+	buckets := NewTargetChangeBuckets()
+	require.Len(t, buckets, len(TargetChangeBucketEdges)+1)
+
+	assert.Nil(t, buckets[0].Min)
+	assert.Equal(t, -20.0, *buckets[0].Max)
+	assert.Equal(t, -20.0, *buckets[1].Min)
+	assert.Equal(t, -10.0, *buckets[1].Max)
+	assert.Equal(t, 20.0, *buckets[len(buckets)-1].Min)
+	assert.Nil(t, buckets[len(buckets)-1].Max)
+}
+
+func TestStats_UpgradeDowngradeRatio(t *testing.T) {
+	ratio, ok := (&Stats{Upgrades: 3, Downgrades: 2}).UpgradeDowngradeRatio()
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, ratio)
+
+	_, ok = (&Stats{Upgrades: 3}).UpgradeDowngradeRatio()
+	assert.False(t, ok)
+}
@@ -0,0 +1,145 @@
+package syncrun
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Errores del dominio
+var (
+	ErrRunNotFound = errors.New("sync run not found")
+)
+
+// Status representa el estado de una ejecución de sincronización
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// IsValid valida si el estado es válido
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusRunning, StatusSucceeded, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFinished retorna true si la ejecución ya terminó
+func (s Status) IsFinished() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// String retorna el string del estado
+func (s Status) String() string {
+	return string(s)
+}
+
+// Trigger indica qué originó una ejecución
+type Trigger string
+
+const (
+	TriggerManual    Trigger = "manual"
+	TriggerScheduled Trigger = "scheduled"
+)
+
+// String retorna el string del trigger
+func (t Trigger) String() string {
+	return string(t)
+}
+
+// Mode indica si una ejecución descarga todo o solo lo nuevo
+type Mode string
+
+const (
+	// ModeFull recorre todas las páginas de la API externa
+	ModeFull Mode = "full"
+	// ModeIncremental se detiene al alcanzar eventos ya ingeridos
+	ModeIncremental Mode = "incremental"
+)
+
+// IsValid valida si el modo es válido
+func (m Mode) IsValid() bool {
+	return m == ModeFull || m == ModeIncremental
+}
+
+// String retorna el string del modo
+func (m Mode) String() string {
+	return string(m)
+}
+
+// Run representa una ejecución de sincronización registrada en el ledger
+type Run struct {
+	ID           uuid.UUID
+	Status       Status
+	Trigger      Trigger
+	Mode         Mode
+	PagesFetched int
+	RowsUpserted int
+	RowsRejected int
+	Error        string
+	Instance     string // Proceso que la encoló; solo ese proceso la ejecuta
+	// NewestEventTime es el evento más reciente ingerido hasta esta ejecución;
+	// sirve de marca de agua para la siguiente sincronización incremental
+	NewestEventTime *time.Time
+	CreatedAt       time.Time  // Momento en que se encoló
+	StartedAt       *time.Time // nil mientras está pendiente
+	FinishedAt      *time.Time // nil mientras no ha terminado
+}
+
+// NewRun crea una nueva ejecución pendiente del proceso instance
+func NewRun(instance string, trigger Trigger, mode Mode) *Run {
+	return &Run{
+		ID:        uuid.New(),
+		Status:    StatusPending,
+		Trigger:   trigger,
+		Mode:      mode,
+		Instance:  instance,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Start marca la ejecución como en curso
+func (r *Run) Start() {
+	now := time.Now()
+	r.Status = StatusRunning
+	r.StartedAt = &now
+}
+
+// Succeed marca la ejecución como exitosa con sus contadores
+func (r *Run) Succeed(pagesFetched, rowsUpserted, rowsRejected int, newestEventTime time.Time) {
+	now := time.Now()
+	r.Status = StatusSucceeded
+	r.PagesFetched = pagesFetched
+	r.RowsUpserted = rowsUpserted
+	r.RowsRejected = rowsRejected
+	if !newestEventTime.IsZero() {
+		r.NewestEventTime = &newestEventTime
+	}
+	r.FinishedAt = &now
+}
+
+// Fail marca la ejecución como fallida con el error ocurrido
+func (r *Run) Fail(err error) {
+	now := time.Now()
+	r.Status = StatusFailed
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.FinishedAt = &now
+}
+
+// Duration retorna la duración de la ejecución, o cero si no ha terminado
+func (r *Run) Duration() time.Duration {
+	if r.StartedAt == nil || r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(*r.StartedAt)
+}
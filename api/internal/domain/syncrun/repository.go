@@ -0,0 +1,32 @@
+package syncrun
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository define la interfaz del repositorio del ledger de sincronizaciones
+type Repository interface {
+	// Create registra una nueva ejecución
+	Create(ctx context.Context, run *Run) error
+
+	// Update actualiza el estado y los contadores de una ejecución
+	Update(ctx context.Context, run *Run) error
+
+	// FindByID busca una ejecución por ID
+	FindByID(ctx context.Context, id uuid.UUID) (*Run, error)
+
+	// FindRecent retorna las últimas ejecuciones, de la más reciente a la más antigua
+	FindRecent(ctx context.Context, limit int) ([]*Run, error)
+
+	// FindLatest retorna la ejecución más reciente con el estado indicado
+	// (cualquier estado si status es vacío)
+	FindLatest(ctx context.Context, status Status) (*Run, error)
+
+	// FailUnfinished marca como fallidas las ejecuciones pendientes o en curso del proceso
+	// instance (por ejemplo, tras su reinicio) y las de cualquier proceso encoladas antes de
+	// staleBefore (cero para ninguna); retorna cuántas se marcaron
+	FailUnfinished(ctx context.Context, instance string, staleBefore time.Time, reason string) (int, error)
+}
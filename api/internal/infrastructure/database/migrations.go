@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"io/fs"
 	"os"
@@ -16,7 +17,8 @@ type MigrationInfo struct {
 	SQL      string
 }
 
-// RunMigrations ejecuta todas las migraciones en orden
+// RunMigrations ejecuta en orden las migraciones que aún no se aplicaron y las registra
+// en schema_migrations
 func RunMigrations() error {
 	db := GetDB()
 	if db == nil {
@@ -28,7 +30,21 @@ func RunMigrations() error {
 		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	for _, migration := range migrations {
+	return runMigrations(db, migrations)
+}
+
+// runMigrations aplica las migraciones pendientes de la lista
+func runMigrations(db *sql.DB, migrations []MigrationInfo) error {
+	if _, err := db.Exec(createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	pending, err := pendingMigrations(db, migrations)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
 		// Dividir el SQL en statements individuales y ejecutarlos uno por uno
 		// Esto es necesario porque algunos drivers/SGBD requieren statements separados
 		statements := splitSQLStatements(migration.SQL)
@@ -67,35 +83,99 @@ func RunMigrations() error {
 					migration.Filename, i+1, err, statement)
 			}
 		}
+
+		if _, err := db.Exec(`INSERT INTO schema_migrations (filename) VALUES ($1)`, migration.Filename); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Filename, err)
+		}
 		fmt.Printf("✓ Migration %s executed successfully\n", migration.Filename)
 	}
 
 	return nil
 }
 
-// CheckMigrations verifica si las tablas existen en la base de datos
+// createMigrationsTable crea la tabla que registra las migraciones aplicadas.
+// Las bases creadas antes de existir esta tabla vuelven a ejecutar todas las migraciones
+// una vez, por eso cada migración debe ser idempotente (IF NOT EXISTS, WHERE ... IS NULL).
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		filename VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT now()
+	)`
+
+// CheckMigrations verifica si todas las migraciones están aplicadas
 func CheckMigrations() (bool, error) {
+	pending, err := PendingMigrations()
+	if err != nil {
+		return false, err
+	}
+	return len(pending) == 0, nil
+}
+
+// PendingMigrations retorna los nombres de las migraciones que aún no se aplicaron
+func PendingMigrations() ([]string, error) {
 	db := GetDB()
 	if db == nil {
-		return false, fmt.Errorf("database connection is not initialized")
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	// Verificar si la tabla stocks existe
+	pending, err := pendingMigrations(db, migrations)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(pending))
+	for i, migration := range pending {
+		names[i] = migration.Filename
+	}
+	return names, nil
+}
+
+// pendingMigrations filtra las migraciones que no están registradas en schema_migrations
+func pendingMigrations(db *sql.DB, migrations []MigrationInfo) ([]MigrationInfo, error) {
 	var exists bool
 	query := `
 		SELECT EXISTS (
-			SELECT 1 FROM information_schema.tables 
-			WHERE table_schema = 'public' 
-			AND table_name = 'stocks'
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public'
+			AND table_name = 'schema_migrations'
 		)
 	`
+	if err := db.QueryRow(query).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if !exists {
+		return migrations, nil
+	}
 
-	err := db.QueryRow(query).Scan(&exists)
+	rows, err := db.Query(`SELECT filename FROM schema_migrations`)
 	if err != nil {
-		return false, fmt.Errorf("failed to check migrations: %w", err)
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[filename] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
 	}
 
-	return exists, nil
+	var pending []MigrationInfo
+	for _, migration := range migrations {
+		if !applied[migration.Filename] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
 }
 
 // ResetDatabase elimina todas las tablas y las recrea
@@ -109,6 +189,12 @@ func ResetDatabase() error {
 	dropQueries := []string{
 		"DROP TRIGGER IF EXISTS update_stocks_updated_at ON stocks",
 		"DROP FUNCTION IF EXISTS update_updated_at_column()",
+		"DROP TABLE IF EXISTS schema_migrations CASCADE",
+		"DROP TABLE IF EXISTS scoring_parameters CASCADE",
+		"DROP TABLE IF EXISTS rating_aliases CASCADE",
+		"DROP TABLE IF EXISTS quarantined_records CASCADE",
+		"DROP TABLE IF EXISTS sync_runs CASCADE",
+		"DROP TABLE IF EXISTS rating_events CASCADE",
 		"DROP TABLE IF EXISTS stocks CASCADE",
 	}
 
@@ -0,0 +1,21 @@
+-- Migration: Create rating_events table
+-- Guarda cada llamada de analista en lugar de sobrescribir una fila por ticker
+
+CREATE TABLE IF NOT EXISTS rating_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticker VARCHAR(10) NOT NULL,
+    company_name VARCHAR(255) NOT NULL,
+    brokerage VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL DEFAULT '',
+    rating_from VARCHAR(50) NOT NULL,
+    rating_to VARCHAR(50) NOT NULL,
+    target_from DECIMAL(10,2) NOT NULL,
+    target_to DECIMAL(10,2) NOT NULL,
+    event_time TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT now(),
+    CONSTRAINT uq_rating_events_dedup UNIQUE (ticker, brokerage, action, event_time)
+);
+
+-- Índice para consultar el historial de un ticker ordenado por fecha
+CREATE INDEX IF NOT EXISTS idx_rating_events_ticker_time ON rating_events(ticker, event_time DESC);
+CREATE INDEX IF NOT EXISTS idx_rating_events_brokerage ON rating_events(brokerage);
@@ -0,0 +1,9 @@
+-- Migration: Add event_time to stocks
+-- Momento en que el analista hizo la llamada (campo "time" de la API externa)
+
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS event_time TIMESTAMP;
+
+-- Las filas existentes solo conocen la fecha de sincronización
+UPDATE stocks SET event_time = created_at WHERE event_time IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stocks_event_time ON stocks(event_time);
@@ -0,0 +1,18 @@
+-- Migration: Create sync_runs table
+-- Ledger de ejecuciones de sincronización con la API externa
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status VARCHAR(20) NOT NULL,
+    triggered_by VARCHAR(20) NOT NULL,
+    pages_fetched INT NOT NULL DEFAULT 0,
+    rows_upserted INT NOT NULL DEFAULT 0,
+    rows_rejected INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_created_at ON sync_runs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_status_created_at ON sync_runs(status, created_at DESC);
@@ -0,0 +1,6 @@
+-- Migration: Add incremental sync columns to sync_runs
+-- mode: full | incremental
+-- newest_event_time: marca de agua para la siguiente sincronización incremental
+
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'full';
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS newest_event_time TIMESTAMP;
@@ -0,0 +1,18 @@
+-- Migration: Create quarantined_records table
+-- Registros de la API externa rechazados por validación, para inspección y reprocesamiento
+
+CREATE TABLE IF NOT EXISTS quarantined_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sync_run_id UUID REFERENCES sync_runs(id) ON DELETE SET NULL,
+    ticker VARCHAR(50) NOT NULL DEFAULT '',
+    raw_payload JSONB NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    last_attempt_at TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantined_records_status_created_at ON quarantined_records(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_quarantined_records_sync_run_id ON quarantined_records(sync_run_id);
@@ -0,0 +1,32 @@
+-- Migration: Rating normalization
+-- Alias configurables para traducir los ratings de cada broker a un rating canónico.
+-- Los alias incorporados viven en el código; esta tabla los amplía o sobrescribe.
+-- El alias se guarda en minúsculas y con espacios colapsados.
+
+CREATE TABLE IF NOT EXISTS rating_aliases (
+    alias VARCHAR(100) PRIMARY KEY,
+    rating VARCHAR(50) NOT NULL,
+    score DECIMAL(6,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Rating original enviado por el broker y score asignado al normalizarlo
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_from_raw VARCHAR(100);
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_to_raw VARCHAR(100);
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_from_score DECIMAL(6,2);
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_to_score DECIMAL(6,2);
+
+ALTER TABLE rating_events ADD COLUMN IF NOT EXISTS rating_from_raw VARCHAR(100);
+ALTER TABLE rating_events ADD COLUMN IF NOT EXISTS rating_to_raw VARCHAR(100);
+ALTER TABLE rating_events ADD COLUMN IF NOT EXISTS rating_from_score DECIMAL(6,2);
+ALTER TABLE rating_events ADD COLUMN IF NOT EXISTS rating_to_score DECIMAL(6,2);
+
+-- Las filas existentes solo guardaron el rating ya canónico
+UPDATE stocks SET rating_from_raw = rating_from WHERE rating_from_raw IS NULL;
+UPDATE stocks SET rating_to_raw = rating_to WHERE rating_to_raw IS NULL;
+UPDATE rating_events SET rating_from_raw = rating_from WHERE rating_from_raw IS NULL;
+UPDATE rating_events SET rating_to_raw = rating_to WHERE rating_to_raw IS NULL;
+
+-- Los scores de las filas existentes dependen del modelo de scoring y de los alias vigentes;
+-- los completa el servidor al iniciar (RatingAliasService.BackfillScores)
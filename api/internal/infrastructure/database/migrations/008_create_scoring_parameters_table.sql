@@ -0,0 +1,12 @@
+-- Migration: Create scoring_parameters table
+-- Parámetros del modelo de scoring de recomendaciones. Las filas sobrescriben los valores por defecto:
+--   kind = 'rating' (name = rating canónico), 'action' (name = acción en minúsculas),
+--   'weight' (name = priceChange, rating o action), 'bonus' (name = upgrade)
+
+CREATE TABLE IF NOT EXISTS scoring_parameters (
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    value DECIMAL(10,4) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (kind, name)
+);
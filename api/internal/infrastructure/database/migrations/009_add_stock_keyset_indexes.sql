@@ -0,0 +1,9 @@
+-- Migration: Add keyset pagination indexes to stocks
+-- La paginación por cursor ordena por (campo, id); estos índices evitan ordenar la tabla completa en cada página
+
+CREATE INDEX IF NOT EXISTS idx_stocks_ticker_id ON stocks(ticker, id);
+CREATE INDEX IF NOT EXISTS idx_stocks_company_name_id ON stocks(company_name, id);
+CREATE INDEX IF NOT EXISTS idx_stocks_rating_to_id ON stocks(rating_to, id);
+CREATE INDEX IF NOT EXISTS idx_stocks_target_to_id ON stocks(target_to, id);
+CREATE INDEX IF NOT EXISTS idx_stocks_created_at_id ON stocks(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_stocks_event_time_id ON stocks(event_time, id);
@@ -0,0 +1,11 @@
+-- Migration: Add indexes for computed sort fields
+-- Ordenar y paginar por cambio porcentual del precio objetivo o por delta de rating usa estas
+-- expresiones (deben coincidir con las de CockroachStockRepository). El score depende del modelo
+-- de scoring y de la fecha, por eso no se indexa.
+
+CREATE INDEX IF NOT EXISTS idx_stocks_price_change_id ON stocks (
+    (CASE WHEN target_from = 0 THEN 0 ELSE (target_to - target_from) / target_from * 100 END), id
+);
+CREATE INDEX IF NOT EXISTS idx_stocks_rating_delta_id ON stocks (
+    (COALESCE(rating_to_score, 0) - COALESCE(rating_from_score, 0)), id
+);
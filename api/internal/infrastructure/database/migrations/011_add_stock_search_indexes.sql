@@ -0,0 +1,7 @@
+-- Migration: Add trigram indexes for stock search
+-- La búsqueda compara ticker, company_name y brokerage con ILIKE '%term%' y similitud de trigramas;
+-- un índice B-tree como idx_stocks_company_name no sirve para esos operadores, los índices GIN de trigramas sí.
+
+CREATE INDEX IF NOT EXISTS idx_stocks_ticker_trgm ON stocks USING GIN (ticker gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_stocks_company_name_trgm ON stocks USING GIN (company_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_stocks_brokerage_trgm ON stocks USING GIN (brokerage gin_trgm_ops);
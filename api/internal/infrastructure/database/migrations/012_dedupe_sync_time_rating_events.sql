@@ -0,0 +1,20 @@
+-- Migration: Remove rating events duplicated per sync
+-- Antes de guardar el momento de la llamada (003_add_event_time_to_stocks), cada evento usaba la hora
+-- de la sincronización como event_time, así que la clave de deduplicación no reconocía la misma
+-- llamada en sincronizaciones sucesivas. Esas filas tienen event_time prácticamente igual a
+-- created_at; se borran cuando existe la misma llamada con un event_time anterior (la hora real
+-- del upstream o la primera sincronización que la vio).
+
+DELETE FROM rating_events AS e
+WHERE e.event_time BETWEEN e.created_at - INTERVAL '1 minute' AND e.created_at + INTERVAL '1 minute'
+  AND EXISTS (
+    SELECT 1 FROM rating_events AS o
+    WHERE o.ticker = e.ticker
+      AND o.brokerage = e.brokerage
+      AND o.action = e.action
+      AND o.rating_from = e.rating_from
+      AND o.rating_to = e.rating_to
+      AND o.target_from = e.target_from
+      AND o.target_to = e.target_to
+      AND (o.event_time < e.event_time OR (o.event_time = e.event_time AND o.id < e.id))
+  );
@@ -0,0 +1,22 @@
+-- Migration: Deduplicate quarantined records
+-- Cada sincronización completa vuelve a descargar los mismos registros inválidos; payload_hash
+-- identifica un registro por ticker y payload para guardarlo una sola vez (ON CONFLICT en
+-- CockroachQuarantineRepository) y contar las veces que se volvió a rechazar en attempts.
+
+ALTER TABLE quarantined_records ADD COLUMN IF NOT EXISTS payload_hash STRING
+    AS (sha256(ticker || raw_payload::STRING)) STORED;
+
+-- Conservar una fila por registro: la resuelta si existe, si no la más antigua
+DELETE FROM quarantined_records AS q
+WHERE EXISTS (
+    SELECT 1 FROM quarantined_records AS o
+    WHERE o.payload_hash = q.payload_hash
+      AND o.id <> q.id
+      AND (
+        (o.status = 'resolved' AND q.status <> 'resolved')
+        OR ((o.status = 'resolved') = (q.status = 'resolved')
+            AND (o.created_at < q.created_at OR (o.created_at = q.created_at AND o.id < q.id)))
+      )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quarantined_records_payload_hash ON quarantined_records(payload_hash);
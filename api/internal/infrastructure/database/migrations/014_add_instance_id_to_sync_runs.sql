@@ -0,0 +1,6 @@
+-- Migration: Add instance_id to sync_runs
+-- Cada réplica encola y ejecuta sus sincronizaciones en memoria; instance_id permite que al
+-- reiniciar un proceso solo marque como fallidas sus propias ejecuciones a medias y no las de
+-- otras réplicas. Las filas anteriores quedan sin instancia y solo se limpian por antigüedad.
+
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS instance_id VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration: Add created_at index to rating_events
+-- Las estadísticas de brokerages se calculan sobre todo el historial y se reutilizan mientras
+-- max(created_at) no cambie; el índice evita recorrer la tabla para obtenerlo.
+
+CREATE INDEX IF NOT EXISTS idx_rating_events_created_at ON rating_events(created_at);
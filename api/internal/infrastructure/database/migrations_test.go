@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMigrations_AppliesOnlyPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations := []MigrationInfo{
+		{Filename: "001_create.sql", SQL: "CREATE TABLE IF NOT EXISTS a (id INT);"},
+		{Filename: "002_add_column.sql", SQL: "ALTER TABLE a ADD COLUMN IF NOT EXISTS b INT;\nCREATE INDEX IF NOT EXISTS idx_a_b ON a(b);"},
+	}
+
+	// Una base existente ya tiene 001: solo se aplican las columnas e índices de 002
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT filename FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"filename"}).AddRow("001_create.sql"))
+	mock.ExpectExec(`ALTER TABLE a ADD COLUMN IF NOT EXISTS b INT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS idx_a_b`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs("002_add_column.sql").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, runMigrations(db, migrations))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPendingMigrations_WithoutLedger(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations := []MigrationInfo{{Filename: "001_create.sql"}, {Filename: "002_add_column.sql"}}
+
+	// Las bases anteriores a schema_migrations vuelven a aplicar todas las migraciones
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	pending, err := pendingMigrations(db, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, migrations, pending)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 	"golang.org/x/time/rate"
 )
@@ -94,12 +95,49 @@ type KarenAIClient struct {
 	cache      Cache
 	maxRetries int
 	retryDelay time.Duration
+	normalizer *stock.RatingNormalizer
 }
 
 // APIResponse representa la respuesta de la API externa
 type APIResponse struct {
-	Items    []StockDTO `json:"items"`
-	NextPage string     `json:"next_page,omitempty"`
+	Items    []StockDTO        `json:"items"`
+	NextPage string            `json:"next_page,omitempty"`
+	RawItems []json.RawMessage `json:"-"` // JSON original de cada item, en el orden de Items
+}
+
+// UnmarshalJSON decodifica la respuesta conservando el JSON original de cada item
+func (r *APIResponse) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Items    []json.RawMessage `json:"items"`
+		NextPage string            `json:"next_page"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	items := make([]StockDTO, len(raw.Items))
+	for i, item := range raw.Items {
+		if err := json.Unmarshal(item, &items[i]); err != nil {
+			return fmt.Errorf("invalid item %d: %w", i, err)
+		}
+	}
+
+	r.Items = items
+	r.NextPage = raw.NextPage
+	r.RawItems = raw.Items
+	return nil
+}
+
+// rawItem retorna el JSON original del item i, o el DTO serializado si no se conserva
+func (r *APIResponse) rawItem(i int) []byte {
+	if i < len(r.RawItems) {
+		return r.RawItems[i]
+	}
+	raw, err := json.Marshal(r.Items[i])
+	if err != nil {
+		return []byte("{}")
+	}
+	return raw
 }
 
 // StockDTO representa un stock en la respuesta de la API
@@ -127,6 +165,7 @@ func NewKarenAIClient(baseURL, apiKey string) *KarenAIClient {
 		cache:       NewInMemoryCache(),
 		maxRetries:  3,
 		retryDelay:  1 * time.Second,
+		normalizer:  stock.NewRatingNormalizer(scoring.DefaultModel(), nil),
 	}
 }
 
@@ -145,9 +184,16 @@ func NewKarenAIClientWithOptions(baseURL, apiKey string, requestsPerSecond float
 		cache:       cache,
 		maxRetries:  maxRetries,
 		retryDelay:  1 * time.Second,
+		normalizer:  stock.NewRatingNormalizer(scoring.DefaultModel(), nil),
 	}
 }
 
+// SetRatingNormalizer establece el normalizador usado para traducir los ratings de la API.
+// Por defecto solo se usan los alias incorporados, con los scores del modelo por defecto.
+func (c *KarenAIClient) SetRatingNormalizer(normalizer *stock.RatingNormalizer) {
+	c.normalizer = normalizer
+}
+
 // FetchStocks obtiene stocks de la API externa con paginación
 // Incluye rate limiting, retry logic y caching
 func (c *KarenAIClient) FetchStocks(ctx context.Context, nextPage string) (*APIResponse, error) {
@@ -237,41 +283,104 @@ func (c *KarenAIClient) fetchPage(ctx context.Context, nextPage string) (*APIRes
 	return &apiResp, nil
 }
 
+// FetchOptions controla cómo se recorren las páginas de la API externa
+type FetchOptions struct {
+	// Since activa el modo incremental: solo se retornan eventos desde esta marca de agua
+	// (inclusive; los eventos ya ingeridos se descartan al insertarlos por su clave única)
+	// y el paginado se detiene en la primera página en la que todos los eventos válidos son
+	// anteriores a ella. El corte asume que la API entrega las páginas de la más reciente a
+	// la más antigua; dentro de una página el orden no importa.
+	// Un valor cero recorre todas las páginas.
+	Since time.Time
+
+	// NoCache ignora tanto la cache de páginas como la de resultados y no guarda nada en
+	// ellas: las sincronizaciones deben ver siempre el estado actual de la API.
+	NoCache bool
+}
+
+// RejectedRecord es un DTO que no pudo convertirse a entidad de dominio
+type RejectedRecord struct {
+	DTO        StockDTO
+	RawPayload []byte // Item tal como lo envió la API, en JSON
+	Err        error
+}
+
+// FetchResult contiene los stocks obtenidos de la API y estadísticas de la descarga
+type FetchResult struct {
+	Stocks          []*stock.Stock
+	PagesFetched    int
+	Rejections      []RejectedRecord // DTOs que no pudieron convertirse a entidades de dominio
+	NewestEventTime time.Time        // Evento más reciente visto en la descarga
+	StoppedEarly    bool             // true si el modo incremental cortó el paginado
+}
+
 // FetchAllStocks obtiene todas las páginas de stocks
 // Incluye caching para evitar requests innecesarias
-func (c *KarenAIClient) FetchAllStocks(ctx context.Context) ([]*stock.Stock, error) {
+func (c *KarenAIClient) FetchAllStocks(ctx context.Context, opts FetchOptions) (*FetchResult, error) {
+	incremental := !opts.Since.IsZero()
+
 	// Verificar cache para todos los stocks
 	cacheKey := "stocks:all"
-	if cached, ok := c.cache.Get(cacheKey); ok {
-		return cached.([]*stock.Stock), nil
+	if incremental {
+		cacheKey = fmt.Sprintf("stocks:since:%d", opts.Since.UnixNano())
+	}
+	if !opts.NoCache {
+		if cached, ok := c.cache.Get(cacheKey); ok {
+			return cached.(*FetchResult), nil
+		}
 	}
 
-	var allStocks []*stock.Stock
+	result := &FetchResult{}
 	nextPage := ""
 	pageCount := 0
 
 	for {
-		response, err := c.FetchStocks(ctx, nextPage)
+		var response *APIResponse
+		var err error
+		if opts.NoCache {
+			response, err = c.fetchWithRetry(ctx, nextPage)
+		} else {
+			response, err = c.FetchStocks(ctx, nextPage)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error fetching page %d: %w", pageCount, err)
 		}
+		result.PagesFetched++
 
 		// Convertir DTOs a entidades de dominio
 		convertedCount := 0
-		for _, dto := range response.Items {
+		newCount := 0
+		for i, dto := range response.Items {
 			s, err := c.convertToDomainEntity(dto)
 			if err != nil {
-				// Log error pero continuar con otros stocks
-				// Podríamos agregar logging aquí si fuera necesario
+				result.Rejections = append(result.Rejections, RejectedRecord{
+					DTO:        dto,
+					RawPayload: response.rawItem(i),
+					Err:        err,
+				})
 				continue
 			}
-			allStocks = append(allStocks, s)
 			convertedCount++
+
+			if s.EventTime.After(result.NewestEventTime) {
+				result.NewestEventTime = s.EventTime
+			}
+
+			// En modo incremental, los eventos anteriores a la marca de agua ya se ingirieron.
+			// Los que coinciden con ella se conservan: otro evento pudo llegar en el mismo instante.
+			if incremental && s.EventTime.Before(opts.Since) {
+				continue
+			}
+			result.Stocks = append(result.Stocks, s)
+			newCount++
 		}
 
-		// Si no se convirtió ningún stock en esta página, puede ser un problema
-		if len(response.Items) > 0 && convertedCount == 0 {
-			return nil, fmt.Errorf("failed to convert any stocks from page %d (received %d stocks)", pageCount, len(response.Items))
+		// En modo incremental, una página sin eventos nuevos indica que ya alcanzamos lo ingerido:
+		// con páginas de la más reciente a la más antigua, las siguientes son todavía anteriores.
+		// Una página sin ningún item válido no detiene la descarga; sus rechazos van a cuarentena.
+		if incremental && convertedCount > 0 && newCount == 0 {
+			result.StoppedEarly = true
+			break
 		}
 
 		// Verificar si hay más páginas
@@ -282,20 +391,42 @@ func (c *KarenAIClient) FetchAllStocks(ctx context.Context) ([]*stock.Stock, err
 		pageCount++
 	}
 
-	if len(allStocks) == 0 {
+	// En modo incremental es válido no encontrar nada nuevo, y si todo se rechazó los rechazos
+	// se ponen en cuarentena
+	if len(result.Stocks) == 0 && len(result.Rejections) == 0 && !incremental {
 		return nil, fmt.Errorf("no stocks found after fetching all pages")
 	}
 
 	// Guardar en cache (TTL de 10 minutos para todos los stocks)
-	c.cache.Set(cacheKey, allStocks, 10*time.Minute)
+	if !opts.NoCache {
+		c.cache.Set(cacheKey, result, 10*time.Minute)
+	}
+
+	return result, nil
+}
 
-	return allStocks, nil
+// ConvertRawStock convierte un item de la API en JSON a una entidad de dominio.
+// Se usa para reprocesar registros en cuarentena con el parser actual.
+func (c *KarenAIClient) ConvertRawStock(raw []byte) (*stock.Stock, error) {
+	var dto StockDTO
+	if err := json.Unmarshal(raw, &dto); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return c.convertToDomainEntity(dto)
 }
 
 // convertToDomainEntity convierte un DTO a una entidad de dominio
 func (c *KarenAIClient) convertToDomainEntity(dto StockDTO) (*stock.Stock, error) {
-	ratingFrom := stock.Rating(dto.RatingFrom)
-	ratingTo := stock.Rating(dto.RatingTo)
+	// Cada broker usa su propio vocabulario; se traduce al rating canónico
+	ratingFrom, err := c.normalizer.Normalize(dto.RatingFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rating_from: %w", err)
+	}
+
+	ratingTo, err := c.normalizer.Normalize(dto.RatingTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rating_to: %w", err)
+	}
 
 	// Parsear precios que vienen como strings con $, ej: "$3.00"
 	targetFromFloat, err := parsePriceString(dto.TargetFrom)
@@ -318,16 +449,71 @@ func (c *KarenAIClient) convertToDomainEntity(dto StockDTO) (*stock.Stock, error
 		return nil, fmt.Errorf("invalid target_to: %w", err)
 	}
 
-	return stock.NewStock(
+	s, err := stock.NewStock(
 		dto.Ticker,
 		dto.Company,
 		dto.Brokerage,
 		dto.Action,
-		ratingFrom,
-		ratingTo,
+		ratingFrom.Rating,
+		ratingTo.Rating,
 		targetFrom,
 		targetTo,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SetNormalizedRatings(ratingFrom, ratingTo); err != nil {
+		return nil, err
+	}
+
+	// Si la API no envía el momento de la llamada, se conserva la fecha de sincronización
+	if strings.TrimSpace(dto.Time) != "" {
+		eventTime, err := parseEventTime(dto.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time '%s': %w", dto.Time, err)
+		}
+		if err := s.SetEventTime(eventTime); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// eventTimeLayouts son los formatos de fecha aceptados para el campo time de la API
+var eventTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseEventTime parsea el campo time de la API en sus distintos formatos
+// (RFC3339, fecha y hora sin zona, solo fecha o epoch en segundos/milisegundos).
+// Las fechas sin zona horaria se interpretan como UTC.
+func parseEventTime(timeStr string) (time.Time, error) {
+	cleaned := strings.TrimSpace(timeStr)
+
+	// Epoch en segundos o milisegundos
+	if epoch, err := strconv.ParseInt(cleaned, 10, 64); err == nil {
+		if epoch > 1e12 {
+			return time.UnixMilli(epoch).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.Parse(layout, cleaned); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse time: unsupported format")
 }
 
 // parsePriceString parsea un string de precio como "$3.00" a float64
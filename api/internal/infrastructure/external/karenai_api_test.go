@@ -0,0 +1,412 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventTime(t *testing.T) {
+	expected := time.Date(2025, 1, 15, 0, 30, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"RFC3339 with nanoseconds", "2025-01-15T00:30:05.000000000Z", expected, false},
+		{"RFC3339 with offset", "2025-01-14T19:30:05-05:00", expected, false},
+		{"without timezone", "2025-01-15T00:30:05", expected, false},
+		{"space separated", "2025-01-15 00:30:05", expected, false},
+		{"date only", "2025-01-15", time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), false},
+		{"epoch seconds", "1736901005", expected, false},
+		{"epoch milliseconds", "1736901005000", expected, false},
+		{"surrounding spaces", "  2025-01-15T00:30:05Z ", expected, false},
+		{"invalid format", "15/01/2025", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseEventTime(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.expected.Equal(result), "expected %s, got %s", tt.expected, result)
+		})
+	}
+}
+
+func TestConvertToDomainEntity_EventTime(t *testing.T) {
+	client := &KarenAIClient{normalizer: stock.NewRatingNormalizer(scoring.DefaultModel(), nil)}
+
+	dto := StockDTO{
+		Ticker:     "AAPL",
+		Company:    "Apple Inc.",
+		Brokerage:  "Test Brokerage",
+		Action:     "target raised by",
+		RatingFrom: "Buy",
+		RatingTo:   "Strong Buy",
+		TargetFrom: "$100.00",
+		TargetTo:   "$120.00",
+		Time:       "2025-01-15T00:30:05.000000000Z",
+	}
+
+	t.Run("uses upstream event time", func(t *testing.T) {
+		s, err := client.convertToDomainEntity(dto)
+		assert.NoError(t, err)
+		assert.True(t, time.Date(2025, 1, 15, 0, 30, 5, 0, time.UTC).Equal(s.EventTime))
+	})
+
+	t.Run("falls back to sync time when missing", func(t *testing.T) {
+		noTime := dto
+		noTime.Time = ""
+		s, err := client.convertToDomainEntity(noTime)
+		assert.NoError(t, err)
+		assert.Equal(t, s.CreatedAt, s.EventTime)
+	})
+
+	t.Run("rejects unparsable time", func(t *testing.T) {
+		badTime := dto
+		badTime.Time = "yesterday"
+		_, err := client.convertToDomainEntity(badTime)
+		assert.Error(t, err)
+	})
+}
+
+func TestConvertToDomainEntity_RatingNormalization(t *testing.T) {
+	client := NewKarenAIClient("http://localhost", "key")
+	client.SetRatingNormalizer(stock.NewRatingNormalizer(scoring.DefaultModel(), []stock.RatingAlias{
+		{Alias: "conviction list", Rating: stock.RatingStrongBuy, Score: 5.5},
+	}))
+
+	dto := StockDTO{
+		Ticker:     "AAPL",
+		Company:    "Apple Inc.",
+		Brokerage:  "Test Brokerage",
+		Action:     "upgraded by",
+		RatingFrom: "Sector Perform",
+		RatingTo:   "Conviction List",
+		TargetFrom: "$100.00",
+		TargetTo:   "$120.00",
+	}
+
+	t.Run("maps raw ratings to canonical ratings", func(t *testing.T) {
+		s, err := client.convertToDomainEntity(dto)
+		assert.NoError(t, err)
+		assert.Equal(t, stock.RatingMarketPerform, s.RatingFrom)
+		assert.Equal(t, stock.RatingStrongBuy, s.RatingTo)
+		assert.Equal(t, "Sector Perform", s.RatingFromRaw)
+		assert.Equal(t, "Conviction List", s.RatingToRaw)
+		assert.Equal(t, 1.0, s.RatingFromScore)
+		assert.Equal(t, 5.5, s.RatingToScore)
+	})
+
+	t.Run("rejects unknown ratings", func(t *testing.T) {
+		unknown := dto
+		unknown.RatingTo = "Moonshot"
+		_, err := client.convertToDomainEntity(unknown)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "rating_to")
+	})
+}
+
+// newTestAPIServer sirve páginas encadenadas por next_page y cuenta las requests recibidas
+func newTestAPIServer(t *testing.T, pages map[string]APIResponse, requests *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		page, ok := pages[r.URL.Query().Get("next_page")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func testDTO(ticker, eventTime string) StockDTO {
+	return StockDTO{
+		Ticker:     ticker,
+		Company:    ticker + " Inc.",
+		Brokerage:  "Test Brokerage",
+		Action:     "target raised by",
+		RatingFrom: "Buy",
+		RatingTo:   "Strong Buy",
+		TargetFrom: "$100.00",
+		TargetTo:   "$120.00",
+		Time:       eventTime,
+	}
+}
+
+func TestFetchAllStocks_Incremental(t *testing.T) {
+	// La API entrega primero las llamadas más recientes
+	pages := map[string]APIResponse{
+		"": {
+			Items:    []StockDTO{testDTO("NEW1", "2025-01-20T10:00:00Z"), testDTO("NEW2", "2025-01-19T10:00:00Z")},
+			NextPage: "p2",
+		},
+		"p2": {
+			Items:    []StockDTO{testDTO("NEW3", "2025-01-18T10:00:00Z"), testDTO("OLD1", "2025-01-10T10:00:00Z")},
+			NextPage: "p3",
+		},
+		"p3": {
+			Items:    []StockDTO{testDTO("OLD2", "2025-01-09T10:00:00Z"), testDTO("OLD3", "2025-01-08T10:00:00Z")},
+			NextPage: "p4",
+		},
+		"p4": {
+			Items: []StockDTO{testDTO("OLD4", "2025-01-07T10:00:00Z")},
+		},
+	}
+
+	t.Run("stops at already ingested events", func(t *testing.T) {
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+		since := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{Since: since})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"NEW1", "NEW2", "NEW3", "OLD1"}, tickers(result.Stocks))
+		assert.Equal(t, 3, result.PagesFetched)
+		assert.Equal(t, 3, requests)
+		assert.True(t, result.StoppedEarly)
+		assert.True(t, time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC).Equal(result.NewestEventTime))
+	})
+
+	t.Run("nothing new is not an error", func(t *testing.T) {
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+		since := time.Date(2025, 1, 21, 10, 0, 0, 0, time.UTC)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{Since: since})
+		require.NoError(t, err)
+		assert.Empty(t, result.Stocks)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("keeps events at the watermark", func(t *testing.T) {
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+		since := time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{Since: since})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"NEW1"}, tickers(result.Stocks))
+		assert.Equal(t, 2, requests)
+		assert.True(t, result.StoppedEarly)
+	})
+
+	t.Run("full sync walks every page", func(t *testing.T) {
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, result.Stocks, 7)
+		assert.Equal(t, 4, result.PagesFetched)
+		assert.False(t, result.StoppedEarly)
+	})
+}
+
+func TestFetchAllStocks_IncrementalOrdering(t *testing.T) {
+	since := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	t.Run("does not stop while a page has new events in any position", func(t *testing.T) {
+		// Dentro de una página el orden no importa: basta un evento nuevo para seguir paginando
+		pages := map[string]APIResponse{
+			"":   {Items: []StockDTO{testDTO("OLD1", "2025-01-09T10:00:00Z"), testDTO("NEW1", "2025-01-20T10:00:00Z")}, NextPage: "p2"},
+			"p2": {Items: []StockDTO{testDTO("OLD2", "2025-01-08T10:00:00Z"), testDTO("NEW2", "2025-01-19T10:00:00Z")}, NextPage: "p3"},
+			"p3": {Items: []StockDTO{testDTO("OLD3", "2025-01-07T10:00:00Z")}, NextPage: "p4"},
+			"p4": {Items: []StockDTO{testDTO("OLD4", "2025-01-06T10:00:00Z")}},
+		}
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{Since: since})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"NEW1", "NEW2"}, tickers(result.Stocks))
+		assert.Equal(t, 3, requests)
+		assert.True(t, result.StoppedEarly)
+	})
+
+	t.Run("misses newer pages after an older one", func(t *testing.T) {
+		// Documenta el supuesto: si la API no entregara las páginas de la más reciente a la
+		// más antigua, lo que viene tras una página ya ingerida no se descarga
+		pages := map[string]APIResponse{
+			"":   {Items: []StockDTO{testDTO("OLD1", "2025-01-09T10:00:00Z")}, NextPage: "p2"},
+			"p2": {Items: []StockDTO{testDTO("NEW1", "2025-01-20T10:00:00Z")}},
+		}
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{Since: since})
+		require.NoError(t, err)
+		assert.Empty(t, result.Stocks)
+		assert.Equal(t, 1, requests)
+		assert.True(t, result.StoppedEarly)
+
+		// Una sincronización full no depende del orden
+		result, err = client.FetchAllStocks(context.Background(), FetchOptions{NoCache: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"OLD1", "NEW1"}, tickers(result.Stocks))
+	})
+}
+
+// tickers retorna los tickers de los stocks en orden
+func tickers(stocks []*stock.Stock) []string {
+	result := make([]string, len(stocks))
+	for i, s := range stocks {
+		result[i] = s.Ticker
+	}
+	return result
+}
+
+func TestFetchAllStocks_Rejections(t *testing.T) {
+	bad := testDTO("BAD", "2025-01-19T10:00:00Z")
+	bad.TargetTo = "$1,200.00"
+
+	pages := map[string]APIResponse{
+		"": {Items: []StockDTO{testDTO("GOOD", "2025-01-20T10:00:00Z"), bad}},
+	}
+
+	requests := 0
+	server := newTestAPIServer(t, pages, &requests)
+	defer server.Close()
+
+	client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+	result, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Stocks, 1)
+	require.Len(t, result.Rejections, 1)
+
+	rejection := result.Rejections[0]
+	assert.Equal(t, "BAD", rejection.DTO.Ticker)
+	assert.Contains(t, rejection.Err.Error(), "invalid target_to")
+	assert.Contains(t, string(rejection.RawPayload), `"target_to":"$1,200.00"`)
+
+	// El payload guardado puede volver a pasar por el parser
+	_, err = client.ConvertRawStock(rejection.RawPayload)
+	assert.Error(t, err)
+}
+
+func TestFetchAllStocks_RejectionKeepsRawItem(t *testing.T) {
+	// Campos desconocidos y formato originales deben llegar intactos a la cuarentena
+	badItem := `{"ticker":"BAD","company":"Bad Inc.","brokerage":"Test Brokerage","action":"target raised by","rating_from":"Buy","rating_to":"Buy","target_from":"$1.00","target_to":"$1,200.00","time":"2025-01-19T10:00:00Z","source":"feed-v2"}`
+	goodItem, err := json.Marshal(testDTO("GOOD", "2025-01-20T10:00:00Z"))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[` + string(goodItem) + `,` + badItem + `]}`))
+	}))
+	defer server.Close()
+
+	client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+	result, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Rejections, 1)
+	assert.Equal(t, badItem, string(result.Rejections[0].RawPayload))
+}
+
+func TestFetchAllStocks_NoCache(t *testing.T) {
+	pages := map[string]APIResponse{
+		"":   {Items: []StockDTO{testDTO("AAPL", "2025-01-20T10:00:00Z")}, NextPage: "p2"},
+		"p2": {Items: []StockDTO{testDTO("MSFT", "2025-01-19T10:00:00Z")}},
+	}
+
+	requests := 0
+	server := newTestAPIServer(t, pages, &requests)
+	defer server.Close()
+
+	client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+	// Sin NoCache la segunda descarga sale de la cache de resultados
+	_, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+	require.NoError(t, err)
+	_, err = client.FetchAllStocks(context.Background(), FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	// Con NoCache se piden todas las páginas aunque estén en la cache de páginas
+	pages[""] = APIResponse{Items: []StockDTO{testDTO("NVDA", "2025-01-21T10:00:00Z")}, NextPage: "p2"}
+	result, err := client.FetchAllStocks(context.Background(), FetchOptions{NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, 4, requests)
+	require.Len(t, result.Stocks, 2)
+	assert.Equal(t, "NVDA", result.Stocks[0].Ticker)
+
+	// Y el resultado tampoco se guarda en la cache
+	_, err = client.FetchAllStocks(context.Background(), FetchOptions{NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, 6, requests)
+}
+
+func TestFetchAllStocks_AllInvalidPage(t *testing.T) {
+	bad := testDTO("BAD", "2025-01-19T10:00:00Z")
+	bad.RatingTo = "Moonshot"
+
+	t.Run("continues past the page", func(t *testing.T) {
+		pages := map[string]APIResponse{
+			"":   {Items: []StockDTO{testDTO("AAPL", "2025-01-20T10:00:00Z")}, NextPage: "p2"},
+			"p2": {Items: []StockDTO{bad, bad}, NextPage: "p3"},
+			"p3": {Items: []StockDTO{testDTO("MSFT", "2025-01-18T10:00:00Z")}},
+		}
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"AAPL", "MSFT"}, tickers(result.Stocks))
+		assert.Len(t, result.Rejections, 2)
+		assert.Equal(t, 3, result.PagesFetched)
+	})
+
+	t.Run("returns the rejections when nothing is valid", func(t *testing.T) {
+		pages := map[string]APIResponse{"": {Items: []StockDTO{bad}}}
+		requests := 0
+		server := newTestAPIServer(t, pages, &requests)
+		defer server.Close()
+
+		client := NewKarenAIClientWithOptions(server.URL, "test-key", 1000, 1, nil)
+
+		result, err := client.FetchAllStocks(context.Background(), FetchOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, result.Stocks)
+		require.Len(t, result.Rejections, 1)
+		assert.Equal(t, "BAD", result.Rejections[0].DTO.Ticker)
+	})
+}
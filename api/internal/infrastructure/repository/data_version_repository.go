@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+)
+
+// CockroachDataVersionRepository calcula la versión de los datos que sirve la API a partir
+// del estado persistido, así que todas las instancias y reinicios coinciden en el mismo valor
+type CockroachDataVersionRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachDataVersionRepository crea un nuevo repositorio de versión de datos
+func NewCockroachDataVersionRepository() *CockroachDataVersionRepository {
+	return &CockroachDataVersionRepository{
+		db: database.GetDB(),
+	}
+}
+
+// dataVersionQuery lee las marcas de tiempo de cada escritura que cambia las respuestas
+// cacheables. Los conteos de alias y parámetros detectan los borrados, que no dejan marca.
+const dataVersionQuery = `
+	SELECT
+		(SELECT max(finished_at) FROM sync_runs WHERE status = $1),
+		(SELECT max(updated_at) FROM stocks),
+		(SELECT max(created_at) FROM rating_events),
+		(SELECT max(updated_at) FROM rating_aliases),
+		(SELECT count(*) FROM rating_aliases),
+		(SELECT max(updated_at) FROM scoring_parameters),
+		(SELECT count(*) FROM scoring_parameters)
+`
+
+// Current retorna la versión actual; dos lecturas con el mismo valor sirven los mismos datos
+func (r *CockroachDataVersionRepository) Current(ctx context.Context) (string, error) {
+	var (
+		lastSync, lastStock, lastEvent, lastAlias, lastParameter sql.NullTime
+		aliases, parameters                                      int64
+	)
+	err := r.db.QueryRowContext(ctx, dataVersionQuery, syncrun.StatusSucceeded.String()).Scan(
+		&lastSync, &lastStock, &lastEvent, &lastAlias, &aliases, &lastParameter, &parameters,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to query data version: %w", err)
+	}
+
+	parts := []string{
+		versionTime(lastSync),
+		versionTime(lastStock),
+		versionTime(lastEvent),
+		versionTime(lastAlias),
+		strconv.FormatInt(aliases, 10),
+		versionTime(lastParameter),
+		strconv.FormatInt(parameters, 10),
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// versionTime formatea una marca de tiempo de la versión; NULL (tabla vacía) es 0
+func versionTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "0"
+	}
+	return strconv.FormatInt(t.Time.UnixNano(), 10)
+}
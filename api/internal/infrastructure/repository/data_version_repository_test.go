@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachDataVersionRepository_Current(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachDataVersionRepository{db: db}
+	columns := []string{"sync", "stocks", "events", "aliases", "alias_count", "parameters", "parameter_count"}
+	synced := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT max\(finished_at\) FROM sync_runs WHERE status = \$1`).
+		WithArgs("succeeded").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(synced, synced, synced, nil, 0, nil, 0))
+	first, err := repo.Current(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "1704164645000000000.1704164645000000000.1704164645000000000.0.0.0.0", first)
+
+	// Borrar un alias no deja marca de tiempo pero cambia el conteo
+	mock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(synced, synced, synced, synced, 1, nil, 0))
+	withAlias, err := repo.Current(context.Background())
+	assert.NoError(t, err)
+	mock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(synced, synced, synced, synced, 0, nil, 0))
+	withoutAlias, err := repo.Current(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, withAlias)
+	assert.NotEqual(t, withAlias, withoutAlias)
+
+	mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+	_, err = repo.Current(context.Background())
+	assert.ErrorContains(t, err, "failed to query data version")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
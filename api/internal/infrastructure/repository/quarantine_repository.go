@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+)
+
+// CockroachQuarantineRepository implementa el repositorio de cuarentena para CockroachDB
+type CockroachQuarantineRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachQuarantineRepository crea un nuevo repositorio de cuarentena
+func NewCockroachQuarantineRepository() quarantine.Repository {
+	return &CockroachQuarantineRepository{
+		db: database.GetDB(),
+	}
+}
+
+// quarantineColumns son las columnas seleccionadas para construir una entidad Record
+const quarantineColumns = `id, sync_run_id, ticker, raw_payload, error, status, attempts,
+		       created_at, last_attempt_at, resolved_at`
+
+// SaveBatch guarda múltiples registros en cuarentena. Un registro ya guardado (mismo ticker y
+// payload) no se duplica: si sigue pendiente se actualizan el error, la ejecución y los intentos.
+func (r *CockroachQuarantineRepository) SaveBatch(ctx context.Context, records []*quarantine.Record) error {
+	records = uniqueRecords(records)
+	if len(records) == 0 {
+		return nil
+	}
+
+	batchSize := 100
+	for i := 0; i < len(records); i += batchSize {
+		end := i + batchSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		if err := r.insertBatch(ctx, records[i:end]); err != nil {
+			return fmt.Errorf("failed to insert batch %d-%d: %w", i, end, err)
+		}
+	}
+
+	return nil
+}
+
+// insertBatch inserta un batch de registros en cuarentena
+func (r *CockroachQuarantineRepository) insertBatch(ctx context.Context, records []*quarantine.Record) error {
+	valueStrings := make([]string, 0, len(records))
+	valueArgs := make([]interface{}, 0, len(records)*8)
+
+	for i, rec := range records {
+		offset := i * 8
+		valueStrings = append(valueStrings, fmt.Sprintf(
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			offset+1, offset+2, offset+3, offset+4,
+			offset+5, offset+6, offset+7, offset+8,
+		))
+
+		valueArgs = append(valueArgs,
+			rec.ID,
+			rec.SyncRunID,
+			rec.Ticker,
+			string(rec.RawPayload),
+			rec.Error,
+			rec.Status.String(),
+			rec.Attempts,
+			rec.CreatedAt,
+		)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO quarantined_records (
+			id, sync_run_id, ticker, raw_payload, error, status, attempts, created_at
+		) VALUES %s
+		ON CONFLICT (payload_hash) DO UPDATE SET
+			sync_run_id = excluded.sync_run_id,
+			error = excluded.error,
+			attempts = quarantined_records.attempts + 1,
+			last_attempt_at = excluded.created_at
+		WHERE quarantined_records.status = 'pending'
+	`, strings.Join(valueStrings, ","))
+
+	if _, err := r.db.ExecContext(ctx, query, valueArgs...); err != nil {
+		return fmt.Errorf("failed to insert quarantined records: %w", err)
+	}
+
+	return nil
+}
+
+// uniqueRecords descarta los registros repetidos de un batch; un mismo INSERT ... ON CONFLICT
+// no puede actualizar dos veces la misma fila
+func uniqueRecords(records []*quarantine.Record) []*quarantine.Record {
+	seen := make(map[string]bool, len(records))
+	unique := make([]*quarantine.Record, 0, len(records))
+	for _, rec := range records {
+		key := rec.Ticker + "\x00" + string(rec.RawPayload)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, rec)
+	}
+	return unique
+}
+
+// Update actualiza el estado de un registro tras un reprocesamiento
+func (r *CockroachQuarantineRepository) Update(ctx context.Context, rec *quarantine.Record) error {
+	query := `
+		UPDATE quarantined_records SET
+			error = $2,
+			status = $3,
+			attempts = $4,
+			last_attempt_at = $5,
+			resolved_at = $6
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rec.ID,
+		rec.Error,
+		rec.Status.String(),
+		rec.Attempts,
+		rec.LastAttemptAt,
+		rec.ResolvedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update quarantined record: %w", err)
+	}
+
+	return nil
+}
+
+// Find busca registros con filtros, del más reciente al más antiguo
+func (r *CockroachQuarantineRepository) Find(ctx context.Context, filter quarantine.Filter) ([]*quarantine.Record, error) {
+	query := "SELECT " + quarantineColumns + " FROM quarantined_records WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIndex)
+		args = append(args, filter.Status.String())
+		argIndex++
+	}
+
+	if filter.SyncRunID != nil {
+		query += fmt.Sprintf(" AND sync_run_id = $%d", argIndex)
+		args = append(args, *filter.SyncRunID)
+		argIndex++
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, filter.Limit)
+	}
+
+	return r.queryRecords(ctx, query, args...)
+}
+
+// FindByIDs busca registros por sus IDs
+func (r *CockroachQuarantineRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*quarantine.Record, error) {
+	if len(ids) == 0 {
+		return []*quarantine.Record{}, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM quarantined_records WHERE id IN (%s) ORDER BY created_at",
+		quarantineColumns, strings.Join(placeholders, ","),
+	)
+
+	return r.queryRecords(ctx, query, args...)
+}
+
+// queryRecords ejecuta una consulta y escanea los registros resultantes
+func (r *CockroachQuarantineRepository) queryRecords(ctx context.Context, query string, args ...interface{}) ([]*quarantine.Record, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*quarantine.Record
+	for rows.Next() {
+		var rec quarantine.Record
+		var syncRunID uuid.NullUUID
+		var rawPayload, status string
+		var lastAttemptAt, resolvedAt sql.NullTime
+
+		err := rows.Scan(
+			&rec.ID,
+			&syncRunID,
+			&rec.Ticker,
+			&rawPayload,
+			&rec.Error,
+			&status,
+			&rec.Attempts,
+			&rec.CreatedAt,
+			&lastAttemptAt,
+			&resolvedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined record: %w", err)
+		}
+
+		rec.RawPayload = []byte(rawPayload)
+		rec.Status = quarantine.Status(status)
+		if syncRunID.Valid {
+			rec.SyncRunID = &syncRunID.UUID
+		}
+		if lastAttemptAt.Valid {
+			rec.LastAttemptAt = &lastAttemptAt.Time
+		}
+		if resolvedAt.Valid {
+			rec.ResolvedAt = &resolvedAt.Time
+		}
+
+		records = append(records, &rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return records, nil
+}
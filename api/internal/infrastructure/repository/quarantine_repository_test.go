@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/quarantine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachQuarantineRepository_SaveBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachQuarantineRepository{db: db}
+	runID := uuid.New()
+	payload := []byte(`{"ticker":"BAD","target_to":"$1,200.00"}`)
+	rec := quarantine.NewRecord(runID, "BAD", payload, errors.New("invalid target_to '$1,200.00'"))
+
+	// El mismo registro rechazado dos veces en una descarga se inserta una sola vez
+	duplicate := quarantine.NewRecord(runID, "BAD", payload, errors.New("invalid target_to '$1,200.00'"))
+
+	mock.ExpectExec(`INSERT INTO quarantined_records .+ ON CONFLICT \(payload_hash\) DO UPDATE SET .+ attempts = quarantined_records.attempts \+ 1`).
+		WithArgs(rec.ID, &runID, "BAD", string(payload), rec.Error, "pending", 1, rec.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SaveBatch(context.Background(), []*quarantine.Record{rec, duplicate})
+	assert.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachQuarantineRepository_Find(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachQuarantineRepository{db: db}
+	columns := []string{
+		"id", "sync_run_id", "ticker", "raw_payload", "error", "status", "attempts",
+		"created_at", "last_attempt_at", "resolved_at",
+	}
+
+	t.Run("pending records of a run", func(t *testing.T) {
+		runID := uuid.New()
+		rows := sqlmock.NewRows(columns).
+			AddRow(uuid.New(), runID, "BAD", `{"ticker":"BAD"}`, "invalid rating_to: Overweight", "pending", 2, time.Now(), time.Now(), nil)
+
+		mock.ExpectQuery(`SELECT .+ FROM quarantined_records WHERE 1=1 AND status = \$1 AND sync_run_id = \$2 ORDER BY created_at DESC LIMIT \$3`).
+			WithArgs("pending", runID, 10).
+			WillReturnRows(rows)
+
+		records, err := repo.Find(context.Background(), quarantine.Filter{
+			Status:    quarantine.StatusPending,
+			SyncRunID: &runID,
+			Limit:     10,
+		})
+		assert.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, runID, *records[0].SyncRunID)
+		assert.Equal(t, `{"ticker":"BAD"}`, string(records[0].RawPayload))
+		assert.NotNil(t, records[0].LastAttemptAt)
+		assert.Nil(t, records[0].ResolvedAt)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+)
+
+// CockroachRatingAliasRepository implementa el repositorio de alias de ratings para CockroachDB
+type CockroachRatingAliasRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachRatingAliasRepository crea un nuevo repositorio de alias de ratings
+func NewCockroachRatingAliasRepository() stock.RatingAliasRepository {
+	return &CockroachRatingAliasRepository{
+		db: database.GetDB(),
+	}
+}
+
+// FindAll retorna todos los alias configurados ordenados por alias
+func (r *CockroachRatingAliasRepository) FindAll(ctx context.Context) ([]stock.RatingAlias, error) {
+	query := `SELECT alias, rating, score FROM rating_aliases ORDER BY alias`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rating aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []stock.RatingAlias
+	for rows.Next() {
+		var a stock.RatingAlias
+		var rating string
+		if err := rows.Scan(&a.Alias, &rating, &a.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan rating alias: %w", err)
+		}
+		a.Rating = stock.Rating(rating)
+		aliases = append(aliases, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// Save guarda o actualiza un alias (UPSERT); el alias se guarda normalizado
+func (r *CockroachRatingAliasRepository) Save(ctx context.Context, alias stock.RatingAlias) error {
+	query := `
+		INSERT INTO rating_aliases (alias, rating, score, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (alias)
+		DO UPDATE SET
+			rating = EXCLUDED.rating,
+			score = EXCLUDED.score,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		stock.NormalizeRatingKey(alias.Alias),
+		alias.Rating.String(),
+		alias.Score,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save rating alias: %w", err)
+	}
+
+	return nil
+}
+
+// unscoredColumns son las columnas de rating original y score de stocks y rating_events
+var unscoredColumns = []struct{ table, raw, score string }{
+	{"stocks", "COALESCE(rating_from_raw, rating_from)", "rating_from_score"},
+	{"stocks", "COALESCE(rating_to_raw, rating_to)", "rating_to_score"},
+	{"rating_events", "COALESCE(rating_from_raw, rating_from)", "rating_from_score"},
+	{"rating_events", "COALESCE(rating_to_raw, rating_to)", "rating_to_score"},
+}
+
+// FindUnscoredRatings retorna los ratings originales de stocks y eventos que no tienen score
+func (r *CockroachRatingAliasRepository) FindUnscoredRatings(ctx context.Context) ([]string, error) {
+	selects := make([]string, len(unscoredColumns))
+	for i, c := range unscoredColumns {
+		selects[i] = fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NULL", c.raw, c.table, c.score)
+	}
+
+	rows, err := r.db.QueryContext(ctx, strings.Join(selects, " UNION "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unscored ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var ratings []string
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan unscored rating: %w", err)
+		}
+		ratings = append(ratings, raw)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ratings, nil
+}
+
+// SetMissingScores asigna score a los stocks y eventos sin score cuyo rating original es raw.
+// Cada UPDATE solo toca filas sin score, así que repetirlo tras un fallo parcial es seguro.
+func (r *CockroachRatingAliasRepository) SetMissingScores(ctx context.Context, raw string, score float64) (int, error) {
+	updated := 0
+	for _, c := range unscoredColumns {
+		query := fmt.Sprintf("UPDATE %s SET %s = $2 WHERE %s IS NULL AND %s = $1", c.table, c.score, c.score, c.raw)
+		result, err := r.db.ExecContext(ctx, query, raw, score)
+		if err != nil {
+			return 0, fmt.Errorf("failed to set %s.%s: %w", c.table, c.score, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read affected rows: %w", err)
+		}
+		updated += int(affected)
+	}
+
+	return updated, nil
+}
+
+// Delete elimina un alias; retorna false si no existía
+func (r *CockroachRatingAliasRepository) Delete(ctx context.Context, alias string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rating_aliases WHERE alias = $1`, stock.NormalizeRatingKey(alias))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete rating alias: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachRatingAliasRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingAliasRepository{db: db}
+
+	t.Run("save stores normalized alias", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO rating_aliases .+ ON CONFLICT \(alias\)`).
+			WithArgs("sector outperform", "Buy", 3.0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Save(context.Background(), stock.RatingAlias{
+			Alias:  "  Sector   OUTPERFORM ",
+			Rating: stock.RatingBuy,
+			Score:  3,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("find all", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"alias", "rating", "score"}).
+			AddRow("outperform", "Buy", 3.0).
+			AddRow("top pick", "Strong Buy", 5.0)
+
+		mock.ExpectQuery(`SELECT alias, rating, score FROM rating_aliases ORDER BY alias`).
+			WillReturnRows(rows)
+
+		aliases, err := repo.FindAll(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, aliases, 2)
+		assert.Equal(t, stock.RatingStrongBuy, aliases[1].Rating)
+	})
+
+	t.Run("delete missing alias", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM rating_aliases WHERE alias = \$1`).
+			WithArgs("hold").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		deleted, err := repo.Delete(context.Background(), "Hold")
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachRatingAliasRepository_Backfill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingAliasRepository{db: db}
+
+	t.Run("find unscored ratings", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COALESCE\(rating_from_raw, rating_from\) FROM stocks WHERE rating_from_score IS NULL UNION .+ FROM rating_events WHERE rating_to_score IS NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"rating"}).AddRow("Buy").AddRow("Overweight"))
+
+		ratings, err := repo.FindUnscoredRatings(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Buy", "Overweight"}, ratings)
+	})
+
+	t.Run("set missing scores in both tables", func(t *testing.T) {
+		for _, stmt := range []string{
+			`UPDATE stocks SET rating_from_score = \$2 WHERE rating_from_score IS NULL`,
+			`UPDATE stocks SET rating_to_score = \$2 WHERE rating_to_score IS NULL`,
+			`UPDATE rating_events SET rating_from_score = \$2 WHERE rating_from_score IS NULL`,
+			`UPDATE rating_events SET rating_to_score = \$2 WHERE rating_to_score IS NULL`,
+		} {
+			mock.ExpectExec(stmt).WithArgs("Overweight", 3.0).WillReturnResult(sqlmock.NewResult(0, 2))
+		}
+
+		updated, err := repo.SetMissingScores(context.Background(), "Overweight", 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, updated)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
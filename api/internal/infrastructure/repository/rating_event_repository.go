@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+	"github.com/lib/pq"
+)
+
+// CockroachRatingEventRepository implementa el repositorio de eventos de rating para CockroachDB
+type CockroachRatingEventRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachRatingEventRepository crea un nuevo repositorio de eventos de rating
+func NewCockroachRatingEventRepository() stock.RatingEventRepository {
+	return &CockroachRatingEventRepository{
+		db: database.GetDB(),
+	}
+}
+
+// BatchInsert inserta múltiples eventos en batch ignorando los duplicados
+func (r *CockroachRatingEventRepository) BatchInsert(ctx context.Context, events []*stock.RatingEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	inserted := 0
+	batchSize := 100
+	for i := 0; i < len(events); i += batchSize {
+		end := i + batchSize
+		if end > len(events) {
+			end = len(events)
+		}
+
+		n, err := r.insertBatch(ctx, events[i:end])
+		if err != nil {
+			return inserted, fmt.Errorf("failed to insert batch %d-%d: %w", i, end, err)
+		}
+		inserted += n
+	}
+
+	return inserted, nil
+}
+
+// insertBatch inserta un batch de eventos y retorna cuántas filas nuevas se crearon
+func (r *CockroachRatingEventRepository) insertBatch(ctx context.Context, events []*stock.RatingEvent) (int, error) {
+	valueStrings := make([]string, 0, len(events))
+	valueArgs := make([]interface{}, 0, len(events)*15)
+
+	for i, e := range events {
+		offset := i * 15
+		valueStrings = append(valueStrings, fmt.Sprintf(
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			offset+1, offset+2, offset+3, offset+4, offset+5,
+			offset+6, offset+7, offset+8, offset+9, offset+10, offset+11,
+			offset+12, offset+13, offset+14, offset+15,
+		))
+
+		valueArgs = append(valueArgs,
+			e.ID,
+			e.Ticker,
+			e.CompanyName,
+			e.Brokerage,
+			e.Action,
+			e.RatingFrom.String(),
+			e.RatingTo.String(),
+			e.TargetFrom.Value(),
+			e.TargetTo.Value(),
+			e.EventTime,
+			e.CreatedAt,
+			e.RatingFromRaw,
+			e.RatingToRaw,
+			e.RatingFromScore,
+			e.RatingToScore,
+		)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO rating_events (
+			id, ticker, company_name, brokerage, action,
+			rating_from, rating_to, target_from, target_to,
+			event_time, created_at,
+			rating_from_raw, rating_to_raw, rating_from_score, rating_to_score
+		) VALUES %s
+		ON CONFLICT (ticker, brokerage, action, event_time) DO NOTHING
+	`, strings.Join(valueStrings, ","))
+
+	result, err := r.db.ExecContext(ctx, query, valueArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert rating events: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+// ratingEventColumns son las columnas seleccionadas para construir una entidad RatingEvent
+const ratingEventColumns = `id, ticker, company_name, brokerage, action,
+		       rating_from, rating_to, target_from, target_to,
+		       event_time, created_at,
+		       COALESCE(rating_from_raw, rating_from), COALESCE(rating_to_raw, rating_to),
+		       COALESCE(rating_from_score, 0), COALESCE(rating_to_score, 0)`
+
+// LastChange retorna el momento en que se registró el último evento
+func (r *CockroachRatingEventRepository) LastChange(ctx context.Context) (time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, "SELECT max(created_at) FROM rating_events").Scan(&last); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query last rating event: %w", err)
+	}
+	return last.Time, nil
+}
+
+// FindByTicker retorna los últimos limit eventos de un ticker, del más reciente al más antiguo
+func (r *CockroachRatingEventRepository) FindByTicker(ctx context.Context, ticker string, limit int) ([]*stock.RatingEvent, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("history limit must be positive, got %d", limit)
+	}
+
+	query := "SELECT " + ratingEventColumns + `
+		FROM rating_events
+		WHERE ticker = $1
+		ORDER BY event_time DESC, created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ticker, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rating events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRatingEvents(rows)
+}
+
+// FindByTickers retorna los últimos limit eventos de cada ticker en una sola consulta,
+// ordenados por ticker y del más reciente al más antiguo
+func (r *CockroachRatingEventRepository) FindByTickers(ctx context.Context, tickers []string, limit int) ([]*stock.RatingEvent, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("history limit must be positive, got %d", limit)
+	}
+	if len(tickers) == 0 {
+		return []*stock.RatingEvent{}, nil
+	}
+
+	query := "SELECT " + ratingEventColumns + `
+		FROM (
+			SELECT *, row_number() OVER (PARTITION BY ticker ORDER BY event_time DESC, created_at DESC) AS position
+			FROM rating_events
+			WHERE ticker = ANY($1)
+		)
+		WHERE position <= $2
+		ORDER BY ticker, event_time DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tickers), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rating events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRatingEvents(rows)
+}
+
+// FindSince retorna los eventos de los tickers indicados (todos si está vacío) desde since
+func (r *CockroachRatingEventRepository) FindSince(ctx context.Context, tickers []string, since time.Time) ([]*stock.RatingEvent, error) {
+	query := "SELECT " + ratingEventColumns + " FROM rating_events WHERE event_time >= $1"
+	args := []interface{}{since}
+
+	// Un solo parámetro array: la cantidad de tickers no cambia el statement ni choca con
+	// el límite de parámetros del protocolo
+	if len(tickers) > 0 {
+		query += " AND ticker = ANY($2)"
+		args = append(args, pq.Array(tickers))
+	}
+
+	query += " ORDER BY ticker, event_time DESC, created_at DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rating events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRatingEvents(rows)
+}
+
+// scanRatingEvents convierte las filas de rating_events en entidades de dominio
+func scanRatingEvents(rows *sql.Rows) ([]*stock.RatingEvent, error) {
+	var events []*stock.RatingEvent
+	for rows.Next() {
+		var e stock.RatingEvent
+		var ratingFromStr, ratingToStr string
+		var targetFromVal, targetToVal float64
+
+		err := rows.Scan(
+			&e.ID,
+			&e.Ticker,
+			&e.CompanyName,
+			&e.Brokerage,
+			&e.Action,
+			&ratingFromStr,
+			&ratingToStr,
+			&targetFromVal,
+			&targetToVal,
+			&e.EventTime,
+			&e.CreatedAt,
+			&e.RatingFromRaw,
+			&e.RatingToRaw,
+			&e.RatingFromScore,
+			&e.RatingToScore,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rating event: %w", err)
+		}
+
+		e.RatingFrom = stock.Rating(ratingFromStr)
+		e.RatingTo = stock.Rating(ratingToStr)
+
+		targetFrom, err := stock.NewPrice(targetFromVal)
+		if err != nil {
+			continue // Skip invalid price
+		}
+		e.TargetFrom = targetFrom
+
+		targetTo, err := stock.NewPrice(targetToVal)
+		if err != nil {
+			continue // Skip invalid price
+		}
+		e.TargetTo = targetTo
+
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachRatingEventRepository_BatchInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingEventRepository{db: db}
+
+	targetFrom, _ := stock.NewPrice(100.0)
+	targetTo, _ := stock.NewPrice(120.0)
+
+	s := &stock.Stock{
+		ID:          uuid.New(),
+		Ticker:      "AAPL",
+		CompanyName: "Apple Inc.",
+		Brokerage:   "Test Brokerage",
+		Action:      "target raised by",
+		RatingFrom:  stock.RatingBuy,
+		RatingTo:    stock.RatingStrongBuy,
+		TargetFrom:  targetFrom,
+		TargetTo:    targetTo,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	t.Run("insert ignores duplicates", func(t *testing.T) {
+		events := []*stock.RatingEvent{
+			stock.NewRatingEventFromStock(s),
+			stock.NewRatingEventFromStock(s),
+		}
+
+		mock.ExpectExec(`INSERT INTO rating_events .+ ON CONFLICT \(ticker, brokerage, action, event_time\) DO NOTHING`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		inserted, err := repo.BatchInsert(context.Background(), events)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, inserted)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		inserted, err := repo.BatchInsert(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, inserted)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachRatingEventRepository_FindByTicker(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingEventRepository{db: db}
+
+	t.Run("history ordered by event time", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}).
+			AddRow(
+				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "upgraded by",
+				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
+			).
+			AddRow(
+				uuid.New(), "AAPL", "Apple Inc.", "Brokerage2", "initiated coverage",
+				"Neutral", "Buy", 90.0, 100.0, now.Add(-24*time.Hour), now,
+				"Neutral", "Buy", 0.0, 3.0,
+			)
+
+		mock.ExpectQuery(`SELECT .+ FROM rating_events WHERE ticker = \$1 ORDER BY event_time DESC, created_at DESC LIMIT \$2`).
+			WithArgs("AAPL", 10).
+			WillReturnRows(rows)
+
+		result, err := repo.FindByTicker(context.Background(), "AAPL", 10)
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "Brokerage1", result[0].Brokerage)
+		assert.Equal(t, stock.RatingBuy, result[1].RatingTo)
+	})
+
+	t.Run("limit must be positive", func(t *testing.T) {
+		_, err := repo.FindByTicker(context.Background(), "AAPL", 0)
+		assert.Error(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachRatingEventRepository_FindByTickers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingEventRepository{db: db}
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "ticker", "company_name", "brokerage", "action",
+		"rating_from", "rating_to", "target_from", "target_to",
+		"event_time", "created_at",
+		"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+	}).
+		AddRow(
+			uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "upgraded by",
+			"Buy", "Strong Buy", 100.0, 120.0, now, now,
+			"Buy", "Strong Buy", 3.0, 5.0,
+		).
+		AddRow(
+			uuid.New(), "MSFT", "Microsoft", "Brokerage2", "initiated coverage",
+			"Neutral", "Buy", 90.0, 100.0, now, now,
+			"Neutral", "Buy", 0.0, 3.0,
+		)
+
+	// Una sola consulta para todos los tickers, con limit por ticker
+	mock.ExpectQuery(`PARTITION BY ticker .+ WHERE ticker = ANY\(\$1\)\s+\) WHERE position <= \$2`).
+		WithArgs(pq.Array([]string{"AAPL", "MSFT"}), 5).
+		WillReturnRows(rows)
+
+	result, err := repo.FindByTickers(context.Background(), []string{"AAPL", "MSFT"}, 5)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "MSFT", result[1].Ticker)
+
+	_, err = repo.FindByTickers(context.Background(), []string{"AAPL"}, -1)
+	assert.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachRatingEventRepository_FindSince(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingEventRepository{db: db}
+	since := time.Now().Add(-30 * 24 * time.Hour)
+
+	t.Run("filter by tickers", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}).
+			AddRow(
+				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "upgraded by",
+				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Outperform", "Strong Buy", 3.0, 5.0,
+			)
+
+		mock.ExpectQuery(`SELECT .+ FROM rating_events WHERE event_time >= \$1 AND ticker = ANY\(\$2\) ORDER BY ticker, event_time DESC`).
+			WithArgs(since, pq.Array([]string{"AAPL", "MSFT"})).
+			WillReturnRows(rows)
+
+		result, err := repo.FindSince(context.Background(), []string{"AAPL", "MSFT"}, since)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "Outperform", result[0].RatingFromRaw)
+	})
+
+	t.Run("all tickers", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM rating_events WHERE event_time >= \$1 ORDER BY ticker`).
+			WithArgs(since).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		result, err := repo.FindSince(context.Background(), nil, since)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachRatingEventRepository_LastChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachRatingEventRepository{db: db}
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT max\(created_at\) FROM rating_events`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(now))
+	last, err := repo.LastChange(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(last))
+
+	// Sin eventos max() es NULL
+	mock.ExpectQuery(`SELECT max\(created_at\) FROM rating_events`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	last, err = repo.LastChange(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, last.IsZero())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
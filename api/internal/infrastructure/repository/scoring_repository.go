@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/john/go-react-test/api/internal/domain/scoring"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+)
+
+// CockroachScoringRepository lee el modelo de scoring de la tabla scoring_parameters
+type CockroachScoringRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachScoringRepository crea un nuevo repositorio del modelo de scoring
+func NewCockroachScoringRepository() scoring.Repository {
+	return &CockroachScoringRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Load retorna el modelo por defecto sobrescrito con los parámetros de la tabla.
+// Además de los tipos de la migración 008, kind = 'decay' (name = halfLifeDays) fija la vida media.
+func (r *CockroachScoringRepository) Load(ctx context.Context) (*scoring.Model, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT kind, name, value FROM scoring_parameters`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoring parameters: %w", err)
+	}
+	defer rows.Close()
+
+	model := scoring.DefaultModel()
+	for rows.Next() {
+		var kind, name string
+		var value float64
+		if err := rows.Scan(&kind, &name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan scoring parameter: %w", err)
+		}
+
+		switch kind {
+		case "rating":
+			model.RatingScores[name] = value
+		case "action":
+			model.SetActionScore(name, value)
+		case "bonus":
+			if name != "upgrade" {
+				return nil, fmt.Errorf("unknown bonus parameter: %s", name)
+			}
+			model.UpgradeBonus = value
+		case "decay":
+			if name != "halfLifeDays" {
+				return nil, fmt.Errorf("unknown decay parameter: %s", name)
+			}
+			model.HalfLifeDays = value
+		case "weight":
+			switch name {
+			case "priceChange":
+				model.Weights.PriceChange = value
+			case "rating":
+				model.Weights.Rating = value
+			case "action":
+				model.Weights.Action = value
+			default:
+				return nil, fmt.Errorf("unknown weight parameter: %s", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown scoring parameter kind: %s", kind)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// FileScoringRepository lee el modelo de scoring de un archivo JSON
+type FileScoringRepository struct {
+	path string
+}
+
+// NewFileScoringRepository crea un repositorio del modelo de scoring basado en archivo
+func NewFileScoringRepository(path string) scoring.Repository {
+	return &FileScoringRepository{path: path}
+}
+
+// Load retorna el modelo por defecto sobrescrito con el contenido del archivo
+func (r *FileScoringRepository) Load(ctx context.Context) (*scoring.Model, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring model file: %w", err)
+	}
+	return scoring.ParseJSON(data)
+}
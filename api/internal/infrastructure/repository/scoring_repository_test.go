@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachScoringRepository_Load(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachScoringRepository{db: db}
+
+	t.Run("overrides defaults with stored parameters", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"kind", "name", "value"}).
+			AddRow("rating", "Underperform", -2.0).
+			AddRow("action", "Upgraded By", 2.0).
+			AddRow("weight", "priceChange", 0.4).
+			AddRow("bonus", "upgrade", 1.0).
+			AddRow("decay", "halfLifeDays", 30.0)
+
+		mock.ExpectQuery(`SELECT kind, name, value FROM scoring_parameters`).
+			WillReturnRows(rows)
+
+		model, err := repo.Load(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, -2.0, model.RatingScore("Underperform"))
+		assert.Equal(t, 5.0, model.RatingScore("Strong Buy"))
+		assert.Equal(t, 2.0, model.ActionScore("upgraded by"))
+		assert.Equal(t, 0.4, model.Weights.PriceChange)
+		assert.Equal(t, 0.3, model.Weights.Rating)
+		assert.Equal(t, 1.0, model.UpgradeBonus)
+		assert.Equal(t, 30.0, model.HalfLifeDays)
+	})
+
+	t.Run("rejects unknown kinds", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"kind", "name", "value"}).
+			AddRow("multiplier", "x", 1.0)
+
+		mock.ExpectQuery(`SELECT kind, name, value FROM scoring_parameters`).
+			WillReturnRows(rows)
+
+		_, err := repo.Load(context.Background())
+		assert.Error(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileScoringRepository_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scoring.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"weights": {"priceChange": 0.7}}`), 0o600))
+
+	model, err := NewFileScoringRepository(path).Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.7, model.Weights.PriceChange)
+	assert.Equal(t, 0.2, model.Weights.Action)
+
+	_, err = NewFileScoringRepository(filepath.Join(t.TempDir(), "missing.json")).Load(context.Background())
+	assert.Error(t, err)
+}
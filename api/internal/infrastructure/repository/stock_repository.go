@@ -3,12 +3,18 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
 	"github.com/john/go-react-test/api/internal/infrastructure/database"
+	"github.com/lib/pq"
 )
 
 // CockroachStockRepository implementa el repositorio de stocks para CockroachDB
@@ -24,13 +30,15 @@ func NewCockroachStockRepository() stock.Repository {
 }
 
 // Save guarda o actualiza una acción (UPSERT)
+// Una llamada más antigua que la almacenada no sobrescribe el último estado del ticker
 func (r *CockroachStockRepository) Save(ctx context.Context, s *stock.Stock) error {
 	query := `
 		INSERT INTO stocks (
 			id, ticker, company_name, brokerage, action,
 			rating_from, rating_to, target_from, target_to,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			event_time, created_at, updated_at,
+			rating_from_raw, rating_to_raw, rating_from_score, rating_to_score
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		ON CONFLICT (ticker) 
 		DO UPDATE SET
 			company_name = EXCLUDED.company_name,
@@ -40,7 +48,13 @@ func (r *CockroachStockRepository) Save(ctx context.Context, s *stock.Stock) err
 			rating_to = EXCLUDED.rating_to,
 			target_from = EXCLUDED.target_from,
 			target_to = EXCLUDED.target_to,
-			updated_at = EXCLUDED.updated_at
+			event_time = EXCLUDED.event_time,
+			updated_at = EXCLUDED.updated_at,
+			rating_from_raw = EXCLUDED.rating_from_raw,
+			rating_to_raw = EXCLUDED.rating_to_raw,
+			rating_from_score = EXCLUDED.rating_from_score,
+			rating_to_score = EXCLUDED.rating_to_score
+		WHERE stocks.event_time IS NULL OR EXCLUDED.event_time >= stocks.event_time
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -53,8 +67,13 @@ func (r *CockroachStockRepository) Save(ctx context.Context, s *stock.Stock) err
 		s.RatingTo.String(),
 		s.TargetFrom.Value(),
 		s.TargetTo.Value(),
+		s.EventTime,
 		s.CreatedAt,
 		s.UpdatedAt,
+		s.RatingFromRaw,
+		s.RatingToRaw,
+		s.RatingFromScore,
+		s.RatingToScore,
 	)
 
 	if err != nil {
@@ -94,14 +113,15 @@ func (r *CockroachStockRepository) upsertBatch(ctx context.Context, stocks []*st
 
 	// Construir query con múltiples valores
 	valueStrings := make([]string, 0, len(stocks))
-	valueArgs := make([]interface{}, 0, len(stocks)*11)
+	valueArgs := make([]interface{}, 0, len(stocks)*16)
 
 	for i, s := range stocks {
-		offset := i * 11
+		offset := i * 16
 		valueStrings = append(valueStrings, fmt.Sprintf(
-			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			offset+1, offset+2, offset+3, offset+4, offset+5,
-			offset+6, offset+7, offset+8, offset+9, offset+10, offset+11,
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			offset+1, offset+2, offset+3, offset+4, offset+5, offset+6,
+			offset+7, offset+8, offset+9, offset+10, offset+11, offset+12,
+			offset+13, offset+14, offset+15, offset+16,
 		))
 
 		valueArgs = append(valueArgs,
@@ -114,8 +134,13 @@ func (r *CockroachStockRepository) upsertBatch(ctx context.Context, stocks []*st
 			s.RatingTo.String(),
 			s.TargetFrom.Value(),
 			s.TargetTo.Value(),
+			s.EventTime,
 			s.CreatedAt,
 			s.UpdatedAt,
+			s.RatingFromRaw,
+			s.RatingToRaw,
+			s.RatingFromScore,
+			s.RatingToScore,
 		)
 	}
 
@@ -123,7 +148,8 @@ func (r *CockroachStockRepository) upsertBatch(ctx context.Context, stocks []*st
 		INSERT INTO stocks (
 			id, ticker, company_name, brokerage, action,
 			rating_from, rating_to, target_from, target_to,
-			created_at, updated_at
+			event_time, created_at, updated_at,
+			rating_from_raw, rating_to_raw, rating_from_score, rating_to_score
 		) VALUES %s
 		ON CONFLICT (ticker) 
 		DO UPDATE SET
@@ -134,7 +160,13 @@ func (r *CockroachStockRepository) upsertBatch(ctx context.Context, stocks []*st
 			rating_to = EXCLUDED.rating_to,
 			target_from = EXCLUDED.target_from,
 			target_to = EXCLUDED.target_to,
-			updated_at = EXCLUDED.updated_at
+			event_time = EXCLUDED.event_time,
+			updated_at = EXCLUDED.updated_at,
+			rating_from_raw = EXCLUDED.rating_from_raw,
+			rating_to_raw = EXCLUDED.rating_to_raw,
+			rating_from_score = EXCLUDED.rating_from_score,
+			rating_to_score = EXCLUDED.rating_to_score
+		WHERE stocks.event_time IS NULL OR EXCLUDED.event_time >= stocks.event_time
 	`, strings.Join(valueStrings, ","))
 
 	_, err := r.db.ExecContext(ctx, query, valueArgs...)
@@ -145,21 +177,29 @@ func (r *CockroachStockRepository) upsertBatch(ctx context.Context, stocks []*st
 	return nil
 }
 
-// FindByID busca una acción por ID
-func (r *CockroachStockRepository) FindByID(ctx context.Context, id uuid.UUID) (*stock.Stock, error) {
-	query := `
-		SELECT id, ticker, company_name, brokerage, action,
+// stockColumns son las columnas seleccionadas para construir una entidad Stock
+const stockColumns = `id, ticker, company_name, brokerage, action,
 		       rating_from, rating_to, target_from, target_to,
-		       created_at, updated_at
-		FROM stocks
-		WHERE id = $1
-	`
+		       COALESCE(event_time, created_at), created_at, updated_at,
+		       COALESCE(rating_from_raw, rating_from), COALESCE(rating_to_raw, rating_to),
+		       COALESCE(rating_from_score, 0), COALESCE(rating_to_score, 0)`
+
+// rowScanner abstrae *sql.Row y *sql.Rows para compartir el escaneo
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// errInvalidStoredPrice indica una fila con un precio que no es válido en el dominio.
+// Las consultas de varias filas la omiten para que una fila corrupta no haga fallar toda la consulta.
+var errInvalidStoredPrice = errors.New("invalid stored price")
 
+// scanStock escanea una fila de stocks y la convierte en entidad de dominio
+func scanStock(row rowScanner) (*stock.Stock, error) {
 	var s stock.Stock
 	var ratingFromStr, ratingToStr string
 	var targetFromVal, targetToVal float64
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&s.ID,
 		&s.Ticker,
 		&s.CompanyName,
@@ -169,15 +209,16 @@ func (r *CockroachStockRepository) FindByID(ctx context.Context, id uuid.UUID) (
 		&ratingToStr,
 		&targetFromVal,
 		&targetToVal,
+		&s.EventTime,
 		&s.CreatedAt,
 		&s.UpdatedAt,
+		&s.RatingFromRaw,
+		&s.RatingToRaw,
+		&s.RatingFromScore,
+		&s.RatingToScore,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("stock not found: %w", err)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to find stock: %w", err)
+		return nil, err
 	}
 
 	s.RatingFrom = stock.Rating(ratingFromStr)
@@ -185,47 +226,39 @@ func (r *CockroachStockRepository) FindByID(ctx context.Context, id uuid.UUID) (
 
 	targetFrom, err := stock.NewPrice(targetFromVal)
 	if err != nil {
-		return nil, fmt.Errorf("invalid target_from: %w", err)
+		return nil, fmt.Errorf("%w: target_from of %s: %v", errInvalidStoredPrice, s.Ticker, err)
 	}
 	s.TargetFrom = targetFrom
 
 	targetTo, err := stock.NewPrice(targetToVal)
 	if err != nil {
-		return nil, fmt.Errorf("invalid target_to: %w", err)
+		return nil, fmt.Errorf("%w: target_to of %s: %v", errInvalidStoredPrice, s.Ticker, err)
 	}
 	s.TargetTo = targetTo
 
 	return &s, nil
 }
 
-// FindByTicker busca una acción por ticker
-func (r *CockroachStockRepository) FindByTicker(ctx context.Context, ticker string) (*stock.Stock, error) {
-	query := `
-		SELECT id, ticker, company_name, brokerage, action,
-		       rating_from, rating_to, target_from, target_to,
-		       created_at, updated_at
-		FROM stocks
-		WHERE ticker = $1
-	`
+// FindByID busca una acción por ID
+func (r *CockroachStockRepository) FindByID(ctx context.Context, id uuid.UUID) (*stock.Stock, error) {
+	query := "SELECT " + stockColumns + " FROM stocks WHERE id = $1"
 
-	var s stock.Stock
-	var ratingFromStr, ratingToStr string
-	var targetFromVal, targetToVal float64
+	s, err := scanStock(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("stock not found: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stock: %w", err)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, ticker).Scan(
-		&s.ID,
-		&s.Ticker,
-		&s.CompanyName,
-		&s.Brokerage,
-		&s.Action,
-		&ratingFromStr,
-		&ratingToStr,
-		&targetFromVal,
-		&targetToVal,
-		&s.CreatedAt,
-		&s.UpdatedAt,
-	)
+	return s, nil
+}
 
+// FindByTicker busca una acción por ticker
+func (r *CockroachStockRepository) FindByTicker(ctx context.Context, ticker string) (*stock.Stock, error) {
+	query := "SELECT " + stockColumns + " FROM stocks WHERE ticker = $1"
+
+	s, err := scanStock(r.db.QueryRowContext(ctx, query, ticker))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("stock not found")
 	}
@@ -233,80 +266,321 @@ func (r *CockroachStockRepository) FindByTicker(ctx context.Context, ticker stri
 		return nil, fmt.Errorf("failed to find stock: %w", err)
 	}
 
-	s.RatingFrom = stock.Rating(ratingFromStr)
-	s.RatingTo = stock.Rating(ratingToStr)
+	return s, nil
+}
 
-	targetFrom, err := stock.NewPrice(targetFromVal)
-	if err != nil {
-		return nil, fmt.Errorf("invalid target_from: %w", err)
+// FindByTickers busca las acciones de los tickers indicados en una sola consulta
+func (r *CockroachStockRepository) FindByTickers(ctx context.Context, tickers []string) ([]*stock.Stock, error) {
+	if len(tickers) == 0 {
+		return nil, nil
 	}
-	s.TargetFrom = targetFrom
 
-	targetTo, err := stock.NewPrice(targetToVal)
+	query := "SELECT " + stockColumns + " FROM stocks WHERE ticker = ANY($1)"
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tickers))
 	if err != nil {
-		return nil, fmt.Errorf("invalid target_to: %w", err)
+		return nil, fmt.Errorf("failed to query stocks: %w", err)
 	}
-	s.TargetTo = targetTo
+	defer rows.Close()
 
-	return &s, nil
+	var stocks []*stock.Stock
+	for rows.Next() {
+		s, err := scanStock(rows)
+		if errors.Is(err, errInvalidStoredPrice) {
+			log.Printf("Skipping stock row: %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		stocks = append(stocks, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return stocks, nil
 }
 
-// FindAll busca todas las acciones con filtros y ordenamiento
-func (r *CockroachStockRepository) FindAll(ctx context.Context, filter stock.Filter, sort stock.Sort) ([]*stock.Stock, error) {
-	query := "SELECT id, ticker, company_name, brokerage, action, rating_from, rating_to, target_from, target_to, created_at, updated_at FROM stocks WHERE 1=1"
-	args := []interface{}{}
-	argIndex := 1
+// targetChangeExpr es el cambio porcentual del precio objetivo (0 si target_from es 0),
+// igual que Stock.CalculatePriceChange
+const targetChangeExpr = "(CASE WHEN target_from = 0 THEN 0 ELSE (target_to - target_from) / target_from * 100 END)"
+
+// buildFilterClause construye las condiciones WHERE (a continuación de "WHERE 1=1")
+// compartidas por FindAll y Count
+func buildFilterClause(filter stock.Filter) (string, []interface{}) {
+	b := &filterBuilder{}
+	clause := ""
+	for _, condition := range b.conditions(filter) {
+		clause += " AND " + condition
+	}
+	return clause, b.args
+}
+
+// filterBuilder traduce filtros a condiciones SQL acumulando sus argumentos posicionales
+type filterBuilder struct {
+	args []interface{}
+}
+
+// arg agrega un argumento y retorna su placeholder
+func (b *filterBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// argList agrega los argumentos y retorna sus placeholders separados por comas
+func (b *filterBuilder) argList(values []string) string {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.arg(v)
+	}
+	return strings.Join(placeholders, ",")
+}
+
+// conditions retorna las condiciones del filtro, que deben cumplirse todas
+func (b *filterBuilder) conditions(filter stock.Filter) []string {
+	var conditions []string
 
-	// Aplicar filtros
 	if filter.Ticker != "" {
-		query += fmt.Sprintf(" AND ticker = $%d", argIndex)
-		args = append(args, filter.Ticker)
-		argIndex++
+		conditions = append(conditions, "ticker = "+b.arg(filter.Ticker))
+	}
+
+	if filter.TickerPrefix != "" {
+		conditions = append(conditions, "ticker ILIKE "+b.arg(escapeLike(filter.TickerPrefix)+"%"))
 	}
 
 	if filter.CompanyName != "" {
-		query += fmt.Sprintf(" AND company_name ILIKE $%d", argIndex)
-		args = append(args, "%"+filter.CompanyName+"%")
-		argIndex++
+		conditions = append(conditions, "company_name ILIKE "+b.arg("%"+filter.CompanyName+"%"))
 	}
 
 	if len(filter.Ratings) > 0 {
-		placeholders := make([]string, len(filter.Ratings))
+		ratings := make([]string, len(filter.Ratings))
 		for i, rating := range filter.Ratings {
-			placeholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, rating.String())
-			argIndex++
+			ratings[i] = rating.String()
 		}
-		query += fmt.Sprintf(" AND rating_to = ANY(ARRAY[%s])", strings.Join(placeholders, ","))
+		conditions = append(conditions, fmt.Sprintf("rating_to = ANY(ARRAY[%s])", b.argList(ratings)))
+	}
+
+	if len(filter.Brokerages) > 0 {
+		brokerages := make([]string, len(filter.Brokerages))
+		for i, brokerage := range filter.Brokerages {
+			brokerages[i] = strings.ToLower(strings.TrimSpace(brokerage))
+		}
+		conditions = append(conditions, fmt.Sprintf("lower(trim(brokerage)) = ANY(ARRAY[%s])", b.argList(brokerages)))
 	}
 
 	if filter.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIndex)
-		args = append(args, filter.Action)
-		argIndex++
-	}
-
-	// Aplicar ordenamiento
-	if sort.Field != "" {
-		validFields := map[string]string{
-			"ticker":       "ticker",
-			"company_name": "company_name",
-			"rating_to":    "rating_to",
-			"target_to":    "target_to",
-			"created_at":   "created_at",
+		conditions = append(conditions, "action = "+b.arg(filter.Action))
+	}
+
+	if !filter.EventTimeFrom.IsZero() {
+		conditions = append(conditions, "event_time >= "+b.arg(filter.EventTimeFrom))
+	}
+
+	if !filter.EventTimeTo.IsZero() {
+		conditions = append(conditions, "event_time <= "+b.arg(filter.EventTimeTo))
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= "+b.arg(filter.CreatedFrom))
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at <= "+b.arg(filter.CreatedTo))
+	}
+
+	conditions = append(conditions, b.rangeConditions("target_to", filter.TargetToMin, filter.TargetToMax)...)
+	conditions = append(conditions, b.rangeConditions("target_from", filter.TargetFromMin, filter.TargetFromMax)...)
+	conditions = append(conditions, b.rangeConditions(targetChangeExpr, filter.MinTargetChange, filter.MaxTargetChange)...)
+
+	switch filter.RatingChange {
+	case stock.RatingChangeUpgrade:
+		conditions = append(conditions, "rating_to_score > rating_from_score")
+	case stock.RatingChangeDowngrade:
+		conditions = append(conditions, "rating_to_score < rating_from_score")
+	}
+
+	for _, sub := range filter.And {
+		conditions = append(conditions, b.group(sub))
+	}
+
+	if len(filter.Or) > 0 {
+		alternatives := make([]string, len(filter.Or))
+		for i, sub := range filter.Or {
+			alternatives[i] = b.group(sub)
 		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	if filter.Not != nil {
+		conditions = append(conditions, "NOT "+b.group(*filter.Not))
+	}
+
+	return conditions
+}
 
-		if field, ok := validFields[sort.Field]; ok {
-			direction := "ASC"
-			if sort.Direction == "desc" {
-				direction = "DESC"
+// group retorna las condiciones de un subfiltro unidas con AND entre paréntesis (TRUE si está vacío)
+func (b *filterBuilder) group(filter stock.Filter) string {
+	conditions := b.conditions(filter)
+	if len(conditions) == 0 {
+		return "(TRUE)"
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// rangeConditions retorna las condiciones de un rango inclusivo sobre expr
+func (b *filterBuilder) rangeConditions(expr string, min, max *float64) []string {
+	var conditions []string
+	if min != nil {
+		conditions = append(conditions, expr+" >= "+b.arg(*min))
+	}
+	if max != nil {
+		conditions = append(conditions, expr+" <= "+b.arg(*max))
+	}
+	return conditions
+}
+
+// escapeLike escapa los comodines de LIKE para buscar value literalmente
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// sortColumnTypes son los tipos SQL de las columnas de ordenamiento, usados al comparar con el cursor
+var sortColumnTypes = map[string]string{
+	"ticker":       "STRING",
+	"company_name": "STRING",
+	"rating_to":    "STRING",
+	"target_to":    "DECIMAL",
+	"created_at":   "TIMESTAMP",
+	"event_time":   "TIMESTAMP",
+}
+
+// ratingDeltaExpr es la diferencia entre los scores normalizados del rating destino y el de origen
+const ratingDeltaExpr = "(COALESCE(rating_to_score, 0) - COALESCE(rating_from_score, 0))"
+
+// computedSortExpr retorna la expresión SQL de un campo de orden calculado
+func (b *filterBuilder) computedSortExpr(sort stock.Sort) string {
+	switch sort.Field {
+	case stock.SortPriceChange:
+		return targetChangeExpr
+	case stock.SortRatingDelta:
+		return ratingDeltaExpr
+	default:
+		return b.scoreExpr(sort.Model, sort.At)
+	}
+}
+
+// scoreExpr traduce scoring.Model.Evaluate a SQL: la suma ponderada del cambio de precio,
+// el score del rating (más el bonus por upgrade) y el score de la acción, por el decaimiento
+// de la llamada a la fecha at (cero usa la hora actual)
+func (b *filterBuilder) scoreExpr(model *scoring.Model, at time.Time) string {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	ratingTo := b.scoreCase("rating_to", model.RatingScores)
+	ratingFrom := b.scoreCase("rating_from", model.RatingScores)
+	action := b.scoreCase(`lower(regexp_replace(trim(COALESCE(action, '')), '\s+', ' ', 'g'))`, model.ActionScores)
+	upgrade := fmt.Sprintf("(CASE WHEN %s > %s THEN %s ELSE 0 END)", ratingTo, ratingFrom, b.arg(model.UpgradeBonus)+"::FLOAT8")
+
+	decay := "1"
+	if halfLife := model.HalfLife(); halfLife > 0 {
+		ref := b.arg(at.UTC()) + "::TIMESTAMP"
+		decay = fmt.Sprintf("(CASE WHEN event_time >= %s THEN 1 ELSE power(0.5::FLOAT8, extract(epoch FROM (%s - event_time))::FLOAT8 / %s::FLOAT8) END)",
+			ref, ref, b.arg(halfLife.Seconds()))
+	}
+
+	w := model.Weights
+	return fmt.Sprintf("((%s::FLOAT8 * %s::FLOAT8 + (%s + %s) * %s::FLOAT8 + %s * %s::FLOAT8) * %s)",
+		targetChangeExpr, b.arg(w.PriceChange),
+		ratingTo, upgrade, b.arg(w.Rating),
+		action, b.arg(w.Action),
+		decay)
+}
+
+// scoreCase retorna un CASE que asigna a expr su score en scores (0 si no está)
+func (b *filterBuilder) scoreCase(expr string, scores map[string]float64) string {
+	if len(scores) == 0 {
+		return "0::FLOAT8"
+	}
+
+	keys := make([]string, 0, len(scores))
+	for k := range scores {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	clause := "(CASE " + expr
+	for _, k := range keys {
+		clause += fmt.Sprintf(" WHEN %s THEN %s::FLOAT8", b.arg(k), b.arg(scores[k]))
+	}
+	return clause + " ELSE 0::FLOAT8 END)"
+}
+
+// FindAll busca las acciones con filtros y ordenamiento, limitadas a la página indicada.
+// Con cursor pagina por keyset sobre (campo de orden, id), de modo que cada página
+// cuesta lo mismo independientemente de su posición.
+func (r *CockroachStockRepository) FindAll(
+	ctx context.Context,
+	filter stock.Filter,
+	sort stock.Sort,
+	page stock.Page,
+) ([]*stock.Stock, error) {
+	sort = sort.Normalized()
+	if sort.Field == stock.SortScore && sort.Model == nil {
+		return nil, fmt.Errorf("sorting by score requires a scoring model")
+	}
+	whereClause, args := buildFilterClause(filter)
+	b := &filterBuilder{args: args}
+
+	direction, comparison := "ASC", ">"
+	if sort.Direction == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.After != nil {
+		if !page.After.Matches(sort) {
+			return nil, fmt.Errorf("%w: cursor does not match sort %s %s", stock.ErrInvalidCursor, sort.Field, sort.Direction)
+		}
+		// El score de todas las páginas se calcula respecto al mismo momento
+		if sort.Field == stock.SortScore {
+			sort.At = page.After.At
+		}
+	}
+
+	orderExpr := sort.Field
+	if stock.IsComputedSortField(sort.Field) {
+		orderExpr = b.computedSortExpr(sort)
+	}
+
+	if page.After != nil {
+		if stock.IsComputedSortField(sort.Field) {
+			// El valor del cursor se recalcula con la misma expresión a partir de su fila
+			id := b.arg(page.After.ID)
+			whereClause += fmt.Sprintf(" AND (%s, id) %s ((SELECT %s FROM stocks WHERE id = %s::UUID), %s::UUID)",
+				orderExpr, comparison, orderExpr, id, id)
+		} else {
+			value, err := page.After.SortValue()
+			if err != nil {
+				return nil, err
 			}
-			query += fmt.Sprintf(" ORDER BY %s %s", field, direction)
+			whereClause += fmt.Sprintf(" AND (%s, id) %s (%s::%s, %s::UUID)",
+				orderExpr, comparison, b.arg(value), sortColumnTypes[sort.Field], b.arg(page.After.ID))
 		}
-	} else {
-		query += " ORDER BY created_at DESC"
 	}
 
+	query := "SELECT " + stockColumns + " FROM stocks WHERE 1=1" + whereClause
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", orderExpr, direction, direction)
+
+	if page.Limit > 0 {
+		query += " LIMIT " + b.arg(page.Limit)
+	}
+	if page.Offset > 0 && page.After == nil {
+		query += " OFFSET " + b.arg(page.Offset)
+	}
+	args = b.args
+
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stocks: %w", err)
@@ -315,92 +589,190 @@ func (r *CockroachStockRepository) FindAll(ctx context.Context, filter stock.Fil
 
 	var stocks []*stock.Stock
 	for rows.Next() {
-		var s stock.Stock
-		var ratingFromStr, ratingToStr string
-		var targetFromVal, targetToVal float64
-
-		err := rows.Scan(
-			&s.ID,
-			&s.Ticker,
-			&s.CompanyName,
-			&s.Brokerage,
-			&s.Action,
-			&ratingFromStr,
-			&ratingToStr,
-			&targetFromVal,
-			&targetToVal,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		)
+		s, err := scanStock(rows)
+		if errors.Is(err, errInvalidStoredPrice) {
+			log.Printf("Skipping stock row: %v", err)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan stock: %w", err)
 		}
+		stocks = append(stocks, s)
+	}
 
-		s.RatingFrom = stock.Rating(ratingFromStr)
-		s.RatingTo = stock.Rating(ratingToStr)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
 
-		targetFrom, err := stock.NewPrice(targetFromVal)
-		if err != nil {
-			continue // Skip invalid price
+	return stocks, nil
+}
+
+// Count cuenta el número de acciones que coinciden con el filtro
+func (r *CockroachStockRepository) Count(ctx context.Context, filter stock.Filter) (int, error) {
+	// Aplicar los mismos filtros que FindAll
+	whereClause, args := buildFilterClause(filter)
+	query := "SELECT COUNT(*) FROM stocks WHERE 1=1" + whereClause
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count stocks: %w", err)
+	}
+
+	return count, nil
+}
+
+// Stats calcula las agregaciones de las acciones que coinciden con el filtro:
+// una consulta para el total, los cambios de rating y la distribución del cambio
+// del precio objetivo, y otra con los conteos por rating, acción y brokerage
+func (r *CockroachStockRepository) Stats(ctx context.Context, filter stock.Filter) (*stock.Stats, error) {
+	whereClause, args := buildFilterClause(filter)
+
+	stats := &stock.Stats{TargetChanges: stock.NewTargetChangeBuckets()}
+
+	columns := []string{
+		"COUNT(*)",
+		"COUNT(*) FILTER (WHERE rating_to_score > rating_from_score)",
+		"COUNT(*) FILTER (WHERE rating_to_score < rating_from_score)",
+	}
+	dest := []interface{}{&stats.Total, &stats.Upgrades, &stats.Downgrades}
+	for i := range stats.TargetChanges {
+		bucket := &stats.TargetChanges[i]
+		var bounds []string
+		if bucket.Min != nil {
+			bounds = append(bounds, fmt.Sprintf("%s >= %v", targetChangeExpr, *bucket.Min))
 		}
-		s.TargetFrom = targetFrom
+		if bucket.Max != nil {
+			bounds = append(bounds, fmt.Sprintf("%s < %v", targetChangeExpr, *bucket.Max))
+		}
+		columns = append(columns, "COUNT(*) FILTER (WHERE "+strings.Join(bounds, " AND ")+")")
+		dest = append(dest, &bucket.Count)
+	}
 
-		targetTo, err := stock.NewPrice(targetToVal)
-		if err != nil {
-			continue // Skip invalid price
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM stocks WHERE 1=1" + whereClause
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to compute stock stats: %w", err)
+	}
+
+	// Los tres agrupamientos comparten el filtro y sus argumentos
+	groups := []struct{ name, expr string }{
+		{"rating", "rating_to"},
+		{"action", "COALESCE(action, '')"},
+		{"brokerage", "COALESCE(brokerage, '')"},
+	}
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = fmt.Sprintf("SELECT '%s' AS grp, %s AS value, COUNT(*) AS count FROM stocks WHERE 1=1%s GROUP BY %s",
+			g.name, g.expr, whereClause, g.expr)
+	}
+	query = strings.Join(parts, " UNION ALL ") + " ORDER BY grp, count DESC, value ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count stocks by group: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group string
+		var c stock.GroupCount
+		if err := rows.Scan(&group, &c.Value, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan stock group count: %w", err)
 		}
-		s.TargetTo = targetTo
 
-		stocks = append(stocks, &s)
+		switch group {
+		case "rating":
+			stats.ByRating = append(stats.ByRating, c)
+		case "action":
+			stats.ByAction = append(stats.ByAction, c)
+		case "brokerage":
+			stats.ByBrokerage = append(stats.ByBrokerage, c)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return stocks, nil
+	return stats, nil
 }
 
-// Count cuenta el número de acciones que coinciden con el filtro
-func (r *CockroachStockRepository) Count(ctx context.Context, filter stock.Filter) (int, error) {
-	query := "SELECT COUNT(*) FROM stocks WHERE 1=1"
-	args := []interface{}{}
-	argIndex := 1
+// Relevancia máxima de cada campo en Search: un ticker pesa más que una empresa
+// y una empresa más que un brokerage
+var searchFieldWeights = map[string]float64{
+	stock.SearchFieldTicker:      1,
+	stock.SearchFieldCompanyName: 0.95,
+	stock.SearchFieldBrokerage:   0.8,
+}
 
-	// Aplicar los mismos filtros que FindAll
-	if filter.Ticker != "" {
-		query += fmt.Sprintf(" AND ticker = $%d", argIndex)
-		args = append(args, filter.Ticker)
-		argIndex++
+// searchScoreExpr es la relevancia de column para la búsqueda: coincidencia exacta,
+// prefijo o contenido y, si no, similitud de trigramas (siempre por debajo de una coincidencia literal)
+func searchScoreExpr(column string, weight float64, query, prefix, contains string) string {
+	column = "COALESCE(" + column + ", '')"
+	return fmt.Sprintf("(CASE WHEN lower(%s) = lower(%s) THEN 1.0 WHEN %s ILIKE %s THEN 0.9 "+
+		"WHEN %s ILIKE %s THEN 0.75 ELSE similarity(%s, %s) * 0.7 END * %v)",
+		column, query, column, prefix, column, contains, column, query, weight)
+}
+
+// Search busca acciones por ticker, empresa o brokerage. Las coincidencias literales (ILIKE)
+// y aproximadas (operador % de trigramas) usan los índices GIN de trigramas de cada columna.
+func (r *CockroachStockRepository) Search(ctx context.Context, query string, limit int) ([]*stock.SearchResult, error) {
+	b := &filterBuilder{}
+	q := b.arg(query)
+	prefix := b.arg(escapeLike(query) + "%")
+	contains := b.arg("%" + escapeLike(query) + "%")
+
+	fields := []string{stock.SearchFieldTicker, stock.SearchFieldCompanyName, stock.SearchFieldBrokerage}
+	scores := make([]string, len(fields))
+	matches := make([]string, 0, 2*len(fields))
+	for i, field := range fields {
+		scores[i] = searchScoreExpr(field, searchFieldWeights[field], q, prefix, contains)
+		matches = append(matches, field+" ILIKE "+contains, field+" % "+q)
 	}
 
-	if filter.CompanyName != "" {
-		query += fmt.Sprintf(" AND company_name ILIKE $%d", argIndex)
-		args = append(args, "%"+filter.CompanyName+"%")
-		argIndex++
+	sqlQuery := "SELECT " + stockColumns + ", " + strings.Join(scores, ", ") +
+		" FROM stocks WHERE " + strings.Join(matches, " OR ") +
+		" ORDER BY GREATEST(" + strings.Join(scores, ", ") + ") DESC, ticker ASC LIMIT " + b.arg(limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search stocks: %w", err)
 	}
+	defer rows.Close()
 
-	if len(filter.Ratings) > 0 {
-		placeholders := make([]string, len(filter.Ratings))
-		for i, rating := range filter.Ratings {
-			placeholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, rating.String())
-			argIndex++
+	var results []*stock.SearchResult
+	for rows.Next() {
+		fieldScores := make([]float64, len(fields))
+		s, err := scanStock(scanWithExtra{row: rows, extra: []interface{}{&fieldScores[0], &fieldScores[1], &fieldScores[2]}})
+		if errors.Is(err, errInvalidStoredPrice) {
+			log.Printf("Skipping stock row: %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
 		}
-		query += fmt.Sprintf(" AND rating_to = ANY(ARRAY[%s])", strings.Join(placeholders, ","))
-	}
 
-	if filter.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIndex)
-		args = append(args, filter.Action)
-		argIndex++
+		byField := make(map[string]float64, len(fields))
+		for i, field := range fields {
+			byField[field] = fieldScores[i]
+		}
+		results = append(results, stock.NewSearchResult(s, query, byField))
 	}
 
-	var count int
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count stocks: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return count, nil
+	return results, nil
+}
+
+// scanWithExtra escanea una fila de stocks seguida de columnas adicionales
+type scanWithExtra struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+// Scan escanea las columnas de la entidad en dest y las siguientes en extra
+func (s scanWithExtra) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
 }
@@ -8,7 +8,9 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/scoring"
 	"github.com/john/go-react-test/api/internal/domain/stock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,8 +35,14 @@ func TestCockroachStockRepository_Save(t *testing.T) {
 		RatingTo:    stock.RatingStrongBuy,
 		TargetFrom:  targetFrom,
 		TargetTo:    targetTo,
+		EventTime:   time.Now().Add(-time.Hour),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		RatingFromRaw:   "Outperform",
+		RatingToRaw:     "Strong-Buy",
+		RatingFromScore: 3,
+		RatingToScore:   5,
 	}
 
 	// Test INSERT (new stock)
@@ -44,7 +52,8 @@ func TestCockroachStockRepository_Save(t *testing.T) {
 				s.ID, s.Ticker, s.CompanyName, s.Brokerage, s.Action,
 				s.RatingFrom.String(), s.RatingTo.String(),
 				s.TargetFrom.Value(), s.TargetTo.Value(),
-				s.CreatedAt, s.UpdatedAt,
+				s.EventTime, s.CreatedAt, s.UpdatedAt,
+				s.RatingFromRaw, s.RatingToRaw, s.RatingFromScore, s.RatingToScore,
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -59,7 +68,8 @@ func TestCockroachStockRepository_Save(t *testing.T) {
 				s.ID, s.Ticker, s.CompanyName, s.Brokerage, s.Action,
 				s.RatingFrom.String(), s.RatingTo.String(),
 				s.TargetFrom.Value(), s.TargetTo.Value(),
-				s.CreatedAt, s.UpdatedAt,
+				s.EventTime, s.CreatedAt, s.UpdatedAt,
+				s.RatingFromRaw, s.RatingToRaw, s.RatingFromScore, s.RatingToScore,
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -85,11 +95,13 @@ func TestCockroachStockRepository_FindByTicker(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "ticker", "company_name", "brokerage", "action",
 			"rating_from", "rating_to", "target_from", "target_to",
-			"created_at", "updated_at",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
 		}).AddRow(
 			stockID, ticker, "Apple Inc.", "Test Brokerage", "target raised by",
 			"Buy", "Strong Buy", 100.0, 120.0,
-			now, now,
+			now, now, now,
+			"Buy", "Strong Buy", 3.0, 5.0,
 		)
 
 		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE ticker = \$1`).
@@ -101,6 +113,8 @@ func TestCockroachStockRepository_FindByTicker(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.Equal(t, ticker, result.Ticker)
 		assert.Equal(t, "Apple Inc.", result.CompanyName)
+		assert.Equal(t, "Strong Buy", result.RatingToRaw)
+		assert.Equal(t, 5.0, result.RatingToScore)
 	})
 
 	t.Run("stock not found", func(t *testing.T) {
@@ -119,6 +133,43 @@ func TestCockroachStockRepository_FindByTicker(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestCockroachStockRepository_FindByTickers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachStockRepository{db: db}
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "ticker", "company_name", "brokerage", "action",
+		"rating_from", "rating_to", "target_from", "target_to",
+		"event_time", "created_at", "updated_at",
+		"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+	}).AddRow(
+		uuid.New(), "AAPL", "Apple Inc.", "Test Brokerage", "target raised by",
+		"Buy", "Strong Buy", 100.0, 120.0,
+		now, now, now,
+		"Buy", "Strong Buy", 3.0, 5.0,
+	)
+
+	mock.ExpectQuery(`SELECT .+ FROM stocks WHERE ticker = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"AAPL", "MSFT"})).
+		WillReturnRows(rows)
+
+	result, err := repo.FindByTickers(context.Background(), []string{"AAPL", "MSFT"})
+	assert.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "AAPL", result[0].Ticker)
+
+	// Sin tickers no se consulta la base de datos
+	result, err = repo.FindByTickers(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCockroachStockRepository_FindAll(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -131,15 +182,18 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "ticker", "company_name", "brokerage", "action",
 			"rating_from", "rating_to", "target_from", "target_to",
-			"created_at", "updated_at",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
 		}).
 			AddRow(
 				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
-				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Buy", "Strong Buy", 100.0, 120.0, now, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
 			).
 			AddRow(
 				uuid.New(), "MSFT", "Microsoft Corp.", "Brokerage2", "target raised",
-				"Neutral", "Buy", 50.0, 60.0, now, now,
+				"Neutral", "Buy", 50.0, 60.0, now, now, now,
+				"Neutral", "Buy", 0.0, 3.0,
 			)
 
 		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY created_at DESC`).
@@ -148,21 +202,51 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		filter := stock.Filter{}
 		sort := stock.Sort{Field: "", Direction: ""}
 
-		result, err := repo.FindAll(context.Background(), filter, sort)
+		result, err := repo.FindAll(context.Background(), filter, sort, stock.Page{})
 		assert.NoError(t, err)
 		assert.Len(t, result, 2)
 	})
 
+	t.Run("rows with invalid stored prices are skipped", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}).
+			AddRow(
+				uuid.New(), "BAD", "Bad Row Inc.", "Brokerage1", "target raised by",
+				"Buy", "Buy", -5.0, 120.0, now, now, now,
+				"Buy", "Buy", 3.0, 3.0,
+			).
+			AddRow(
+				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
+				"Buy", "Strong Buy", 100.0, 120.0, now, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
+			)
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY created_at DESC`).
+			WillReturnRows(rows)
+
+		result, err := repo.FindAll(context.Background(), stock.Filter{}, stock.Sort{}, stock.Page{})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "AAPL", result[0].Ticker)
+	})
+
 	t.Run("find with ticker filter", func(t *testing.T) {
 		now := time.Now()
 		rows := sqlmock.NewRows([]string{
 			"id", "ticker", "company_name", "brokerage", "action",
 			"rating_from", "rating_to", "target_from", "target_to",
-			"created_at", "updated_at",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
 		}).
 			AddRow(
 				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
-				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Buy", "Strong Buy", 100.0, 120.0, now, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
 			)
 
 		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 AND ticker = \$1`).
@@ -172,7 +256,7 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		filter := stock.Filter{Ticker: "AAPL"}
 		sort := stock.Sort{Field: "", Direction: ""}
 
-		result, err := repo.FindAll(context.Background(), filter, sort)
+		result, err := repo.FindAll(context.Background(), filter, sort, stock.Page{})
 		assert.NoError(t, err)
 		assert.Len(t, result, 1)
 		assert.Equal(t, "AAPL", result[0].Ticker)
@@ -183,11 +267,13 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "ticker", "company_name", "brokerage", "action",
 			"rating_from", "rating_to", "target_from", "target_to",
-			"created_at", "updated_at",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
 		}).
 			AddRow(
 				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
-				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Buy", "Strong Buy", 100.0, 120.0, now, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
 			)
 
 		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 AND rating_to = ANY`).
@@ -197,9 +283,37 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		filter := stock.Filter{Ratings: []stock.Rating{stock.RatingStrongBuy}}
 		sort := stock.Sort{Field: "", Direction: ""}
 
-		result, err := repo.FindAll(context.Background(), filter, sort)
+		result, err := repo.FindAll(context.Background(), filter, sort, stock.Page{})
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("find with event time range and sort", func(t *testing.T) {
+		now := time.Now()
+		from := now.Add(-7 * 24 * time.Hour)
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}).
+			AddRow(
+				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
+				"Buy", "Strong Buy", 100.0, 120.0, now.Add(-time.Hour), now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
+			)
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 AND event_time >= \$1 AND event_time <= \$2 ORDER BY event_time DESC`).
+			WithArgs(from, now).
+			WillReturnRows(rows)
+
+		filter := stock.Filter{EventTimeFrom: from, EventTimeTo: now}
+		sort := stock.Sort{Field: "event_time", Direction: "desc"}
+
+		result, err := repo.FindAll(context.Background(), filter, sort, stock.Page{})
 		assert.NoError(t, err)
 		assert.Len(t, result, 1)
+		assert.Equal(t, now.Add(-time.Hour), result[0].EventTime)
 	})
 
 	t.Run("find with sort", func(t *testing.T) {
@@ -207,15 +321,18 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "ticker", "company_name", "brokerage", "action",
 			"rating_from", "rating_to", "target_from", "target_to",
-			"created_at", "updated_at",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
 		}).
 			AddRow(
 				uuid.New(), "AAPL", "Apple Inc.", "Brokerage1", "target raised by",
-				"Buy", "Strong Buy", 100.0, 120.0, now, now,
+				"Buy", "Strong Buy", 100.0, 120.0, now, now, now,
+				"Buy", "Strong Buy", 3.0, 5.0,
 			).
 			AddRow(
 				uuid.New(), "MSFT", "Microsoft Corp.", "Brokerage2", "target raised",
-				"Neutral", "Buy", 50.0, 60.0, now, now,
+				"Neutral", "Buy", 50.0, 60.0, now, now, now,
+				"Neutral", "Buy", 0.0, 3.0,
 			)
 
 		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY ticker ASC`).
@@ -224,11 +341,122 @@ func TestCockroachStockRepository_FindAll(t *testing.T) {
 		filter := stock.Filter{}
 		sort := stock.Sort{Field: "ticker", Direction: "asc"}
 
-		result, err := repo.FindAll(context.Background(), filter, sort)
+		result, err := repo.FindAll(context.Background(), filter, sort, stock.Page{})
 		assert.NoError(t, err)
 		assert.Len(t, result, 2)
 	})
 
+	t.Run("find page after cursor", func(t *testing.T) {
+		now := time.Now()
+		id := uuid.New()
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}).
+			AddRow(
+				uuid.New(), "MSFT", "Microsoft Corp.", "Brokerage2", "target raised",
+				"Neutral", "Buy", 50.0, 60.0, now, now, now,
+				"Neutral", "Buy", 0.0, 3.0,
+			)
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 AND rating_to = ANY\(ARRAY\[\$1\]\) AND \(target_to, id\) < \(\$2::DECIMAL, \$3::UUID\) ORDER BY target_to DESC, id DESC LIMIT \$4`).
+			WithArgs("Buy", "120.5", id, 11).
+			WillReturnRows(rows)
+
+		sort := stock.Sort{Field: "target_to", Direction: "desc"}
+		page := stock.Page{
+			Limit:  11,
+			Offset: 20, // Se ignora con cursor
+			After:  &stock.Cursor{Field: "target_to", Direction: "desc", Value: "120.5", ID: id},
+		}
+
+		result, err := repo.FindAll(context.Background(), stock.Filter{Ratings: []stock.Rating{stock.RatingBuy}}, sort, page)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("find page with offset", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		})
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY created_at DESC, id DESC LIMIT \$1 OFFSET \$2`).
+			WithArgs(10, 30).
+			WillReturnRows(rows)
+
+		result, err := repo.FindAll(context.Background(), stock.Filter{}, stock.Sort{}, stock.Page{Limit: 10, Offset: 30})
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("sort by computed fields", func(t *testing.T) {
+		columns := []string{
+			"id", "ticker", "company_name", "brokerage", "action",
+			"rating_from", "rating_to", "target_from", "target_to",
+			"event_time", "created_at", "updated_at",
+			"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		}
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY \(CASE WHEN target_from = 0 THEN 0 ELSE \(target_to - target_from\) / target_from \* 100 END\) DESC, id DESC$`).
+			WillReturnRows(sqlmock.NewRows(columns))
+		_, err := repo.FindAll(context.Background(), stock.Filter{}, stock.Sort{Field: stock.SortPriceChange, Direction: "desc"}, stock.Page{})
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 ORDER BY \(COALESCE\(rating_to_score, 0\) - COALESCE\(rating_from_score, 0\)\) ASC, id ASC$`).
+			WillReturnRows(sqlmock.NewRows(columns))
+		_, err = repo.FindAll(context.Background(), stock.Filter{}, stock.Sort{Field: stock.SortRatingDelta}, stock.Page{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("sort by score after cursor", func(t *testing.T) {
+		id := uuid.New()
+		at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		model := &scoring.Model{
+			RatingScores: map[string]float64{"Buy": 3},
+			ActionScores: map[string]float64{"upgraded by": 2},
+			UpgradeBonus: 1,
+			Weights:      scoring.Weights{PriceChange: 0.5, Rating: 0.3, Action: 0.2},
+			HalfLifeDays: 10,
+		}
+
+		// Filtro, scores de rating_to, rating_from y acción, bonus, decaimiento, pesos, cursor y límite
+		mock.ExpectQuery(`SELECT .+ FROM stocks WHERE 1=1 AND ticker = \$1 `+
+			`AND \(\(\(\(CASE WHEN target_from = 0 .+ END\)::FLOAT8 \* \$11::FLOAT8 .+ power\(0\.5::FLOAT8, .+\), id\) < `+
+			`\(\(SELECT .+ FROM stocks WHERE id = \$14::UUID\), \$14::UUID\) ORDER BY .+ DESC, id DESC LIMIT \$15$`).
+			WithArgs("AAPL",
+				"Buy", 3.0,
+				"Buy", 3.0,
+				"upgraded by", 2.0,
+				1.0,
+				at, (10 * 24 * time.Hour).Seconds(),
+				0.5, 0.3, 0.2,
+				id, 6).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "ticker", "company_name", "brokerage", "action",
+				"rating_from", "rating_to", "target_from", "target_to",
+				"event_time", "created_at", "updated_at",
+				"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+			}))
+
+		page := stock.Page{Limit: 6, After: &stock.Cursor{Field: stock.SortScore, Direction: "desc", ID: id, At: at}}
+		sort := stock.Sort{Field: stock.SortScore, Direction: "desc", Model: model, At: time.Now()}
+
+		_, err := repo.FindAll(context.Background(), stock.Filter{Ticker: "AAPL"}, sort, page)
+		assert.NoError(t, err)
+	})
+
+	t.Run("cursor from another sort", func(t *testing.T) {
+		page := stock.Page{After: &stock.Cursor{Field: "ticker", Direction: "asc", Value: "AAPL", ID: uuid.New()}}
+
+		_, err := repo.FindAll(context.Background(), stock.Filter{}, stock.Sort{Field: "event_time"}, page)
+		assert.ErrorIs(t, err, stock.ErrInvalidCursor)
+	})
+
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -264,5 +492,130 @@ func TestCockroachStockRepository_Count(t *testing.T) {
 		assert.Equal(t, 5, count)
 	})
 
+	t.Run("count with composed filter", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"count"}).AddRow(3)
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM stocks WHERE 1=1 `+
+			`AND ticker ILIKE \$1 `+
+			`AND lower\(trim\(brokerage\)\) = ANY\(ARRAY\[\$2,\$3\]\) `+
+			`AND target_to >= \$4 `+
+			`AND \(CASE WHEN target_from = 0 THEN 0 ELSE \(target_to - target_from\) / target_from \* 100 END\) >= \$5 `+
+			`AND rating_to_score > rating_from_score `+
+			`AND \(\(ticker = \$6\) OR \(event_time >= \$7 AND target_from <= \$8\)\) `+
+			`AND NOT \(rating_to = ANY\(ARRAY\[\$9\]\)\)$`).
+			WithArgs(`A\_B%`, "goldman sachs", "ubs", 50.0, 10.0, "MSFT", sqlmock.AnyArg(), 200.0, "Sell").
+			WillReturnRows(rows)
+
+		minTarget, minChange, maxFrom := 50.0, 10.0, 200.0
+		filter := stock.Filter{
+			TickerPrefix:    "A_B",
+			Brokerages:      []string{"Goldman Sachs", " UBS "},
+			TargetToMin:     &minTarget,
+			MinTargetChange: &minChange,
+			RatingChange:    stock.RatingChangeUpgrade,
+			Or: []stock.Filter{
+				{Ticker: "MSFT"},
+				{EventTimeFrom: time.Now().Add(-24 * time.Hour), TargetFromMax: &maxFrom},
+			},
+			Not: &stock.Filter{Ratings: []stock.Rating{stock.RatingSell}},
+		}
+		count, err := repo.Count(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachStockRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachStockRepository{db: db}
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "ticker", "company_name", "brokerage", "action",
+		"rating_from", "rating_to", "target_from", "target_to",
+		"event_time", "created_at", "updated_at",
+		"rating_from_raw", "rating_to_raw", "rating_from_score", "rating_to_score",
+		"ticker_score", "company_name_score", "brokerage_score",
+	}).
+		AddRow(
+			uuid.New(), "MSFT", "Microsoft Corporation", "Morgan Stanley", "target raised by",
+			"Buy", "Buy", 100.0, 120.0, now, now, now,
+			"Buy", "Buy", 3.0, 3.0,
+			0.0, 0.42, 0.05,
+		)
+
+	mock.ExpectQuery(`SELECT .+, \(CASE WHEN lower\(COALESCE\(ticker, ''\)\) = lower\(\$1\) THEN 1.0 `+
+		`WHEN COALESCE\(ticker, ''\) ILIKE \$2 THEN 0.9 WHEN COALESCE\(ticker, ''\) ILIKE \$3 THEN 0.75 `+
+		`ELSE similarity\(COALESCE\(ticker, ''\), \$1\) \* 0.7 END \* 1\), .+ FROM stocks `+
+		`WHERE ticker ILIKE \$3 OR ticker % \$1 OR company_name ILIKE \$3 OR company_name % \$1 `+
+		`OR brokerage ILIKE \$3 OR brokerage % \$1 ORDER BY GREATEST\(.+\) DESC, ticker ASC LIMIT \$4$`).
+		WithArgs("micro_soft", `micro\_soft%`, `%micro\_soft%`, 5).
+		WillReturnRows(rows)
+
+	results, err := repo.Search(context.Background(), "micro_soft", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "MSFT", results[0].Stock.Ticker)
+	assert.Equal(t, stock.SearchFieldCompanyName, results[0].Field)
+	assert.Equal(t, 0.42, results[0].Score)
+	assert.Equal(t, []stock.TextRange{{Start: 0, End: 9}}, results[0].Highlights)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachStockRepository_Stats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachStockRepository{db: db}
+
+	summary := sqlmock.NewRows([]string{"total", "upgrades", "downgrades", "b0", "b1", "b2", "b3", "b4", "b5", "b6", "b7"}).
+		AddRow(12, 5, 2, 1, 0, 2, 1, 3, 2, 2, 1)
+	mock.ExpectQuery(`SELECT COUNT\(\*\), `+
+		`COUNT\(\*\) FILTER \(WHERE rating_to_score > rating_from_score\), `+
+		`COUNT\(\*\) FILTER \(WHERE rating_to_score < rating_from_score\), `+
+		`COUNT\(\*\) FILTER \(WHERE \(CASE .+ END\) < -20\), `+
+		`COUNT\(\*\) FILTER \(WHERE \(CASE .+ END\) >= -20 AND \(CASE .+ END\) < -10\), `+
+		`.+`+
+		`COUNT\(\*\) FILTER \(WHERE \(CASE .+ END\) >= 20\) `+
+		`FROM stocks WHERE 1=1 AND ticker ILIKE \$1$`).
+		WithArgs("A%").
+		WillReturnRows(summary)
+
+	groups := sqlmock.NewRows([]string{"grp", "value", "count"}).
+		AddRow("action", "target raised by", 7).
+		AddRow("brokerage", "UBS Group", 12).
+		AddRow("rating", "Buy", 8).
+		AddRow("rating", "Sell", 4)
+	mock.ExpectQuery(`SELECT 'rating' AS grp, rating_to AS value, COUNT\(\*\) AS count FROM stocks WHERE 1=1 AND ticker ILIKE \$1 GROUP BY rating_to `+
+		`UNION ALL SELECT 'action' .+ GROUP BY COALESCE\(action, ''\) `+
+		`UNION ALL SELECT 'brokerage' .+ GROUP BY COALESCE\(brokerage, ''\) `+
+		`ORDER BY grp, count DESC, value ASC$`).
+		WithArgs("A%").
+		WillReturnRows(groups)
+
+	stats, err := repo.Stats(context.Background(), stock.Filter{TickerPrefix: "A"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, stats.Total)
+	assert.Equal(t, 5, stats.Upgrades)
+	assert.Equal(t, 2, stats.Downgrades)
+	assert.Equal(t, []stock.GroupCount{{Value: "Buy", Count: 8}, {Value: "Sell", Count: 4}}, stats.ByRating)
+	assert.Equal(t, []stock.GroupCount{{Value: "target raised by", Count: 7}}, stats.ByAction)
+	assert.Equal(t, []stock.GroupCount{{Value: "UBS Group", Count: 12}}, stats.ByBrokerage)
+
+	require.Len(t, stats.TargetChanges, 8)
+	counts := make([]int, len(stats.TargetChanges))
+	for i, b := range stats.TargetChanges {
+		counts[i] = b.Count
+	}
+	assert.Equal(t, []int{1, 0, 2, 1, 3, 2, 2, 1}, counts)
+
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+	"github.com/john/go-react-test/api/internal/infrastructure/database"
+)
+
+// CockroachSyncRunRepository implementa el ledger de sincronizaciones para CockroachDB
+type CockroachSyncRunRepository struct {
+	db *sql.DB
+}
+
+// NewCockroachSyncRunRepository crea un nuevo repositorio de ejecuciones de sincronización
+func NewCockroachSyncRunRepository() syncrun.Repository {
+	return &CockroachSyncRunRepository{
+		db: database.GetDB(),
+	}
+}
+
+// syncRunColumns son las columnas seleccionadas para construir una entidad Run
+const syncRunColumns = `id, status, triggered_by, mode, pages_fetched, rows_upserted, rows_rejected,
+		       error, instance_id, newest_event_time, created_at, started_at, finished_at`
+
+// Create registra una nueva ejecución
+func (r *CockroachSyncRunRepository) Create(ctx context.Context, run *syncrun.Run) error {
+	query := `
+		INSERT INTO sync_runs (
+			id, status, triggered_by, mode, pages_fetched, rows_upserted, rows_rejected,
+			error, instance_id, newest_event_time, created_at, started_at, finished_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		run.ID,
+		run.Status.String(),
+		run.Trigger.String(),
+		run.Mode.String(),
+		run.PagesFetched,
+		run.RowsUpserted,
+		run.RowsRejected,
+		run.Error,
+		run.Instance,
+		run.NewestEventTime,
+		run.CreatedAt,
+		run.StartedAt,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sync run: %w", err)
+	}
+
+	return nil
+}
+
+// Update actualiza el estado y los contadores de una ejecución
+func (r *CockroachSyncRunRepository) Update(ctx context.Context, run *syncrun.Run) error {
+	query := `
+		UPDATE sync_runs SET
+			status = $2,
+			mode = $3,
+			pages_fetched = $4,
+			rows_upserted = $5,
+			rows_rejected = $6,
+			error = $7,
+			newest_event_time = $8,
+			started_at = $9,
+			finished_at = $10
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		run.ID,
+		run.Status.String(),
+		run.Mode.String(),
+		run.PagesFetched,
+		run.RowsUpserted,
+		run.RowsRejected,
+		run.Error,
+		run.NewestEventTime,
+		run.StartedAt,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sync run: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID busca una ejecución por ID
+func (r *CockroachSyncRunRepository) FindByID(ctx context.Context, id uuid.UUID) (*syncrun.Run, error) {
+	query := "SELECT " + syncRunColumns + " FROM sync_runs WHERE id = $1"
+
+	run, err := scanSyncRun(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, syncrun.ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sync run: %w", err)
+	}
+
+	return run, nil
+}
+
+// FindRecent retorna las últimas ejecuciones, de la más reciente a la más antigua
+func (r *CockroachSyncRunRepository) FindRecent(ctx context.Context, limit int) ([]*syncrun.Run, error) {
+	query := "SELECT " + syncRunColumns + " FROM sync_runs ORDER BY created_at DESC LIMIT $1"
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*syncrun.Run
+	for rows.Next() {
+		run, err := scanSyncRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return runs, nil
+}
+
+// FindLatest retorna la ejecución más reciente con el estado indicado
+func (r *CockroachSyncRunRepository) FindLatest(ctx context.Context, status syncrun.Status) (*syncrun.Run, error) {
+	query := "SELECT " + syncRunColumns + " FROM sync_runs"
+	args := []interface{}{}
+
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status.String())
+	}
+	query += " ORDER BY created_at DESC LIMIT 1"
+
+	run, err := scanSyncRun(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, syncrun.ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find latest sync run: %w", err)
+	}
+
+	return run, nil
+}
+
+// FailUnfinished marca como fallidas las ejecuciones pendientes o en curso de un proceso
+// y las abandonadas por cualquier proceso
+func (r *CockroachSyncRunRepository) FailUnfinished(ctx context.Context, instance string, staleBefore time.Time, reason string) (int, error) {
+	query := `
+		UPDATE sync_runs SET
+			status = $1,
+			error = $2,
+			finished_at = now()
+		WHERE status IN ($3, $4) AND (instance_id = $5 OR created_at < $6)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		syncrun.StatusFailed.String(),
+		reason,
+		syncrun.StatusPending.String(),
+		syncrun.StatusRunning.String(),
+		instance,
+		staleBefore,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished sync runs: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+// scanSyncRun escanea una fila de sync_runs y la convierte en entidad de dominio
+func scanSyncRun(row rowScanner) (*syncrun.Run, error) {
+	var run syncrun.Run
+	var status, trigger, mode string
+	var newestEventTime, startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&run.ID,
+		&status,
+		&trigger,
+		&mode,
+		&run.PagesFetched,
+		&run.RowsUpserted,
+		&run.RowsRejected,
+		&run.Error,
+		&run.Instance,
+		&newestEventTime,
+		&run.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	run.Status = syncrun.Status(status)
+	run.Trigger = syncrun.Trigger(trigger)
+	run.Mode = syncrun.Mode(mode)
+	if newestEventTime.Valid {
+		run.NewestEventTime = &newestEventTime.Time
+	}
+	if startedAt.Valid {
+		run.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return &run, nil
+}
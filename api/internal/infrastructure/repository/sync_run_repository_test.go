@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/john/go-react-test/api/internal/domain/syncrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachSyncRunRepository_CreateAndUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachSyncRunRepository{db: db}
+	run := syncrun.NewRun("api-1", syncrun.TriggerManual, syncrun.ModeIncremental)
+
+	t.Run("create pending run", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO sync_runs`).
+			WithArgs(run.ID, "pending", "manual", "incremental", 0, 0, 0, "", "api-1", nil, run.CreatedAt, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.Create(context.Background(), run)
+		assert.NoError(t, err)
+	})
+
+	t.Run("record finished run", func(t *testing.T) {
+		run.Start()
+		run.Succeed(3, 250, 2, time.Now())
+
+		mock.ExpectExec(`UPDATE sync_runs SET`).
+			WithArgs(run.ID, "succeeded", "incremental", 3, 250, 2, "", run.NewestEventTime, run.StartedAt, run.FinishedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Update(context.Background(), run)
+		assert.NoError(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachSyncRunRepository_FindLatest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachSyncRunRepository{db: db}
+	columns := []string{
+		"id", "status", "triggered_by", "mode", "pages_fetched", "rows_upserted", "rows_rejected",
+		"error", "instance_id", "newest_event_time", "created_at", "started_at", "finished_at",
+	}
+
+	t.Run("latest succeeded run", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows(columns).
+			AddRow(uuid.New(), "succeeded", "scheduled", "incremental", 5, 500, 1, "", "api-1", now, now, now, now)
+
+		mock.ExpectQuery(`SELECT .+ FROM sync_runs WHERE status = \$1 ORDER BY created_at DESC LIMIT 1`).
+			WithArgs("succeeded").
+			WillReturnRows(rows)
+
+		run, err := repo.FindLatest(context.Background(), syncrun.StatusSucceeded)
+		assert.NoError(t, err)
+		assert.Equal(t, syncrun.TriggerScheduled, run.Trigger)
+		assert.Equal(t, 500, run.RowsUpserted)
+		assert.Equal(t, syncrun.ModeIncremental, run.Mode)
+		assert.NotNil(t, run.NewestEventTime)
+		assert.NotNil(t, run.FinishedAt)
+		assert.Equal(t, "api-1", run.Instance)
+	})
+
+	t.Run("pending run has no timestamps", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(uuid.New(), "pending", "manual", "full", 0, 0, 0, "", "api-1", nil, time.Now(), nil, nil)
+
+		mock.ExpectQuery(`SELECT .+ FROM sync_runs ORDER BY created_at DESC LIMIT 1`).
+			WillReturnRows(rows)
+
+		run, err := repo.FindLatest(context.Background(), "")
+		assert.NoError(t, err)
+		assert.Nil(t, run.StartedAt)
+		assert.Nil(t, run.FinishedAt)
+	})
+
+	t.Run("no runs yet", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM sync_runs ORDER BY created_at DESC LIMIT 1`).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		run, err := repo.FindLatest(context.Background(), "")
+		assert.True(t, errors.Is(err, syncrun.ErrRunNotFound))
+		assert.Nil(t, run)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCockroachSyncRunRepository_FailUnfinished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &CockroachSyncRunRepository{db: db}
+	staleBefore := time.Now().Add(-30 * time.Minute)
+
+	// Solo las ejecuciones de esta instancia o las abandonadas por cualquiera
+	mock.ExpectExec(`UPDATE sync_runs SET .+ WHERE status IN \(\$3, \$4\) AND \(instance_id = \$5 OR created_at < \$6\)`).
+		WithArgs("failed", "interrupted by server restart", "pending", "running", "api-1", staleBefore).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repo.FailUnfinished(context.Background(), "api-1", staleBefore, "interrupted by server restart")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,466 @@
+// Package websocket implementa el lado servidor del protocolo WebSocket (RFC 6455)
+// necesario para las suscripciones GraphQL: handshake con verificación de Origin, mensajes
+// de texto fragmentados, ping/pong con plazo de inactividad y cierre. No soporta extensiones
+// (compresión). Usa solo la biblioteca estándar porque el módulo no puede sumar dependencias;
+// ver "WebSocket" en docs/ARCHITECTURE.md.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Códigos de cierre (RFC 6455, sección 7.4.1)
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseMessageTooBig   = 1009
+)
+
+// DefaultMaxMessageSize es el tamaño máximo por defecto de un mensaje recibido
+const DefaultMaxMessageSize = 64 << 10
+
+// writeTimeout es el plazo para escribir un frame antes de dar la conexión por perdida
+const writeTimeout = 10 * time.Second
+
+// DefaultPingInterval es cada cuánto se envía un ping de control por defecto
+const DefaultPingInterval = 25 * time.Second
+
+// DefaultIdleTimeout es el plazo por defecto sin recibir ningún frame (ni el pong de un ping)
+// tras el cual se cierra la conexión; debe ser mayor que el intervalo de ping
+const DefaultIdleTimeout = 60 * time.Second
+
+// handshakeGUID es el valor fijo que se concatena a Sec-WebSocket-Key para calcular Sec-WebSocket-Accept
+const handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes de los frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+var (
+	// ErrBadHandshake indica una petición que no es un handshake WebSocket válido
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+
+	// ErrClosed indica que ya se envió el frame de cierre
+	ErrClosed = errors.New("websocket: connection closed")
+
+	// ErrIdleTimeout indica que el cliente no envió ningún frame dentro del plazo de inactividad
+	ErrIdleTimeout = errors.New("websocket: idle timeout")
+)
+
+// Options configura el handshake y la supervisión de una conexión
+type Options struct {
+	// Protocols son los subprotocolos aceptados en orden de preferencia: si el cliente
+	// ofrece subprotocolos y ninguno está en la lista se rechaza el handshake
+	Protocols []string
+
+	// AllowedOrigins son los orígenes (ej: https://app.example.com) desde los que un navegador
+	// puede abrir la conexión; "*" acepta cualquiera. El mismo host de la petición y las
+	// peticiones sin Origin (clientes que no son navegadores) se aceptan siempre.
+	AllowedOrigins []string
+
+	// PingInterval es cada cuánto se envía un ping de control (cero usa DefaultPingInterval)
+	PingInterval time.Duration
+
+	// IdleTimeout es el plazo sin recibir frames tras el cual se cierra la conexión
+	// (cero usa DefaultIdleTimeout); los pong que responde el cliente lo renuevan
+	IdleTimeout time.Duration
+}
+
+// CloseError es el cierre de la conexión, iniciado por el cliente o por un error de protocolo
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implementa la interfaz error
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// Conn es una conexión WebSocket del lado servidor.
+// Las escrituras pueden hacerse desde varias goroutines; las lecturas desde una sola.
+type Conn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	protocol       string
+	maxMessageSize int64
+	idleTimeout    time.Duration
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	closeOnce sync.Once
+	done      chan struct{} // Se cierra con la conexión; detiene los ping
+}
+
+// IsUpgradeRequest retorna true si r pide cambiar la conexión a WebSocket
+func IsUpgradeRequest(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completa el handshake, toma el control de la conexión HTTP y comienza a enviar ping.
+// Si falla, ya se respondió al cliente con el error HTTP correspondiente.
+func Upgrade(w http.ResponseWriter, r *http.Request, opts Options) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsUpgradeRequest(r) {
+		http.Error(w, "WebSocket handshake expected", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, ErrBadHandshake
+	}
+
+	// Los navegadores no aplican CORS a WebSocket: sin esta verificación cualquier sitio
+	// podría abrir una conexión con las cookies del usuario
+	if !originAllowed(r, opts.AllowedOrigins) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, ErrBadHandshake
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "Invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	protocol := selectProtocol(offered, opts.Protocols)
+	if len(offered) > 0 && protocol == "" {
+		http.Error(w, "Unsupported WebSocket subprotocol", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: response does not implement http.Hijacker")
+	}
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+
+	// Los timeouts del servidor HTTP no aplican a una conexión de larga duración
+	netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n"
+	if protocol != "" {
+		response += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	response += "\r\n"
+
+	netConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: failed to write handshake: %w", err)
+	}
+
+	pingInterval := opts.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = DefaultPingInterval
+	}
+	idleTimeout := opts.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+
+	c := &Conn{
+		conn:           netConn,
+		br:             brw.Reader,
+		protocol:       protocol,
+		maxMessageSize: DefaultMaxMessageSize,
+		idleTimeout:    idleTimeout,
+		done:           make(chan struct{}),
+	}
+	go c.ping(pingInterval)
+	return c, nil
+}
+
+// originAllowed retorna true si el Origin de r está permitido
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimRight(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ping envía un ping de control cada interval hasta que se cierre la conexión; el pong
+// del cliente renueva el plazo de inactividad de ReadMessage
+func (c *Conn) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// AcceptKey calcula el valor de Sec-WebSocket-Accept para la clave del cliente
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + handshakeGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Subprotocol retorna el subprotocolo negociado ("" si el cliente no pidió ninguno)
+func (c *Conn) Subprotocol() string {
+	return c.protocol
+}
+
+// SetMaxMessageSize limita el tamaño de los mensajes recibidos
+func (c *Conn) SetMaxMessageSize(n int64) {
+	c.maxMessageSize = n
+}
+
+// ReadMessage lee el siguiente mensaje de datos, uniendo sus fragmentos.
+// Responde a los ping automáticamente. Si el cliente cierra la conexión o viola
+// el protocolo, responde con el cierre y retorna un *CloseError; si no envía ningún
+// frame dentro del plazo de inactividad, cierra la conexión y retorna ErrIdleTimeout.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	started := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			c.WriteClose(CloseGoingAway, "idle timeout")
+			return nil, ErrIdleTimeout
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			code, reason := CloseNoStatus, ""
+			if len(payload) >= 2 {
+				code, reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			replyCode := code
+			if replyCode == CloseNoStatus {
+				replyCode = CloseNormal
+			}
+			c.WriteClose(replyCode, "")
+			return nil, &CloseError{Code: code, Reason: reason}
+		case opText, opBinary:
+			if started {
+				return nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			started = true
+			message = payload
+		case opContinuation:
+			if !started {
+				return nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			message = append(message, payload...)
+		default:
+			return nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(message)) > c.maxMessageSize {
+			return nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame lee un frame del cliente y retorna su payload desenmascarado.
+// Cada frame debe llegar completo dentro del plazo de inactividad.
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= opClose && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(c.maxMessageSize) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage envía un mensaje de texto
+func (c *Conn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// WriteClose envía el frame de cierre; después no se pueden enviar más mensajes
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	return c.writeFrame(opClose, payload)
+}
+
+// Close cierra la conexión de red sin enviar el frame de cierre y deja de enviar ping
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return c.conn.Close()
+}
+
+// fail cierra la conexión por un error de protocolo y retorna el error correspondiente
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// writeFrame envía un frame completo (sin fragmentar ni enmascarar, como corresponde al servidor)
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	if opcode == opClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// headerTokens retorna los valores separados por comas de un header
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken retorna true si el header incluye token sin distinguir mayúsculas
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectProtocol retorna el primer subprotocolo aceptado que ofrece el cliente
+func selectProtocol(offered, accepted []string) string {
+	for _, a := range accepted {
+		for _, o := range offered {
+			if o == a {
+				return a
+			}
+		}
+	}
+	return ""
+}
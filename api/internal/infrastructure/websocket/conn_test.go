@@ -0,0 +1,321 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient es un cliente WebSocket mínimo para probar el servidor
+type testClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+func dial(t *testing.T, server *httptest.Server, protocol string) *testClient {
+	t.Helper()
+	return dialOrigin(t, server, protocol, "")
+}
+
+// dialOrigin abre la conexión como un navegador en origin ("" omite el header Origin)
+func dialOrigin(t *testing.T, server *httptest.Server, protocol, origin string) *testClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if protocol != "" {
+		request += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	if origin != "" {
+		request += "Origin: " + origin + "\r\n"
+	}
+	_, err = conn.Write([]byte(request + "\r\n"))
+	require.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	return &testClient{conn: conn, br: br, resp: resp}
+}
+
+// writeFrame envía un frame enmascarado (o sin máscara si masked es false)
+func (c *testClient) writeFrame(t *testing.T, fin bool, opcode byte, payload []byte, masked bool) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	data := append([]byte(nil), payload...)
+	if masked {
+		mask := []byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask...)
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	_, err := c.conn.Write(append(frame, data...))
+	require.NoError(t, err)
+}
+
+// readFrame lee un frame del servidor
+func (c *testClient) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+
+	var header [2]byte
+	_, err := io.ReadFull(c.br, header[:])
+	require.NoError(t, err)
+	require.Zero(t, header[1]&0x80, "server frames must not be masked")
+
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, err := io.ReadFull(c.br, ext[:])
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0F, payload
+}
+
+// echoServer responde cada mensaje con el mismo contenido y registra el error final
+func echoServer(t *testing.T, maxSize int64, done chan<- error) *httptest.Server {
+	return echoServerWithOptions(t, Options{Protocols: []string{"graphql-transport-ws", "graphql-ws"}}, maxSize, done)
+}
+
+// echoServerWithOptions es echoServer con opciones de conexión propias
+func echoServerWithOptions(t *testing.T, opts Options, maxSize int64, done chan<- error) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, opts)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if maxSize > 0 {
+			conn.SetMaxMessageSize(maxSize)
+		}
+
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			conn.WriteMessage(msg)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Ejemplo de la RFC 6455, sección 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestUpgrade_Handshake(t *testing.T) {
+	done := make(chan error, 1)
+	server := echoServer(t, 0, done)
+
+	client := dial(t, server, "graphql-ws, graphql-transport-ws")
+	assert.Equal(t, http.StatusSwitchingProtocols, client.resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", client.resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "graphql-transport-ws", client.resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	client = dial(t, server, "mqtt")
+	assert.Equal(t, http.StatusBadRequest, client.resp.StatusCode)
+}
+
+func TestUpgrade_Origin(t *testing.T) {
+	server := echoServerWithOptions(t, Options{AllowedOrigins: []string{"https://app.example.com/"}}, 0, make(chan error, 1))
+
+	tests := []struct {
+		origin string
+		status int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"https://app.example.com", http.StatusSwitchingProtocols},
+		{"http://localhost", http.StatusSwitchingProtocols}, // Mismo host que la petición
+		{"https://evil.example.com", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		client := dialOrigin(t, server, "", tt.origin)
+		assert.Equal(t, tt.status, client.resp.StatusCode, tt.origin)
+	}
+
+	server = echoServerWithOptions(t, Options{AllowedOrigins: []string{"*"}}, 0, make(chan error, 1))
+	client := dialOrigin(t, server, "", "https://evil.example.com")
+	assert.Equal(t, http.StatusSwitchingProtocols, client.resp.StatusCode)
+}
+
+func TestUpgrade_RejectsPlainRequests(t *testing.T) {
+	server := echoServer(t, 0, make(chan error, 1))
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestConn_Messages(t *testing.T) {
+	done := make(chan error, 1)
+	server := echoServer(t, 0, done)
+	client := dial(t, server, "")
+
+	// Mensaje simple
+	client.writeFrame(t, true, opText, []byte(`{"type":"ping"}`), true)
+	opcode, payload := client.readFrame(t)
+	assert.Equal(t, byte(opText), opcode)
+	assert.Equal(t, `{"type":"ping"}`, string(payload))
+
+	// Mensaje fragmentado con un ping intercalado
+	client.writeFrame(t, false, opText, []byte("hola "), true)
+	client.writeFrame(t, true, opPing, []byte("p"), true)
+	client.writeFrame(t, true, opContinuation, []byte("mundo"), true)
+	opcode, payload = client.readFrame(t)
+	assert.Equal(t, byte(opPong), opcode)
+	assert.Equal(t, "p", string(payload))
+	opcode, payload = client.readFrame(t)
+	assert.Equal(t, byte(opText), opcode)
+	assert.Equal(t, "hola mundo", string(payload))
+
+	// Mensaje con longitud extendida de 16 bits
+	long := strings.Repeat("x", 300)
+	client.writeFrame(t, true, opText, []byte(long), true)
+	_, payload = client.readFrame(t)
+	assert.Equal(t, long, string(payload))
+
+	// Cierre iniciado por el cliente
+	client.writeFrame(t, true, opClose, binary.BigEndian.AppendUint16(nil, CloseGoingAway), true)
+	opcode, payload = client.readFrame(t)
+	assert.Equal(t, byte(opClose), opcode)
+	assert.Equal(t, uint16(CloseGoingAway), binary.BigEndian.Uint16(payload))
+
+	var closeErr *CloseError
+	require.True(t, errors.As(<-done, &closeErr))
+	assert.Equal(t, CloseGoingAway, closeErr.Code)
+}
+
+func TestConn_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		send    func(t *testing.T, c *testClient)
+		code    int
+	}{
+		{"frame sin máscara", 0, func(t *testing.T, c *testClient) {
+			c.writeFrame(t, true, opText, []byte("x"), false)
+		}, CloseProtocolError},
+		{"continuación sin mensaje", 0, func(t *testing.T, c *testClient) {
+			c.writeFrame(t, true, opContinuation, []byte("x"), true)
+		}, CloseProtocolError},
+		{"ping fragmentado", 0, func(t *testing.T, c *testClient) {
+			c.writeFrame(t, false, opPing, []byte("x"), true)
+		}, CloseProtocolError},
+		{"mensaje demasiado grande", 8, func(t *testing.T, c *testClient) {
+			c.writeFrame(t, false, opText, []byte("12345"), true)
+			c.writeFrame(t, true, opContinuation, []byte("67890"), true)
+		}, CloseMessageTooBig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			client := dial(t, echoServer(t, tt.maxSize, done), "")
+
+			tt.send(t, client)
+			opcode, payload := client.readFrame(t)
+			assert.Equal(t, byte(opClose), opcode)
+			assert.Equal(t, uint16(tt.code), binary.BigEndian.Uint16(payload))
+
+			var closeErr *CloseError
+			require.True(t, errors.As(<-done, &closeErr))
+			assert.Equal(t, tt.code, closeErr.Code)
+		})
+	}
+}
+
+func TestConn_IdleTimeout(t *testing.T) {
+	opts := Options{PingInterval: 20 * time.Millisecond, IdleTimeout: 100 * time.Millisecond}
+
+	t.Run("pongs keep the connection open", func(t *testing.T) {
+		done := make(chan error, 1)
+		client := dial(t, echoServerWithOptions(t, opts, 0, done), "")
+
+		// El servidor envía ping y el cliente responde durante varios plazos de inactividad
+		deadline := time.Now().Add(3 * opts.IdleTimeout)
+		for time.Now().Before(deadline) {
+			opcode, payload := client.readFrame(t)
+			require.Equal(t, byte(opPing), opcode)
+			client.writeFrame(t, true, opPong, payload, true)
+		}
+
+		client.writeFrame(t, true, opText, []byte("still here"), true)
+		for {
+			opcode, payload := client.readFrame(t)
+			if opcode == opPing {
+				continue
+			}
+			assert.Equal(t, byte(opText), opcode)
+			assert.Equal(t, "still here", string(payload))
+			break
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("connection closed: %v", err)
+		default:
+		}
+	})
+
+	t.Run("silent clients are disconnected", func(t *testing.T) {
+		done := make(chan error, 1)
+		client := dial(t, echoServerWithOptions(t, opts, 0, done), "")
+
+		for {
+			opcode, payload := client.readFrame(t)
+			if opcode == opPing {
+				continue
+			}
+			assert.Equal(t, byte(opClose), opcode)
+			assert.Equal(t, uint16(CloseGoingAway), binary.BigEndian.Uint16(payload))
+			break
+		}
+		assert.ErrorIs(t, <-done, ErrIdleTimeout)
+	})
+}
@@ -1,5 +1,5 @@
 import { ref, computed } from 'vue';
-import { graphqlClient, GET_STOCKS_QUERY, GET_STOCK_QUERY, GET_RECOMMENDATIONS_QUERY, SYNC_STOCKS_MUTATION, type Stock, type StockFilter, type StockSort, type StockConnection, type Recommendation } from '@/utils/api';
+import { graphqlClient, GET_STOCKS_QUERY, GET_STOCK_QUERY, GET_RECOMMENDATIONS_QUERY, SYNC_STOCKS_MUTATION, type Stock, type SyncStocksResult, type StockFilter, type StockSort, type StockConnection, type Recommendation } from '@/utils/api';
 
 interface CacheEntry<T> {
   data: T;
@@ -181,7 +181,7 @@ export function useApi() {
     }
   };
 
-  const syncStocks = async (): Promise<{ success: boolean; message: string; stocksSynced: number }> => {
+  const syncStocks = async (): Promise<SyncStocksResult> => {
     loading.value = true;
     error.value = null;
 
@@ -197,7 +197,7 @@ export function useApi() {
       // Limpiar cache después de sincronizar
       cache.clear();
 
-      return result.data?.syncStocks || { success: false, message: 'Error desconocido', stocksSynced: 0 };
+      return result.data?.syncStocks || { success: false, message: 'Error desconocido' };
     } catch (err) {
       error.value = err instanceof Error ? err.message : 'Error desconocido';
       throw err;
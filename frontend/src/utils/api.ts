@@ -17,11 +17,29 @@ const getGraphQLEndpoint = () => {
 
 const GRAPHQL_ENDPOINT = getGraphQLEndpoint();
 
+// Sin credenciales: el bundle es público, así que las peticiones usan el rol anónimo (viewer)
 export const graphqlClient = new Client({
   url: GRAPHQL_ENDPOINT,
   exchanges: [cacheExchange, fetchExchange],
 });
 
+// Roles del API, de menor a mayor: cada uno incluye los permisos de los anteriores
+export type Role = "viewer" | "operator" | "admin";
+const ROLE_LEVELS: Record<Role, number> = { viewer: 1, operator: 2, admin: 3 };
+
+// Rol con el que el frontend llama al API: sin credenciales siempre es el anónimo
+export const CLIENT_ROLE: Role = "viewer";
+
+// Retorna true si el rol del cliente alcanza para las operaciones que requieren required
+export const hasRole = (required: Role, role: Role = CLIENT_ROLE): boolean =>
+  ROLE_LEVELS[role] >= ROLE_LEVELS[required];
+
+// Mensaje para el usuario de un error del API; los errores de autorización se explican
+export const describeApiError = (message: string): string =>
+  /forbidden|unauthorized/i.test(message)
+    ? "No tienes permisos para esta operación: se requiere un rol de operador o superior."
+    : message;
+
 // Tipos para las queries GraphQL
 export interface Stock {
   id: string;
@@ -74,6 +92,14 @@ export interface Recommendation {
   actionScore: number;
 }
 
+// La sincronización es asíncrona: runId identifica la ejecución encolada
+export interface SyncStocksResult {
+  success: boolean;
+  message: string;
+  runId?: string;
+  status?: string;
+}
+
 // Queries GraphQL
 export const GET_STOCKS_QUERY = `
   query GetStocks($filter: StockFilter, $sort: StockSort, $limit: Int, $offset: Int) {
@@ -147,7 +173,8 @@ export const SYNC_STOCKS_MUTATION = `
     syncStocks {
       success
       message
-      stocksSynced
+      runId
+      status
     }
   }
 `;